@@ -0,0 +1,238 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+)
+
+// defaultFilters is used when the portal does not expose a filter list.
+// These are the two filter IDs VehicleStateTableForBranchEx is known to accept.
+var defaultFilters = []storage.Filter{
+	{FilterID: IncludeDeletedFilterID, FilterName: "全車両（削除済みを含む）"},
+	{FilterID: "0", FilterName: "稼働車両（削除済みを除く）"},
+}
+
+// GetBranches returns the branches available to the logged-in account.
+// Cached results are used until DiscoveryTTL expires unless refresh is set.
+func (r *Renderer) GetBranches(ctx context.Context, sessionID string, refresh bool) ([]storage.Branch, error) {
+	if !refresh {
+		branches, err := r.storage.GetCachedBranches()
+		if err != nil {
+			log.Printf("Error reading cached branches: %v", err)
+		} else if len(branches) > 0 {
+			return branches, nil
+		}
+	}
+
+	branches, _, err := r.discover(ctx, sessionID)
+	return branches, err
+}
+
+// GetFilters returns the vehicle list filters available to the logged-in account.
+// Cached results are used until DiscoveryTTL expires unless refresh is set.
+func (r *Renderer) GetFilters(ctx context.Context, sessionID string, refresh bool) ([]storage.Filter, error) {
+	if !refresh {
+		filters, err := r.storage.GetCachedFilters()
+		if err != nil {
+			log.Printf("Error reading cached filters: %v", err)
+		} else if len(filters) > 0 {
+			return filters, nil
+		}
+	}
+
+	_, filters, err := r.discover(ctx, sessionID)
+	return filters, err
+}
+
+// discover reads branches and filters from VenusMain and refreshes both caches.
+func (r *Renderer) discover(_ context.Context, sessionID string) ([]storage.Branch, []storage.Filter, error) {
+	page := r.browser.MustPage()
+	defer page.MustClose()
+
+	page = page.Timeout(2 * time.Minute)
+
+	if _, err := r.openVenusMain(page, sessionID, false); err != nil {
+		return nil, nil, err
+	}
+
+	branches, err := r.discoverBranches(page)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to discover branches: %w", err)
+	}
+	filters := r.discoverFilters(page)
+
+	if err := r.storage.CacheBranches(branches, r.config.DiscoveryTTL); err != nil {
+		log.Printf("Failed to cache branches: %v", err)
+	}
+	if err := r.storage.CacheFilters(filters, r.config.DiscoveryTTL); err != nil {
+		log.Printf("Failed to cache filters: %v", err)
+	}
+
+	log.Printf("Discovered %d branches and %d filters", len(branches), len(filters))
+	return branches, filters, nil
+}
+
+func (r *Renderer) discoverBranches(page *rod.Page) ([]storage.Branch, error) {
+	if r.hasBridgeMethod(page, "BranchList") {
+		result, err := r.callBridge(page, "BranchList")
+		if err == nil {
+			if branches := parseBranches(result); len(branches) > 0 {
+				return branches, nil
+			}
+		} else {
+			log.Printf("BranchList failed, falling back to vehicle table: %v", err)
+		}
+	}
+
+	// Every vehicle record carries BranchCD/BranchName, so the full table
+	// (all branches, including deleted vehicles) lists every branch in use.
+	result, err := r.callBridge(page, "VehicleStateTableForBranchEx", "", "")
+	if err != nil {
+		return nil, err
+	}
+	return parseBranches(result), nil
+}
+
+func (r *Renderer) discoverFilters(page *rod.Page) []storage.Filter {
+	if r.hasBridgeMethod(page, "FilterList") {
+		result, err := r.callBridge(page, "FilterList")
+		if err == nil {
+			if filters := parseFilters(result); len(filters) > 0 {
+				return filters
+			}
+		} else {
+			log.Printf("FilterList failed, using default filters: %v", err)
+		}
+	}
+	return defaultFilters
+}
+
+// hasBridgeMethod reports whether VenusBridgeService exposes the named method.
+func (r *Renderer) hasBridgeMethod(page *rod.Page, method string) bool {
+	result, err := page.Eval(`(method) => {
+		return typeof VenusBridgeService !== 'undefined' &&
+		       typeof VenusBridgeService[method] === 'function';
+	}`, method)
+	if err != nil {
+		return false
+	}
+	return result.Value.Bool()
+}
+
+// callBridge invokes a VenusBridgeService method with the given arguments and
+// waits for its success or failure callback.
+func (r *Renderer) callBridge(page *rod.Page, method string, args ...interface{}) (interface{}, error) {
+	if args == nil {
+		args = []interface{}{}
+	}
+
+	result, err := page.Timeout(60*time.Second).Eval(`(method, args) => new Promise((resolve, reject) => {
+		if (typeof VenusBridgeService === 'undefined' || typeof VenusBridgeService[method] !== 'function') {
+			reject(new Error('VenusBridgeService.' + method + ' not found'));
+			return;
+		}
+		VenusBridgeService[method](...args,
+			(data) => resolve(data),
+			(error) => reject(new Error(error && error.get_message ? error.get_message() : String(error)))
+		);
+	})`, method, args)
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", method, err)
+	}
+
+	return result.Value.Val(), nil
+}
+
+// parseBranches collects the distinct branches from a list of records that
+// carry BranchCD and BranchName, such as BranchList or vehicle table results.
+func parseBranches(result interface{}) []storage.Branch {
+	items, ok := result.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	seen := make(map[int]storage.Branch)
+	for _, rawItem := range items {
+		item, ok := rawItem.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		cd, ok := toInt(item["BranchCD"])
+		if !ok {
+			continue
+		}
+		name, _ := item["BranchName"].(string)
+		if existing, exists := seen[cd]; exists && existing.BranchName != "" {
+			continue
+		}
+		seen[cd] = storage.Branch{
+			BranchID:   fmt.Sprintf("%08d", cd),
+			BranchCD:   cd,
+			BranchName: strings.TrimSpace(name),
+		}
+	}
+
+	branches := make([]storage.Branch, 0, len(seen))
+	for _, branch := range seen {
+		branches = append(branches, branch)
+	}
+	sort.Slice(branches, func(i, j int) bool {
+		return branches[i].BranchCD < branches[j].BranchCD
+	})
+	return branches
+}
+
+// parseFilters converts a FilterList result into filters.
+func parseFilters(result interface{}) []storage.Filter {
+	items, ok := result.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	filters := make([]storage.Filter, 0, len(items))
+	for _, rawItem := range items {
+		item, ok := rawItem.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var id string
+		switch v := item["FilterID"].(type) {
+		case string:
+			id = v
+		case float64:
+			id = strconv.Itoa(int(v))
+		default:
+			continue
+		}
+		if id == "" {
+			id = IncludeDeletedFilterID
+		}
+		name, _ := item["FilterName"].(string)
+		filters = append(filters, storage.Filter{
+			FilterID:   id,
+			FilterName: strings.TrimSpace(name),
+		})
+	}
+	return filters
+}
+
+func toInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case float64:
+		return int(n), true
+	case int:
+		return n, true
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(n))
+		return i, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,65 @@
+package browser
+
+import "testing"
+
+func TestParseBranches(t *testing.T) {
+	records := []interface{}{
+		map[string]interface{}{"BranchCD": float64(2), "BranchName": "本社 "},
+		map[string]interface{}{"BranchCD": float64(1), "BranchName": "支店"},
+		map[string]interface{}{"BranchCD": float64(2), "BranchName": "本社"},
+		map[string]interface{}{"BranchCD": "3", "BranchName": "営業所"},
+		map[string]interface{}{"BranchName": "no code"},
+		"not a record",
+	}
+
+	branches := parseBranches(records)
+	if len(branches) != 3 {
+		t.Fatalf("Expected 3 branches, got %d: %+v", len(branches), branches)
+	}
+
+	if branches[0].BranchCD != 1 || branches[0].BranchID != "00000001" {
+		t.Errorf("Unexpected first branch: %+v", branches[0])
+	}
+	if branches[1].BranchName != "本社" {
+		t.Errorf("Expected trimmed branch name, got %q", branches[1].BranchName)
+	}
+	if branches[2].BranchCD != 3 {
+		t.Errorf("Expected string BranchCD to be parsed, got %+v", branches[2])
+	}
+
+	if parseBranches("not a list") != nil {
+		t.Error("Expected nil for non-list result")
+	}
+}
+
+func TestParseFilters(t *testing.T) {
+	filters := parseFilters([]interface{}{
+		map[string]interface{}{"FilterID": "0", "FilterName": "Active"},
+		map[string]interface{}{"FilterID": float64(1), "FilterName": "Trucks"},
+		map[string]interface{}{"FilterID": "", "FilterName": "All"},
+		map[string]interface{}{"FilterName": "missing id"},
+	})
+
+	if len(filters) != 3 {
+		t.Fatalf("Expected 3 filters, got %d", len(filters))
+	}
+	if filters[1].FilterID != "1" {
+		t.Errorf("Expected numeric FilterID to be converted, got %q", filters[1].FilterID)
+	}
+	if filters[2].FilterID != IncludeDeletedFilterID {
+		t.Errorf("Expected the empty FilterID to become %q, got %q", IncludeDeletedFilterID, filters[2].FilterID)
+	}
+}
+
+func TestBridgeFilterID(t *testing.T) {
+	tests := map[string]string{
+		DefaultFilterID:        DefaultFilterID,
+		IncludeDeletedFilterID: "",
+		"3":                    "3",
+	}
+	for filterID, want := range tests {
+		if got := bridgeFilterID(filterID); got != want {
+			t.Errorf("bridgeFilterID(%q) = %q, want %q", filterID, got, want)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+)
+
+// DownloadRequest describes a report download from the portal.
+// Steps run on the logged-in VenusMain page (or StartURL) and the last of
+// them is expected to trigger the browser download. Step values may use the
+// {{date_from}}, {{date_to}} and {{report_type}} placeholders.
+type DownloadRequest struct {
+	ReportType string `json:"report_type"`
+	DateFrom   string `json:"date_from,omitempty"`
+	DateTo     string `json:"date_to,omitempty"`
+	SessionID  string `json:"session_id,omitempty"`
+	StartURL   string `json:"start_url,omitempty"`
+	Steps      []Step `json:"steps"`
+	JobID      string `json:"-"`
+}
+
+// Validate checks the request before a job is created for it.
+func (req DownloadRequest) Validate() error {
+	if req.ReportType == "" {
+		return fmt.Errorf("report_type is required")
+	}
+	if len(req.Steps) == 0 {
+		return fmt.Errorf("at least one step is required")
+	}
+	if req.StartURL != "" {
+		if _, err := checkURLScheme(req.StartURL); err != nil {
+			return err
+		}
+	}
+	return ValidateSteps(req.Steps)
+}
+
+// contentTypes covers the report formats the portal produces; other
+// extensions fall back to the mime package.
+var contentTypes = map[string]string{
+	".csv":  "text/csv",
+	".xls":  "application/vnd.ms-excel",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".pdf":  "application/pdf",
+	".zip":  "application/zip",
+}
+
+// DownloadReport runs the request's click path, waits for the CDP download to
+// complete and stores the file with its metadata.
+func (r *Renderer) DownloadReport(ctx context.Context, req DownloadRequest) (*storage.Download, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Download behavior is browser-wide, so only one download runs at a time
+	r.downloadMu.Lock()
+	defer r.downloadMu.Unlock()
+
+	dir, err := filepath.Abs(r.config.DownloadDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid download directory: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create download directory: %w", err)
+	}
+
+	page := r.browser.MustPage()
+	defer page.MustClose()
+
+	page = page.Timeout(5 * time.Minute)
+
+	if _, err := r.openVenusMain(page, req.SessionID, false); err != nil {
+		return nil, err
+	}
+	if req.StartURL != "" {
+		if err := r.runSteps(page, []Step{{Action: "navigate", URL: req.StartURL}}, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	timeout := r.config.DownloadTimeout
+	if timeout <= 0 {
+		timeout = 2 * time.Minute
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	wait := r.browser.Context(waitCtx).WaitDownload(dir)
+
+	vars := map[string]string{
+		"report_type": req.ReportType,
+		"date_from":   req.DateFrom,
+		"date_to":     req.DateTo,
+	}
+	if err := r.runSteps(page, req.Steps, vars); err != nil {
+		cancel()
+		wait()
+		return nil, err
+	}
+
+	log.Printf("Waiting for %s download...", req.ReportType)
+	info := wait()
+	if waitCtx.Err() != nil || info == nil {
+		return nil, fmt.Errorf("timeout waiting for download after %v", timeout)
+	}
+
+	path := filepath.Join(dir, info.GUID)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read downloaded file: %w", err)
+	}
+	if err := os.Remove(path); err != nil {
+		log.Printf("Failed to remove downloaded file %s: %v", path, err)
+	}
+
+	download := &storage.Download{
+		ID:          uuid.New().String(),
+		JobID:       req.JobID,
+		ReportType:  req.ReportType,
+		DateFrom:    req.DateFrom,
+		DateTo:      req.DateTo,
+		Account:     r.account(),
+		Filename:    info.SuggestedFilename,
+		ContentType: downloadContentType(info.SuggestedFilename),
+		Size:        len(data),
+		CreatedAt:   time.Now(),
+		Data:        data,
+	}
+	if err := r.storage.SaveDownload(download); err != nil {
+		return nil, fmt.Errorf("failed to store download: %w", err)
+	}
+
+	log.Printf("Stored %s download %s (%s, %d bytes)", req.ReportType, download.ID, download.Filename, download.Size)
+	download.Data = nil
+	return download, nil
+}
+
+// account identifies the portal account the renderer logs in with.
+func (r *Renderer) account() string {
+	return r.config.CompID + "/" + r.config.UserName
+}
+
+func downloadContentType(filename string) string {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ct, ok := contentTypes[ext]; ok {
+		return ct
+	}
+	if ct := mime.TypeByExtension(ext); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,44 @@
+package browser
+
+import "testing"
+
+func TestDownloadRequest_Validate(t *testing.T) {
+	steps := []Step{{Action: "click", Selector: "#btnCsv"}}
+
+	tests := []struct {
+		name    string
+		req     DownloadRequest
+		wantErr bool
+	}{
+		{"Valid", DownloadRequest{ReportType: "daily", Steps: steps}, false},
+		{"Missing report type", DownloadRequest{Steps: steps}, true},
+		{"Missing steps", DownloadRequest{ReportType: "daily"}, true},
+		{"Invalid step", DownloadRequest{ReportType: "daily", Steps: []Step{{Action: "click"}}}, true},
+		{"Local start URL", DownloadRequest{ReportType: "daily", StartURL: "file:///tmp", Steps: steps}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.req.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDownloadContentType(t *testing.T) {
+	tests := map[string]string{
+		"report.csv":    "text/csv",
+		"report.XLSX":   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		"report.xls":    "application/vnd.ms-excel",
+		"report.pdf":    "application/pdf",
+		"report":        "application/octet-stream",
+		"report.bin123": "application/octet-stream",
+	}
+
+	for filename, want := range tests {
+		if got := downloadContentType(filename); got != want {
+			t.Errorf("downloadContentType(%q) = %q, want %q", filename, got, want)
+		}
+	}
+}
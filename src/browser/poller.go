@@ -0,0 +1,164 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// poller calls the bridge service on the warm VenusMain page at a fixed
+// interval and emits the vehicles whose DataDateTime changed.
+type poller struct {
+	mu          sync.Mutex
+	subscribers map[int]chan *Snapshot
+	nextID      int
+	lastSeen    map[string]string // vehicle key -> DataDateTime
+}
+
+func newPoller() *poller {
+	return &poller{
+		subscribers: make(map[int]chan *Snapshot),
+		lastSeen:    make(map[string]string),
+	}
+}
+
+// SubscribeVehicleUpdates returns a channel that receives every polled
+// snapshot with changed vehicles, and a function that unsubscribes and closes
+// it. Slow subscribers miss updates rather than blocking the poller.
+func (r *Renderer) SubscribeVehicleUpdates(buffer int) (<-chan *Snapshot, func()) {
+	p := r.poller
+	ch := make(chan *Snapshot, buffer)
+
+	p.mu.Lock()
+	id := p.nextID
+	p.nextID++
+	p.subscribers[id] = ch
+	p.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			p.mu.Lock()
+			delete(p.subscribers, id)
+			p.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// RunPoller polls vehicle state every interval until ctx is cancelled.
+func (r *Renderer) RunPoller(ctx context.Context, interval time.Duration, branchID, filterID string) {
+	if branchID == "" {
+		branchID = DefaultBranchID
+	}
+	if filterID == "" {
+		filterID = DefaultFilterID
+	}
+	log.Printf("Vehicle state polling started (interval=%v, branchID=%s, filterID=%s)", interval, branchID, filterID)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.pollOnce(ctx, branchID, filterID); err != nil {
+			log.Printf("Vehicle state poll failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Vehicle state polling stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Renderer) pollOnce(ctx context.Context, branchID, filterID string) error {
+	rawData, _, err := r.fetchFromWarmPage("", branchID, filterID, false)
+	if err != nil {
+		return err
+	}
+
+	changed := r.poller.changedRecords(rawData)
+	if len(changed) == 0 {
+		return nil
+	}
+	log.Printf("Poll found %d changed vehicles out of %d", len(changed), len(rawData))
+
+	vehicles := toVehicleData(changed, r.gpsDatum())
+	r.registerVehicles(vehicles)
+
+	snapshot := &Snapshot{
+		BranchID:  branchID,
+		FilterID:  filterID,
+		FetchedAt: time.Now(),
+		Partial:   true,
+		Records:   changed,
+		Vehicles:  vehicles,
+	}
+	r.publish(ctx, snapshot)
+	r.poller.broadcast(snapshot)
+	return nil
+}
+
+// changedRecords returns the records whose DataDateTime differs from the
+// previous poll and remembers the new values. Only the vehicles in rawData
+// are remembered, so removed vehicles are forgotten.
+func (p *poller) changedRecords(rawData []interface{}) []interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	seen := make(map[string]string, len(rawData))
+	var changed []interface{}
+	for _, rawItem := range rawData {
+		item, ok := rawItem.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		key := recordKey(item)
+		if key == "" {
+			continue
+		}
+		dataTime := fmt.Sprintf("%v", item["DataDateTime"])
+		seen[key] = dataTime
+		if prev, ok := p.lastSeen[key]; ok && prev == dataTime {
+			continue
+		}
+		changed = append(changed, item)
+	}
+	p.lastSeen = seen
+	return changed
+}
+
+func (p *poller) broadcast(snapshot *Snapshot) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for id, ch := range p.subscribers {
+		select {
+		case ch <- snapshot:
+		default:
+			log.Printf("Vehicle update subscriber %d is not keeping up, dropping update", id)
+		}
+	}
+}
+
+// recordKey identifies a vehicle in a raw record by VehicleCD, falling back
+// to VehicleName.
+func recordKey(item map[string]interface{}) string {
+	switch v := item["VehicleCD"].(type) {
+	case string:
+		if v != "" {
+			return v
+		}
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	if name, ok := item["VehicleName"].(string); ok {
+		return "name:" + name
+	}
+	return ""
+}
@@ -0,0 +1,131 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRecordKey(t *testing.T) {
+	tests := []struct {
+		name string
+		item map[string]interface{}
+		want string
+	}{
+		{"string code", map[string]interface{}{"VehicleCD": "123", "VehicleName": "truck"}, "123"},
+		{"numeric code", map[string]interface{}{"VehicleCD": float64(456)}, "456"},
+		{"empty code falls back to name", map[string]interface{}{"VehicleCD": "", "VehicleName": "truck"}, "name:truck"},
+		{"no identity", map[string]interface{}{"DataDateTime": "25/01/01 10:00"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := recordKey(tt.item); got != tt.want {
+				t.Errorf("recordKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPoller_ChangedRecords(t *testing.T) {
+	p := newPoller()
+
+	first := []interface{}{
+		map[string]interface{}{"VehicleCD": "1", "DataDateTime": "10:00"},
+		map[string]interface{}{"VehicleCD": "2", "DataDateTime": "10:00"},
+		map[string]interface{}{"DataDateTime": "10:00"},
+		"not a record",
+	}
+	if changed := p.changedRecords(first); len(changed) != 2 {
+		t.Fatalf("Expected 2 new vehicles on first poll, got %d", len(changed))
+	}
+
+	if changed := p.changedRecords(first); len(changed) != 0 {
+		t.Errorf("Expected no changes on identical poll, got %d", len(changed))
+	}
+
+	second := []interface{}{
+		map[string]interface{}{"VehicleCD": "1", "DataDateTime": "10:00"},
+		map[string]interface{}{"VehicleCD": "2", "DataDateTime": "10:15"},
+		map[string]interface{}{"VehicleCD": "3", "DataDateTime": "10:15"},
+	}
+	changed := p.changedRecords(second)
+	if len(changed) != 2 {
+		t.Fatalf("Expected 2 changed vehicles, got %d", len(changed))
+	}
+	for _, item := range changed {
+		if cd := item.(map[string]interface{})["VehicleCD"]; cd == "1" {
+			t.Errorf("Unchanged vehicle 1 was emitted")
+		}
+	}
+
+	// Vehicles missing from a poll are forgotten and count as new if they return
+	if _, ok := p.lastSeen["1"]; !ok || len(p.lastSeen) != 3 {
+		t.Fatalf("Expected the 3 polled vehicles to be remembered, got %v", p.lastSeen)
+	}
+	p.changedRecords(second[1:])
+	if _, ok := p.lastSeen["1"]; ok {
+		t.Errorf("Expected vehicle 1 to be forgotten, got %v", p.lastSeen)
+	}
+	if changed := p.changedRecords(second); len(changed) != 1 {
+		t.Errorf("Expected the returning vehicle to count as changed, got %d", len(changed))
+	}
+}
+
+func TestPoller_SubscribeBroadcast(t *testing.T) {
+	r := &Renderer{poller: newPoller()}
+
+	updates, unsubscribe := r.SubscribeVehicleUpdates(1)
+	snapshot := &Snapshot{BranchID: DefaultBranchID, Partial: true}
+
+	r.poller.broadcast(snapshot)
+	// The buffer is full; this update is dropped instead of blocking
+	r.poller.broadcast(&Snapshot{})
+
+	select {
+	case got := <-updates:
+		if got != snapshot {
+			t.Errorf("Expected the first snapshot, got %+v", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a snapshot")
+	}
+
+	unsubscribe()
+	unsubscribe() // safe to call twice
+
+	if _, ok := <-updates; ok {
+		t.Error("Expected channel to be closed after unsubscribe")
+	}
+	if len(r.poller.subscribers) != 0 {
+		t.Errorf("Expected no subscribers, got %d", len(r.poller.subscribers))
+	}
+}
+
+type recordingSink struct {
+	name   string
+	err    error
+	writes int
+}
+
+func (s *recordingSink) Name() string { return s.name }
+
+func (s *recordingSink) Write(_ context.Context, _ *Snapshot) error {
+	s.writes++
+	return s.err
+}
+
+func TestRenderer_PublishContinuesAfterFailure(t *testing.T) {
+	r := &Renderer{}
+	failing := &recordingSink{name: "failing", err: errors.New("boom")}
+	ok := &recordingSink{name: "ok"}
+	r.AddSink(failing)
+	r.AddSink(ok)
+
+	r.publish(context.Background(), &Snapshot{})
+
+	if failing.writes != 1 || ok.writes != 1 {
+		t.Errorf("Expected each sink to be written once, got failing=%d ok=%d", failing.writes, ok.writes)
+	}
+}
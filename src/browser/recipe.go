@@ -0,0 +1,277 @@
+package browser
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Recipe is a declarative extraction job: after logging in to the portal it
+// optionally opens StartURL, runs Steps and then reads each Field from the page.
+type Recipe struct {
+	Name        string  `json:"name" yaml:"name"`
+	Description string  `json:"description,omitempty" yaml:"description,omitempty"`
+	StartURL    string  `json:"start_url,omitempty" yaml:"start_url,omitempty"`
+	Steps       []Step  `json:"steps,omitempty" yaml:"steps,omitempty"`
+	Fields      []Field `json:"fields" yaml:"fields"`
+}
+
+// Field maps a CSS selector or JavaScript expression to an output field.
+//
+// Selector fields read the element's text, or Attr if set. JS fields evaluate
+// the expression in the page (promises are awaited). Type converts the value
+// to string (default), int, float, bool or json. Multiple collects every
+// matching element as a list.
+type Field struct {
+	Name     string `json:"name" yaml:"name"`
+	Selector string `json:"selector,omitempty" yaml:"selector,omitempty"`
+	Attr     string `json:"attr,omitempty" yaml:"attr,omitempty"`
+	JS       string `json:"js,omitempty" yaml:"js,omitempty"`
+	Type     string `json:"type,omitempty" yaml:"type,omitempty"`
+	Multiple bool   `json:"multiple,omitempty" yaml:"multiple,omitempty"`
+}
+
+// RecipeResult is the typed output of one recipe run.
+type RecipeResult struct {
+	Recipe      string                 `json:"recipe"`
+	Data        map[string]interface{} `json:"data"`
+	ExtractedAt time.Time              `json:"extracted_at"`
+}
+
+var recipeNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ParseRecipe decodes a recipe from JSON or YAML and validates it.
+func ParseRecipe(data []byte) (*Recipe, error) {
+	var recipe Recipe
+
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&recipe); err != nil {
+			return nil, fmt.Errorf("invalid recipe JSON: %w", err)
+		}
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(trimmed))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&recipe); err != nil {
+			return nil, fmt.Errorf("invalid recipe YAML: %w", err)
+		}
+	}
+
+	if err := recipe.Validate(); err != nil {
+		return nil, err
+	}
+	return &recipe, nil
+}
+
+// Validate checks the recipe name, steps and field definitions.
+func (rc *Recipe) Validate() error {
+	if !recipeNamePattern.MatchString(rc.Name) {
+		return fmt.Errorf("recipe name must match %s", recipeNamePattern)
+	}
+	if rc.StartURL != "" {
+		if _, err := checkURLScheme(rc.StartURL); err != nil {
+			return fmt.Errorf("start_url: %w", err)
+		}
+	}
+	if err := ValidateSteps(rc.Steps); err != nil {
+		return err
+	}
+	if len(rc.Fields) == 0 {
+		return fmt.Errorf("recipe needs at least one field")
+	}
+
+	seen := make(map[string]bool)
+	for i, field := range rc.Fields {
+		if field.Name == "" {
+			return fmt.Errorf("field %d: name is required", i+1)
+		}
+		if seen[field.Name] {
+			return fmt.Errorf("field %q is defined twice", field.Name)
+		}
+		seen[field.Name] = true
+
+		if (field.Selector == "") == (field.JS == "") {
+			return fmt.Errorf("field %q: exactly one of selector or js is required", field.Name)
+		}
+		switch field.Type {
+		case "", "string", "int", "float", "bool", "json":
+		default:
+			return fmt.Errorf("field %q: unknown type %q", field.Name, field.Type)
+		}
+	}
+	return nil
+}
+
+// RunRecipe logs in, runs the recipe's steps and extracts its fields.
+func (r *Renderer) RunRecipe(_ context.Context, recipe *Recipe, sessionID string, vars map[string]string) (*RecipeResult, error) {
+	page := r.browser.MustPage()
+	defer page.MustClose()
+
+	page = page.Timeout(5 * time.Minute)
+
+	if _, err := r.openVenusMain(page, sessionID, false); err != nil {
+		return nil, err
+	}
+
+	steps := recipe.Steps
+	if recipe.StartURL != "" {
+		steps = append([]Step{{Action: "navigate", URL: recipe.StartURL}}, steps...)
+	}
+	if err := r.runSteps(page, steps, vars); err != nil {
+		return nil, err
+	}
+
+	data := make(map[string]interface{}, len(recipe.Fields))
+	for _, field := range recipe.Fields {
+		raw, err := page.Timeout(defaultStepTimeout).Eval(field.script())
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", field.Name, err)
+		}
+
+		value, err := field.coerce(raw.Value.Val())
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", field.Name, err)
+		}
+		data[field.Name] = value
+	}
+
+	log.Printf("Recipe %s extracted %d fields", recipe.Name, len(data))
+	return &RecipeResult{
+		Recipe:      recipe.Name,
+		Data:        data,
+		ExtractedAt: time.Now(),
+	}, nil
+}
+
+// script returns the page function that reads the field's raw value.
+func (f Field) script() string {
+	if f.JS != "" {
+		return "() => (" + f.JS + ")"
+	}
+
+	selector, _ := json.Marshal(f.Selector)
+	attr, _ := json.Marshal(f.Attr)
+	read := `(el) => { const attr = ` + string(attr) + `; return attr ? el.getAttribute(attr) : el.textContent.trim(); }`
+	if f.Multiple {
+		return `() => Array.from(document.querySelectorAll(` + string(selector) + `)).map(` + read + `)`
+	}
+	return `() => { const el = document.querySelector(` + string(selector) + `); return el ? (` + read + `)(el) : null; }`
+}
+
+// coerce converts a raw page value to the field's type.
+func (f Field) coerce(v interface{}) (interface{}, error) {
+	if f.Multiple {
+		items, ok := v.([]interface{})
+		if !ok {
+			if v == nil {
+				return []interface{}{}, nil
+			}
+			items = []interface{}{v}
+		}
+		out := make([]interface{}, len(items))
+		for i, item := range items {
+			value, err := coerceValue(item, f.Type)
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %w", i, err)
+			}
+			out[i] = value
+		}
+		return out, nil
+	}
+	return coerceValue(v, f.Type)
+}
+
+func coerceValue(v interface{}, typ string) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	switch typ {
+	case "", "string":
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+		return fmt.Sprintf("%v", v), nil
+
+	case "int":
+		switch n := v.(type) {
+		case float64:
+			return int64(n), nil
+		case string:
+			s := cleanNumber(n)
+			if s == "" {
+				return nil, nil
+			}
+			i, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				f, ferr := strconv.ParseFloat(s, 64)
+				if ferr != nil {
+					return nil, fmt.Errorf("cannot convert %q to int", n)
+				}
+				return int64(f), nil
+			}
+			return i, nil
+		}
+
+	case "float":
+		switch n := v.(type) {
+		case float64:
+			return n, nil
+		case string:
+			s := cleanNumber(n)
+			if s == "" {
+				return nil, nil
+			}
+			f, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return nil, fmt.Errorf("cannot convert %q to float", n)
+			}
+			return f, nil
+		}
+
+	case "bool":
+		switch b := v.(type) {
+		case bool:
+			return b, nil
+		case float64:
+			return b != 0, nil
+		case string:
+			switch strings.ToLower(strings.TrimSpace(b)) {
+			case "true", "1", "yes", "on":
+				return true, nil
+			case "false", "0", "no", "off", "":
+				return false, nil
+			}
+			return nil, fmt.Errorf("cannot convert %q to bool", b)
+		}
+
+	case "json":
+		if s, ok := v.(string); ok {
+			var out interface{}
+			if err := json.Unmarshal([]byte(s), &out); err != nil {
+				return nil, fmt.Errorf("invalid JSON: %w", err)
+			}
+			return out, nil
+		}
+		return v, nil
+	}
+
+	return nil, fmt.Errorf("cannot convert %T to %s", v, typ)
+}
+
+// cleanNumber strips thousands separators and whitespace from a number string.
+func cleanNumber(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.ReplaceAll(s, ",", "")
+	return s
+}
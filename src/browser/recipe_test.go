@@ -0,0 +1,122 @@
+package browser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRecipe_JSONAndYAML(t *testing.T) {
+	jsonRecipe := `{
+		"name": "vehicle_count",
+		"steps": [{"action": "wait", "selector": "#igGrid-VenusMain-VehicleList"}],
+		"fields": [
+			{"name": "title", "selector": "title"},
+			{"name": "rows", "js": "document.querySelectorAll('tr').length", "type": "int"}
+		]
+	}`
+	yamlRecipe := `
+name: vehicle_count
+steps:
+  - action: wait
+    selector: "#igGrid-VenusMain-VehicleList"
+fields:
+  - name: title
+    selector: title
+  - name: rows
+    js: document.querySelectorAll('tr').length
+    type: int
+`
+
+	fromJSON, err := ParseRecipe([]byte(jsonRecipe))
+	if err != nil {
+		t.Fatalf("Failed to parse JSON recipe: %v", err)
+	}
+	fromYAML, err := ParseRecipe([]byte(yamlRecipe))
+	if err != nil {
+		t.Fatalf("Failed to parse YAML recipe: %v", err)
+	}
+	if !reflect.DeepEqual(fromJSON, fromYAML) {
+		t.Errorf("JSON and YAML recipes differ:\n%+v\n%+v", fromJSON, fromYAML)
+	}
+}
+
+func TestParseRecipe_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		recipe string
+		errMsg string
+	}{
+		{"Bad name", `{"name": "a b", "fields": [{"name": "x", "selector": "p"}]}`, "recipe name"},
+		{"No fields", `{"name": "r"}`, "at least one field"},
+		{"Selector and JS", `{"name": "r", "fields": [{"name": "x", "selector": "p", "js": "1"}]}`, "exactly one"},
+		{"Unknown type", `{"name": "r", "fields": [{"name": "x", "selector": "p", "type": "date"}]}`, "unknown type"},
+		{"Duplicate field", `{"name": "r", "fields": [{"name": "x", "selector": "p"}, {"name": "x", "js": "1"}]}`, "twice"},
+		{"Bad step", `{"name": "r", "steps": [{"action": "click"}], "fields": [{"name": "x", "js": "1"}]}`, "step 1"},
+		{"Unknown key", `{"name": "r", "feilds": []}`, "unknown field"},
+		{"Unknown YAML key", "name: r\nfeilds: []\n", "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRecipe([]byte(tt.recipe))
+			if err == nil {
+				t.Fatal("Expected error")
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %q", tt.errMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestField_Coerce(t *testing.T) {
+	tests := []struct {
+		name  string
+		field Field
+		in    interface{}
+		want  interface{}
+	}{
+		{"String default", Field{}, "abc", "abc"},
+		{"Number to string", Field{Type: "string"}, float64(12), "12"},
+		{"Int from text", Field{Type: "int"}, " 1,234 ", int64(1234)},
+		{"Int from number", Field{Type: "int"}, float64(7), int64(7)},
+		{"Float from text", Field{Type: "float"}, "35.5", 35.5},
+		{"Empty float", Field{Type: "float"}, "", nil},
+		{"Bool from text", Field{Type: "bool"}, "yes", true},
+		{"JSON from text", Field{Type: "json"}, `{"a":1}`, map[string]interface{}{"a": float64(1)}},
+		{"Null", Field{Type: "int"}, nil, nil},
+		{"Multiple", Field{Type: "int", Multiple: true}, []interface{}{"1", "2"}, []interface{}{int64(1), int64(2)}},
+		{"Multiple none", Field{Multiple: true}, nil, []interface{}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.field.coerce(tt.in)
+			if err != nil {
+				t.Fatalf("coerce() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("coerce() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := (Field{Type: "int"}).coerce("abc"); err == nil {
+		t.Error("Expected error converting text to int")
+	}
+}
+
+func TestField_Script(t *testing.T) {
+	script := Field{Selector: `a[href="x"]`, Attr: "href", Multiple: true}.script()
+	if !strings.Contains(script, `querySelectorAll("a[href=\"x\"]")`) {
+		t.Errorf("Selector not quoted safely: %s", script)
+	}
+	if !strings.Contains(script, `"href"`) {
+		t.Errorf("Attribute not included: %s", script)
+	}
+
+	if got := (Field{JS: "document.title"}).script(); got != "() => (document.title)" {
+		t.Errorf("Unexpected JS script: %s", got)
+	}
+}
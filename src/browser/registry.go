@@ -0,0 +1,44 @@
+package browser
+
+import (
+	"log"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+)
+
+// registerVehicles resolves every vehicle to its stable registry ID and sets
+// VehicleID. Registry failures are logged; the vehicles are still returned.
+func (r *Renderer) registerVehicles(vehicles []VehicleData) {
+	if r.storage == nil || len(vehicles) == 0 {
+		return
+	}
+
+	observations := make([]storage.VehicleObservation, len(vehicles))
+	for i, vd := range vehicles {
+		observations[i] = vehicleObservation(vd)
+	}
+
+	ids, err := r.storage.RegisterVehicles(observations)
+	if err != nil {
+		log.Printf("Warning: failed to update vehicle registry: %v", err)
+		return
+	}
+	for i := range vehicles {
+		vehicles[i].VehicleID = ids[i]
+	}
+}
+
+func vehicleObservation(vd VehicleData) storage.VehicleObservation {
+	o := storage.VehicleObservation{VehicleName: vd.VehicleName}
+	if vd.Plate != nil {
+		o.Plate = vd.Plate.Key()
+	}
+	if t := vd.Telemetry; t != nil {
+		o.VehicleCD = t.VehicleCD
+		o.BranchCD = t.BranchCD
+		if o.VehicleName == "" {
+			o.VehicleName = t.VehicleName
+		}
+	}
+	return o
+}
@@ -0,0 +1,245 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// ScreenshotOptions describes a screenshot of an arbitrary URL.
+type ScreenshotOptions struct {
+	URL          string `json:"url"`
+	SessionID    string `json:"session_id,omitempty"`    // restore cookies from this stored session
+	Width        int    `json:"width,omitempty"`         // viewport width in CSS pixels (default 1280)
+	Height       int    `json:"height,omitempty"`        // viewport height in CSS pixels (default 800)
+	FullPage     bool   `json:"full_page,omitempty"`     // capture the whole scrollable page
+	WaitSelector string `json:"wait_selector,omitempty"` // wait for this element before capturing
+	Format       string `json:"format,omitempty"`        // png (default) or jpeg
+	Quality      int    `json:"quality,omitempty"`       // jpeg quality 0-100
+}
+
+// PDFOptions describes a PDF print of an arbitrary URL.
+type PDFOptions struct {
+	URL             string `json:"url"`
+	SessionID       string `json:"session_id,omitempty"`
+	Width           int    `json:"width,omitempty"`
+	Height          int    `json:"height,omitempty"`
+	WaitSelector    string `json:"wait_selector,omitempty"`
+	PaperSize       string `json:"paper_size,omitempty"` // A3, A4 (default), A5, B4, B5, Letter, Legal
+	Landscape       bool   `json:"landscape,omitempty"`
+	PrintBackground bool   `json:"print_background,omitempty"`
+}
+
+// paperSizes maps paper names to width and height in inches.
+// B4/B5 are the JIS sizes used for Japanese business documents.
+var paperSizes = map[string][2]float64{
+	"A3":     {11.69, 16.54},
+	"A4":     {8.27, 11.69},
+	"A5":     {5.83, 8.27},
+	"B4":     {10.12, 14.33},
+	"B5":     {7.17, 10.12},
+	"LETTER": {8.5, 11},
+	"LEGAL":  {8.5, 14},
+}
+
+// RenderScreenshot loads the URL in the managed browser and returns the image
+// bytes together with their content type.
+func (r *Renderer) RenderScreenshot(_ context.Context, opts ScreenshotOptions) ([]byte, string, error) {
+	format := proto.PageCaptureScreenshotFormatPng
+	contentType := "image/png"
+	switch strings.ToLower(opts.Format) {
+	case "", "png":
+	case "jpeg", "jpg":
+		format = proto.PageCaptureScreenshotFormatJpeg
+		contentType = "image/jpeg"
+	default:
+		return nil, "", fmt.Errorf("unsupported screenshot format: %s", opts.Format)
+	}
+
+	page, err := r.openRenderPage(opts.URL, opts.SessionID, opts.Width, opts.Height, opts.WaitSelector)
+	if err != nil {
+		return nil, "", err
+	}
+	defer page.MustClose()
+
+	req := &proto.PageCaptureScreenshot{Format: format}
+	if format == proto.PageCaptureScreenshotFormatJpeg && opts.Quality > 0 {
+		quality := opts.Quality
+		req.Quality = &quality
+	}
+
+	data, err := page.Screenshot(opts.FullPage, req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to capture screenshot: %w", err)
+	}
+
+	log.Printf("Rendered screenshot of %s (%d bytes)", opts.URL, len(data))
+	return data, contentType, nil
+}
+
+// RenderPDF loads the URL in the managed browser and prints it to PDF.
+func (r *Renderer) RenderPDF(_ context.Context, opts PDFOptions) ([]byte, error) {
+	paper, err := paperSize(opts.PaperSize)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := r.openRenderPage(opts.URL, opts.SessionID, opts.Width, opts.Height, opts.WaitSelector)
+	if err != nil {
+		return nil, err
+	}
+	defer page.MustClose()
+
+	width, height := paper[0], paper[1]
+	stream, err := page.PDF(&proto.PagePrintToPDF{
+		Landscape:       opts.Landscape,
+		PrintBackground: opts.PrintBackground,
+		PaperWidth:      &width,
+		PaperHeight:     &height,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to print PDF: %w", err)
+	}
+
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	log.Printf("Rendered PDF of %s (%d bytes)", opts.URL, len(data))
+	return data, nil
+}
+
+// openRenderPage opens a new page with the stored session's cookies and the
+// requested viewport, navigates to rawURL and waits until it is ready.
+func (r *Renderer) openRenderPage(rawURL, sessionID string, width, height int, waitSelector string) (*rod.Page, error) {
+	if err := validateRenderURL(rawURL, r.config.RenderAllowedHosts); err != nil {
+		return nil, err
+	}
+
+	if width <= 0 {
+		width = 1280
+	}
+	if height <= 0 {
+		height = 800
+	}
+
+	page, err := r.browser.Page(proto.TargetCreateTarget{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open page: %w", err)
+	}
+
+	timeout := r.config.BrowserTimeout
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+
+	err = rod.Try(func() {
+		if sessionID != "" {
+			r.restoreSession(page, sessionID)
+		}
+
+		page.MustSetViewport(width, height, 1, false)
+
+		p := page.Timeout(timeout)
+		p.MustNavigate(rawURL)
+		p.MustWaitLoad()
+		if waitSelector != "" {
+			p.MustElement(waitSelector)
+		}
+		p.MustWaitRequestIdle()
+	})
+	if err != nil {
+		page.MustClose()
+		return nil, fmt.Errorf("failed to load %s: %w", rawURL, err)
+	}
+
+	return page, nil
+}
+
+// lookupIP resolves render hosts; tests replace it.
+var lookupIP = net.LookupIP
+
+// checkURLScheme only allows http(s) URLs with a host so callers cannot
+// read local files.
+func checkURLScheme(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported url scheme: %q", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("url has no host")
+	}
+	return u, nil
+}
+
+// validateRenderURL checks the scheme and refuses hosts resolving to
+// loopback, private or link-local addresses so the browser cannot be used to
+// reach the internal network. allowed lists hosts, IPs or CIDRs that are
+// opened anyway. Only the requested URL is checked, not redirects or
+// subresources.
+func validateRenderURL(rawURL string, allowed []string) error {
+	u, err := checkURLScheme(rawURL)
+	if err != nil {
+		return err
+	}
+	host := u.Hostname()
+
+	for _, a := range allowed {
+		if strings.EqualFold(a, host) {
+			return nil
+		}
+	}
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		if ips, err = lookupIP(host); err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", host, err)
+		}
+	}
+	for _, ip := range ips {
+		if internalIP(ip) && !allowedIP(ip, allowed) {
+			return fmt.Errorf("url host %s resolves to internal address %s", host, ip)
+		}
+	}
+	return nil
+}
+
+func internalIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast()
+}
+
+func allowedIP(ip net.IP, allowed []string) bool {
+	for _, a := range allowed {
+		if _, cidr, err := net.ParseCIDR(a); err == nil {
+			if cidr.Contains(ip) {
+				return true
+			}
+		} else if allowed := net.ParseIP(a); allowed != nil && allowed.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func paperSize(name string) ([2]float64, error) {
+	if name == "" {
+		name = "A4"
+	}
+	size, ok := paperSizes[strings.ToUpper(name)]
+	if !ok {
+		return [2]float64{}, fmt.Errorf("unsupported paper size: %s", name)
+	}
+	return size, nil
+}
@@ -0,0 +1,79 @@
+package browser
+
+import (
+	"fmt"
+	"net"
+	"testing"
+)
+
+func TestPaperSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    [2]float64
+		wantErr bool
+	}{
+		{name: "", want: [2]float64{8.27, 11.69}},
+		{name: "a3", want: [2]float64{11.69, 16.54}},
+		{name: "Letter", want: [2]float64{8.5, 11}},
+		{name: "B5", want: [2]float64{7.17, 10.12}},
+		{name: "Tabloid", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := paperSize(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("paperSize(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("paperSize(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRenderURL(t *testing.T) {
+	hosts := map[string]string{
+		"theearth-np.com": "203.0.113.10",
+		"localhost":       "127.0.0.1",
+		"intranet.local":  "10.1.2.3",
+	}
+	defer func(orig func(string) ([]net.IP, error)) { lookupIP = orig }(lookupIP)
+	lookupIP = func(host string) ([]net.IP, error) {
+		if ip, ok := hosts[host]; ok {
+			return []net.IP{net.ParseIP(ip)}, nil
+		}
+		return nil, fmt.Errorf("no such host %s", host)
+	}
+
+	tests := []struct {
+		url     string
+		allowed []string
+		wantErr bool
+	}{
+		{url: "https://theearth-np.com/WebVenus/F-AAV0001[VenusMain].aspx", wantErr: false},
+		{url: "http://203.0.113.10/", wantErr: false},
+		{url: "http://localhost:8080/health", wantErr: true},
+		{url: "http://127.0.0.1:8080/health", wantErr: true},
+		{url: "http://[::1]/", wantErr: true},
+		{url: "http://intranet.local/", wantErr: true},
+		{url: "http://192.168.1.1/", wantErr: true},
+		{url: "http://169.254.169.254/latest/meta-data/", wantErr: true},
+		{url: "http://0.0.0.0/", wantErr: true},
+		{url: "http://unknown.example/", wantErr: true},
+		{url: "http://intranet.local/", allowed: []string{"INTRANET.local"}, wantErr: false},
+		{url: "http://intranet.local/", allowed: []string{"10.0.0.0/8"}, wantErr: false},
+		{url: "http://192.168.1.1/", allowed: []string{"192.168.1.1"}, wantErr: false},
+		{url: "http://192.168.1.2/", allowed: []string{"192.168.1.1"}, wantErr: true},
+		{url: "file:///etc/passwd", wantErr: true},
+		{url: "javascript:alert(1)", wantErr: true},
+		{url: "https://", wantErr: true},
+		{url: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		if err := validateRenderURL(tt.url, tt.allowed); (err != nil) != tt.wantErr {
+			t.Errorf("validateRenderURL(%q, %v) error = %v, wantErr %v", tt.url, tt.allowed, err, tt.wantErr)
+		}
+	}
+}
@@ -5,14 +5,15 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
-	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-rod/rod"
@@ -20,19 +21,61 @@ import (
 	"github.com/go-rod/rod/lib/proto"
 	"github.com/yhonda-ohishi/browser_render_go/src/config"
 	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
 )
 
 type Renderer struct {
 	config  *config.Config
 	storage *storage.Storage
 	browser *rod.Browser
+
+	downloadMu sync.Mutex
+
+	venusPages   map[string]*venusPage
+	venusPagesMu sync.Mutex
+
+	sinks     []Sink
+	sinksMu   sync.RWMutex
+	poller    *poller
+	validator *scrapeValidator
 }
 
 type VehicleData struct {
-	VehicleCD   string            `json:"VehicleCD"`
-	VehicleName string            `json:"VehicleName"`
-	Status      string            `json:"Status"`
-	Metadata    map[string]string `json:"Metadata"`
+	VehicleID   int64                  `json:"VehicleID,omitempty"` // stable registry ID
+	VehicleCD   string                 `json:"VehicleCD"`
+	VehicleName string                 `json:"VehicleName"`
+	Status      string                 `json:"Status"`
+	Metadata    map[string]string      `json:"Metadata"` // stringified values, kept for compatibility
+	Telemetry   *vehicle.Telemetry     `json:"Telemetry,omitempty"`
+	Plate       *vehicle.Plate         `json:"Plate,omitempty"`    // parsed from VehicleName
+	Position    *vehicle.Position      `json:"Position,omitempty"` // WGS84
+	Raw         map[string]interface{} `json:"Raw,omitempty"`      // the record as returned by the bridge service
+
+	// Parsed portal timestamps, serialized as RFC3339
+	DataTime      *time.Time `json:"DataTime,omitempty"`
+	ComuTime      *time.Time `json:"ComuTime,omitempty"`
+	StartWorkTime *time.Time `json:"StartWorkTime,omitempty"`
+}
+
+// Bridge parameters used when the caller does not pick a branch or filter.
+// "00000000" selects all branches, "0" excludes deleted vehicles.
+const (
+	DefaultBranchID = "00000000"
+	DefaultFilterID = "0"
+)
+
+// IncludeDeletedFilterID selects every vehicle including deleted ones. The
+// bridge takes an empty filterID for this, which callers cannot pass because
+// an empty filter_id means DefaultFilterID.
+const IncludeDeletedFilterID = "all"
+
+// bridgeFilterID returns the filterID argument VehicleStateTableForBranchEx
+// expects for filterID.
+func bridgeFilterID(filterID string) string {
+	if filterID == IncludeDeletedFilterID {
+		return ""
+	}
+	return filterID
 }
 
 type HonoAPIResponse struct {
@@ -84,19 +127,50 @@ func NewRenderer(cfg *config.Config, store *storage.Storage) (*Renderer, error)
 	url := l.MustLaunch()
 	browser := rod.New().ControlURL(url).MustConnect()
 
-	return &Renderer{
+	r := &Renderer{
 		config:  cfg,
 		storage: store,
 		browser: browser,
-	}, nil
+		poller:  newPoller(),
+		validator: newScrapeValidator(ScrapeRules{
+			MinRatio:       cfg.ScrapeMinRatio,
+			BaselineSize:   cfg.ScrapeBaselineSize,
+			AgreeTolerance: cfg.ScrapeAgreeTolerance,
+		}, store),
+	}
+	// Store locally first so history is kept even when Hono is unreachable
+	if store != nil {
+		r.AddSink(&telemetrySink{storage: store})
+	}
+	if cfg.HonoAPIURL != "" {
+		r.AddSink(&honoSink{renderer: r})
+	}
+
+	return r, nil
 }
 
 func (r *Renderer) GetVehicleData(_ context.Context, sessionID, branchID, filterID string, forceLogin bool) ([]VehicleData, string, *HonoAPIResponse, error) {
 	log.Println("GetVehicleData called")
-	// Use fixed parameters for initial request
-	branchID = "00000000"
-	filterID = "0"
-	log.Printf("Using fixed parameters - BranchID: %s, FilterID: %s, ForceLogin: %v", branchID, filterID, forceLogin)
+	// Fall back to all branches / active vehicles when not specified
+	if branchID == "" {
+		branchID = DefaultBranchID
+	}
+	if filterID == "" {
+		filterID = DefaultFilterID
+	}
+	log.Printf("Using parameters - BranchID: %s, FilterID: %s, ForceLogin: %v", branchID, filterID, forceLogin)
+
+	if r.config.VenusKeepAlive {
+		rawData, sessionID, err := r.fetchFromWarmPage(sessionID, branchID, filterID, forceLogin)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("failed to extract vehicle data: %w", err)
+		}
+		vehicleData, suspect := r.processVehicleData(rawData, branchID, filterID)
+		if suspect != nil {
+			return vehicleData, sessionID, nil, suspect
+		}
+		return r.finishVehicleData(vehicleData, sessionID)
+	}
 
 	page := r.browser.MustPage()
 	defer page.MustClose()
@@ -104,66 +178,30 @@ func (r *Renderer) GetVehicleData(_ context.Context, sessionID, branchID, filter
 	// Use a longer timeout for data fetching operations (5 minutes)
 	page = page.Timeout(5 * time.Minute)
 
-	// Check and restore session if exists
-	if sessionID != "" && !forceLogin {
-		session, err := r.storage.GetSession(sessionID)
-		if err != nil {
-			log.Printf("Error getting session: %v", err)
-		} else if session != nil {
-			// Restore cookies
-			cookies, err := r.storage.GetCookies(sessionID)
-			if err != nil {
-				log.Printf("Error getting cookies: %v", err)
-			} else {
-				for _, cookie := range cookies {
-					// Use SetCookies with NetworkCookieParam
-					page.MustSetCookies(&proto.NetworkCookieParam{
-						Name:     cookie.Name,
-						Value:    cookie.Value,
-						Domain:   cookie.Domain,
-						Path:     cookie.Path,
-						Expires:  proto.TimeSinceEpoch(cookie.ExpiresAt.Unix()),
-						HTTPOnly: cookie.HTTPOnly,
-						Secure:   cookie.Secure,
-					})
-				}
-			}
-		}
-	}
-
-	// Try to navigate to main page
-	err := r.navigateToMain(page, branchID, filterID)
+	sessionID, err := r.openVenusMain(page, sessionID, forceLogin)
 	if err != nil {
-		log.Printf("First navigation failed, attempting login: %v", err)
-		// Need to login
-		newSessionID, err := r.login(page)
-		if err != nil {
-			return nil, "", nil, fmt.Errorf("login failed: %w", err)
-		}
-		sessionID = newSessionID
-		log.Printf("Login successful, new session ID: %s", sessionID)
-
-		// Navigate again after login
-		if err := r.navigateToMain(page, branchID, filterID); err != nil {
-			return nil, "", nil, fmt.Errorf("navigation failed after login: %w", err)
-		}
-		log.Println("Navigation to main page successful after login")
-	} else {
-		log.Println("Navigation to main page successful without login")
+		return nil, "", nil, err
 	}
 
 	// Extract vehicle data
 	vehicleData, err := r.extractVehicleData(page, branchID, filterID)
+	var suspect *SuspectScrapeError
+	if errors.As(err, &suspect) {
+		// The vehicles are returned for inspection but were not published
+		return vehicleData, sessionID, nil, err
+	}
 	if err != nil {
 		return nil, "", nil, fmt.Errorf("failed to extract vehicle data: %w", err)
 	}
 
-	// Cache the data
-	for _, vehicle := range vehicleData {
-		r.storage.CacheVehicleData(vehicle.VehicleCD, vehicle, 5*time.Minute)
-	}
+	return r.finishVehicleData(vehicleData, sessionID)
+}
+
+// finishVehicleData caches the extracted vehicles and builds the response.
+func (r *Renderer) finishVehicleData(vehicleData []VehicleData, sessionID string) ([]VehicleData, string, *HonoAPIResponse, error) {
+	r.cacheVehicles(vehicleData)
 
-	// API sending is handled inside GetVehicleData with sendRawToHonoAPI
+	// API sending is handled by the sinks (Hono API via sendRawToHonoAPI)
 	// Using a default success response since raw data was sent successfully
 	honoResponse := &HonoAPIResponse{
 		Success:      true,
@@ -174,6 +212,76 @@ func (r *Renderer) GetVehicleData(_ context.Context, sessionID, branchID, filter
 	return vehicleData, sessionID, honoResponse, nil
 }
 
+// cacheVehicles caches each vehicle for five minutes.
+func (r *Renderer) cacheVehicles(vehicleData []VehicleData) {
+	for _, vehicle := range vehicleData {
+		r.storage.CacheVehicleData(vehicle.VehicleCD, vehicle, 5*time.Minute)
+	}
+}
+
+// restoreSession loads the cookies stored for sessionID into the page.
+func (r *Renderer) restoreSession(page *rod.Page, sessionID string) {
+	session, err := r.storage.GetSession(sessionID)
+	if err != nil {
+		log.Printf("Error getting session: %v", err)
+		return
+	}
+	if session == nil {
+		return
+	}
+
+	cookies, err := r.storage.GetCookies(sessionID)
+	if err != nil {
+		log.Printf("Error getting cookies: %v", err)
+		return
+	}
+	for _, cookie := range cookies {
+		// Use SetCookies with NetworkCookieParam
+		page.MustSetCookies(&proto.NetworkCookieParam{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Domain:   cookie.Domain,
+			Path:     cookie.Path,
+			Expires:  proto.TimeSinceEpoch(cookie.ExpiresAt.Unix()),
+			HTTPOnly: cookie.HTTPOnly,
+			Secure:   cookie.Secure,
+		})
+	}
+}
+
+// openVenusMain navigates the page to VenusMain, restoring the stored session
+// first and logging in again when the portal redirects to the login page.
+// It returns the session ID that is valid for the page.
+func (r *Renderer) openVenusMain(page *rod.Page, sessionID string, forceLogin bool) (string, error) {
+	// Check and restore session if exists
+	if sessionID != "" && !forceLogin {
+		r.restoreSession(page, sessionID)
+	}
+
+	// Try to navigate to main page
+	err := r.navigateToMain(page)
+	if err == nil {
+		log.Println("Navigation to main page successful without login")
+		return sessionID, nil
+	}
+
+	log.Printf("First navigation failed, attempting login: %v", err)
+	// Need to login
+	newSessionID, err := r.login(page)
+	if err != nil {
+		return "", fmt.Errorf("login failed: %w", err)
+	}
+	log.Printf("Login successful, new session ID: %s", newSessionID)
+
+	// Navigate again after login
+	if err := r.navigateToMain(page); err != nil {
+		return "", fmt.Errorf("navigation failed after login: %w", err)
+	}
+	log.Println("Navigation to main page successful after login")
+
+	return newSessionID, nil
+}
+
 func (r *Renderer) login(page *rod.Page) (string, error) {
 	log.Println("Starting login process")
 	log.Printf("Using credentials - Company: %s, User: %s", r.config.CompID, r.config.UserName)
@@ -273,7 +381,7 @@ func (r *Renderer) login(page *rod.Page) (string, error) {
 	return sessionID, nil
 }
 
-func (r *Renderer) navigateToMain(page *rod.Page, branchID, filterID string) error {
+func (r *Renderer) navigateToMain(page *rod.Page) error {
 	log.Println("Navigating to Venus Main page...")
 
 	err := rod.Try(func() {
@@ -306,22 +414,31 @@ func (r *Renderer) extractVehicleData(page *rod.Page, branchID, filterID string)
 	// filterID = "0" excludes deleted vehicles (193 active vehicles)
 	// filterID = "" includes deleted vehicles too (266 total)
 	if filterID == "" {
-		filterID = "0"  // Use "0" to exclude deleted vehicles
+		filterID = "0" // Use "0" to exclude deleted vehicles
 	}
 
 	// First check if VenusBridgeService exists
-	hasService := page.MustEval(`() => {
-		return typeof VenusBridgeService !== 'undefined' &&
-		       typeof VenusBridgeService.VehicleStateTableForBranchEx === 'function';
-	}`).Bool()
-
-	if !hasService {
+	if !r.hasBridgeMethod(page, "VehicleStateTableForBranchEx") {
 		return nil, fmt.Errorf("VenusBridgeService not found on page")
 	}
 
-	// Log the parameters being used
-	log.Printf("Calling VenusBridgeService.VehicleStateTableForBranchEx with branchID='%s', filterID='%s'", branchID, filterID)
+	r.waitVenusReady(page)
+
+	rawData, err := r.fetchVehicleTable(page, branchID, filterID)
+	if err != nil {
+		return nil, err
+	}
 
+	vehicles, suspect := r.processVehicleData(rawData, branchID, filterID)
+	if suspect != nil {
+		return vehicles, suspect
+	}
+	return vehicles, nil
+}
+
+// waitVenusReady waits until the VenusMain grid is present and no loading
+// message is visible, so the bridge service can be called.
+func (r *Renderer) waitVenusReady(page *rod.Page) {
 	// Wait for the page to be stable
 	page.MustWaitStable()
 	time.Sleep(2 * time.Second)
@@ -406,6 +523,13 @@ func (r *Renderer) extractVehicleData(page *rod.Page, branchID, filterID string)
 
 	// Additional wait to ensure JavaScript is ready
 	time.Sleep(3 * time.Second)
+}
+
+// fetchVehicleTable calls VehicleStateTableForBranchEx on a ready VenusMain
+// page and returns the raw records.
+func (r *Renderer) fetchVehicleTable(page *rod.Page, branchID, filterID string) ([]interface{}, error) {
+	// Log the parameters being used
+	log.Printf("Calling VenusBridgeService.VehicleStateTableForBranchEx with branchID='%s', filterID='%s'", branchID, filterID)
 
 	// Execute the JavaScript to get vehicle data
 	log.Println("Executing JavaScript to get vehicle data...")
@@ -427,7 +551,7 @@ func (r *Renderer) extractVehicleData(page *rod.Page, branchID, filterID string)
 				window.__vehicleDataCompleted = true;
 			}
 		);
-	}`, branchID, filterID)
+	}`, branchID, bridgeFilterID(filterID))
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to inject JavaScript: %w", err)
@@ -498,63 +622,140 @@ func (r *Renderer) extractVehicleData(page *rod.Page, branchID, filterID string)
 		return nil, fmt.Errorf("failed to unmarshal vehicle data: %w", err)
 	}
 
+	return rawData, nil
+}
+
+// processVehicleData converts raw records to VehicleData, saves them to
+// ./data and publishes the snapshot to the sinks unless the scrape is
+// suspect, in which case it is held back and the error describes why.
+func (r *Renderer) processVehicleData(rawData []interface{}, branchID, filterID string) ([]VehicleData, *SuspectScrapeError) {
+	vehicles := toVehicleData(rawData, r.gpsDatum())
+	log.Printf("Extracted %d vehicles", len(vehicles))
+	r.registerVehicles(vehicles)
+
+	// Save raw data to local JSON file for debugging
+	timestamp := time.Now().Format("20060102_150405")
+	filename := fmt.Sprintf("./data/vehicles_%s.json", timestamp)
+
+	// Create data directory if it doesn't exist
+	os.MkdirAll("./data", 0755)
+
+	// Save raw data
+	rawJSON, err := json.MarshalIndent(rawData, "", "  ")
+	if err == nil {
+		if err := os.WriteFile(filename, rawJSON, 0644); err == nil {
+			log.Printf("Saved vehicle data to %s", filename)
+		} else {
+			log.Printf("Failed to save vehicle data: %v", err)
+		}
+	}
+
+	snapshot := &Snapshot{
+		BranchID:  branchID,
+		FilterID:  filterID,
+		FetchedAt: time.Now(),
+		Records:   rawData,
+		Vehicles:  vehicles,
+	}
+	if suspect := r.validator.check(snapshot); suspect != nil {
+		log.Printf("Warning: %v; not publishing until confirmed", suspect)
+		return vehicles, suspect
+	}
+
+	// Send raw data to the sinks (Hono API etc.)
+	// Sink failures don't fail the whole operation
+	r.publish(context.Background(), snapshot)
+
+	return vehicles, nil
+}
+
+// gpsDatum returns the configured datum of the portal's coordinates.
+func (r *Renderer) gpsDatum() vehicle.Datum {
+	if r.config != nil && r.config.GPSDatum == string(vehicle.DatumWGS84) {
+		return vehicle.DatumWGS84
+	}
+	return vehicle.DatumTokyo
+}
+
+// toVehicleData converts raw bridge records to VehicleData. Coordinates are
+// converted from datum to WGS84.
+func toVehicleData(rawData []interface{}, datum vehicle.Datum) []VehicleData {
 	// Convert to VehicleData struct
 	vehicles := make([]VehicleData, 0, len(rawData))
+	reported := make(map[string]bool)
+	warn := func(key, format string, args ...interface{}) {
+		if !reported[key] {
+			reported[key] = true
+			log.Printf(format, args...)
+		}
+	}
+
 	for _, rawItem := range rawData {
 		item, ok := rawItem.(map[string]interface{})
 		if !ok {
+			log.Printf("Warning: skipping vehicle record of type %T", rawItem)
 			continue
 		}
-		vehicle := VehicleData{
-			Metadata: make(map[string]string),
+
+		telemetry, issues := vehicle.Decode(item)
+		for _, issue := range issues {
+			warn(issue.Kind+":"+issue.Field, "Warning: vehicle record has %s", issue)
+		}
+
+		vd := VehicleData{
+			Metadata:  make(map[string]string),
+			Telemetry: telemetry,
+			Raw:       item,
 		}
 
 		if v, ok := item["VehicleCD"].(string); ok {
-			vehicle.VehicleCD = v
+			vd.VehicleCD = v
 		}
 		if v, ok := item["VehicleName"].(string); ok {
-			vehicle.VehicleName = v
+			vd.VehicleName = v
 		}
 		if v, ok := item["Status"].(string); ok {
-			vehicle.Status = v
+			vd.Status = v
+		}
+		if vd.VehicleCD == "" && telemetry.VehicleCD != 0 {
+			vd.VehicleCD = strconv.FormatInt(telemetry.VehicleCD, 10)
+		}
+		if plate, ok := vehicle.ParsePlate(vd.VehicleName); ok {
+			vd.Plate = plate
+		}
+		vd.Position = telemetry.Position(datum)
+
+		// Portal timestamps are Asia/Tokyo local time
+		for _, ts := range []struct {
+			field string
+			value string
+			dest  **time.Time
+		}{
+			{"DataDateTime", telemetry.DataDateTime, &vd.DataTime},
+			{"ComuDateTime", telemetry.ComuDateTime, &vd.ComuTime},
+			{"StartWorkDateTime", telemetry.StartWorkDateTime, &vd.StartWorkTime},
+		} {
+			t, err := vehicle.ParseTime(ts.value)
+			if err != nil {
+				warn("time:"+ts.field, "Warning: vehicle record has invalid %s: %v", ts.field, err)
+				continue
+			}
+			if !t.IsZero() {
+				*ts.dest = &t
+			}
 		}
 
 		// Add all other fields to metadata
 		for k, v := range item {
 			if k != "VehicleCD" && k != "VehicleName" && k != "Status" {
-				vehicle.Metadata[k] = fmt.Sprintf("%v", v)
+				vd.Metadata[k] = fmt.Sprintf("%v", v)
 			}
 		}
 
-		vehicles = append(vehicles, vehicle)
-	}
-
-	log.Printf("Extracted %d vehicles", len(vehicles))
-
-	// Save raw data to local JSON file for debugging
-	timestamp := time.Now().Format("20060102_150405")
-	filename := fmt.Sprintf("./data/vehicles_%s.json", timestamp)
-
-	// Create data directory if it doesn't exist
-	os.MkdirAll("./data", 0755)
-
-	// Save raw data
-	rawJSON, err := json.MarshalIndent(rawData, "", "  ")
-	if err == nil {
-		if err := os.WriteFile(filename, rawJSON, 0644); err == nil {
-			log.Printf("Saved vehicle data to %s", filename)
-		} else {
-			log.Printf("Failed to save vehicle data: %v", err)
-		}
+		vehicles = append(vehicles, vd)
 	}
 
-	// Send raw data to Hono API
-	if _, err := r.sendRawToHonoAPI(rawData); err != nil {
-		log.Printf("Warning: Failed to send to Hono API: %v", err)
-		// Don't fail the whole operation if API fails
-	}
-
-	return vehicles, nil
+	return vehicles
 }
 
 func (r *Renderer) CheckSession(sessionID string) (bool, string) {
@@ -624,7 +825,7 @@ func (r *Renderer) sendRawToHonoAPI(rawData []interface{}) (*HonoAPIResponse, er
 
 	log.Printf("sendRawToHonoAPI: JSON size: %d bytes", len(jsonData))
 
-	req, err := http.NewRequest("POST", "https://hono-api.mtamaramu.com/api/dtakologs",
+	req, err := http.NewRequest("POST", r.config.HonoAPIURL,
 		bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -668,10 +869,11 @@ func (r *Renderer) convertToHonoFormat(vehicles []VehicleData) []map[string]inte
 			data[key] = r.convertToNumber(value)
 		}
 
-		// Generate VehicleCD from VehicleName if empty
-		vehicleCD := r.extractVehicleCode(vehicle.VehicleName)
-		if vehicleCD > 0 {
-			data["VehicleCD"] = vehicleCD
+		// Use the portal's VehicleCD, or the registry ID when it has none
+		if vehicle.Telemetry != nil && vehicle.Telemetry.VehicleCD != 0 {
+			data["VehicleCD"] = vehicle.Telemetry.VehicleCD
+		} else if vehicle.VehicleID != 0 {
+			data["VehicleCD"] = vehicle.VehicleID
 		}
 
 		// Add VehicleName
@@ -679,7 +881,7 @@ func (r *Renderer) convertToHonoFormat(vehicles []VehicleData) []map[string]inte
 
 		// Format DataDateTime
 		if dt, ok := data["DataDateTime"].(string); ok {
-			data["DataDateTime"] = r.formatDateTime(dt)
+			data["DataDateTime"] = honoDateTime(dt)
 		}
 
 		result = append(result, data)
@@ -701,47 +903,10 @@ func (r *Renderer) convertToNumber(value string) interface{} {
 	return value
 }
 
-func (r *Renderer) extractVehicleCode(vehicleName string) int {
-	if vehicleName == "" {
-		return 0
-	}
-
-	// Extract all numbers from vehicle name
-	re := regexp.MustCompile(`\d+`)
-	matches := re.FindAllString(vehicleName, -1)
-
-	if len(matches) > 0 {
-		// Combine all numbers
-		combined := strings.Join(matches, "")
-		if code, err := strconv.Atoi(combined); err == nil {
-			return code % 2147483647 // Keep within integer range
-		}
-	}
-
-	// Generate hash-based ID if no numbers found
-	hash := 0
-	for _, r := range vehicleName {
-		hash = hash*31 + int(r)
-	}
-	return hash % 2147483647
-}
-
-func (r *Renderer) formatDateTime(dt string) string {
-	if dt == "" || dt == "<nil>" {
-		return ""
-	}
-
-	// Remove "20" prefix if exists to avoid double prefixing
-	if strings.HasPrefix(dt, "20") {
-		return dt[2:]
-	}
-
-	return dt
-}
-
 func (r *Renderer) Close() error {
+	r.closeVenusPages()
 	if r.browser != nil {
 		return r.browser.Close()
 	}
 	return nil
-}
\ No newline at end of file
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/yhonda-ohishi/browser_render_go/src/config"
 	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
 )
 
 func TestNewRenderer_Config(t *testing.T) {
@@ -72,6 +73,48 @@ func TestVehicleData_Structure(t *testing.T) {
 	}
 }
 
+func TestToVehicleData_Telemetry(t *testing.T) {
+	vehicles := toVehicleData([]interface{}{
+		map[string]interface{}{
+			"VehicleCD":    float64(42),
+			"VehicleName":  "42号車 品川３００あ１２－３４",
+			"Speed":        float64(55.5),
+			"DriverCD":     float64(7),
+			"DataDateTime": "25/09/29 10:30",
+			"GPSLatitude":  float64(128440800),
+			"GPSLongitude": float64(503174880),
+			"GPSEnable":    float64(1),
+		},
+		"not a record",
+	}, vehicle.DatumTokyo)
+
+	if len(vehicles) != 1 {
+		t.Fatalf("Expected 1 vehicle, got %d", len(vehicles))
+	}
+	vd := vehicles[0]
+	if vd.VehicleCD != "42" {
+		t.Errorf("Expected numeric VehicleCD to be kept as '42', got '%s'", vd.VehicleCD)
+	}
+	if vd.Telemetry == nil {
+		t.Fatal("Expected telemetry to be decoded")
+	}
+	if vd.Telemetry.Speed != 55.5 || vd.Telemetry.DriverCD != 7 {
+		t.Errorf("Unexpected telemetry: %+v", vd.Telemetry)
+	}
+	if speed, ok := vd.Raw["Speed"].(float64); !ok || speed != 55.5 {
+		t.Errorf("Expected raw Speed to stay a number, got %#v", vd.Raw["Speed"])
+	}
+	if vd.Plate == nil || vd.Plate.Key() != "品川300あ1234" {
+		t.Errorf("Expected plate to be parsed from VehicleName, got %+v", vd.Plate)
+	}
+	if vd.Position == nil || vd.Position.Quality != vehicle.QualityLow {
+		t.Errorf("Expected low quality WGS84 position, got %+v", vd.Position)
+	}
+	if vd.DataTime == nil || vd.DataTime.Format(time.RFC3339) != "2025-09-29T10:30:00+09:00" {
+		t.Errorf("Expected DataTime in Asia/Tokyo, got %v", vd.DataTime)
+	}
+}
+
 // Test renderer methods when browser is nil (graceful handling)
 func TestRenderer_NilBrowser(t *testing.T) {
 	cfg := &config.Config{
@@ -188,4 +231,4 @@ func TestRenderer_StorageIntegration(t *testing.T) {
 	if renderer.storage == nil {
 		t.Error("Renderer storage should not be nil")
 	}
-}
\ No newline at end of file
+}
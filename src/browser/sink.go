@@ -0,0 +1,104 @@
+package browser
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"time"
+)
+
+// Snapshot is one result set from VehicleStateTableForBranchEx.
+type Snapshot struct {
+	BranchID  string
+	FilterID  string
+	FetchedAt time.Time
+	// Partial is set when Records only holds the vehicles whose DataDateTime
+	// changed since the previous poll rather than the full fleet.
+	Partial  bool
+	Records  []interface{} // raw records as returned by the bridge service
+	Vehicles []VehicleData
+}
+
+// Sink receives every snapshot the renderer fetches.
+type Sink interface {
+	Name() string
+	Write(ctx context.Context, snapshot *Snapshot) error
+}
+
+// AddSink registers a sink. Sinks are written in registration order.
+func (r *Renderer) AddSink(sink Sink) {
+	r.sinksMu.Lock()
+	defer r.sinksMu.Unlock()
+	r.sinks = append(r.sinks, sink)
+}
+
+// publish writes the snapshot to every sink. A failing sink is logged and
+// does not stop the others.
+func (r *Renderer) publish(ctx context.Context, snapshot *Snapshot) {
+	r.sinksMu.RLock()
+	sinks := append([]Sink(nil), r.sinks...)
+	r.sinksMu.RUnlock()
+
+	for _, sink := range sinks {
+		if err := sink.Write(ctx, snapshot); err != nil {
+			log.Printf("Warning: sink %s failed: %v", sink.Name(), err)
+		}
+	}
+}
+
+// honoSink sends raw records to the Hono dtakologs API. Hono stores
+// DataDateTime in the legacy two-digit-year form, so timestamps are rewritten
+// here and nowhere else.
+type honoSink struct {
+	renderer *Renderer
+}
+
+func (s *honoSink) Name() string {
+	return "hono"
+}
+
+func (s *honoSink) Write(_ context.Context, snapshot *Snapshot) error {
+	if len(snapshot.Records) == 0 {
+		return nil
+	}
+	_, err := s.renderer.sendRawToHonoAPI(honoRecords(snapshot.Records))
+	return err
+}
+
+// honoRecords copies the records with DataDateTime in Hono's format.
+func honoRecords(records []interface{}) []interface{} {
+	out := make([]interface{}, len(records))
+	for i, rawItem := range records {
+		item, ok := rawItem.(map[string]interface{})
+		if !ok {
+			out[i] = rawItem
+			continue
+		}
+		copied := make(map[string]interface{}, len(item))
+		for k, v := range item {
+			copied[k] = v
+		}
+		if dt, ok := copied["DataDateTime"].(string); ok {
+			copied["DataDateTime"] = honoDateTime(dt)
+		}
+		out[i] = copied
+	}
+	return out
+}
+
+// fourDigitYear matches a DataDateTime that starts with a four-digit year.
+var fourDigitYear = regexp.MustCompile(`^\d{4}/`)
+
+// honoDateTime drops the century from the portal's DataDateTime and keeps
+// the rest of the string as is. dtakologs rows are keyed on DataDateTime, so
+// its padding and precision must not change. Values that already have a
+// two-digit year, such as the dtakologs default "20/1/1 00:00", are kept.
+func honoDateTime(dt string) string {
+	if dt == "<nil>" {
+		return ""
+	}
+	if fourDigitYear.MatchString(dt) {
+		return dt[2:]
+	}
+	return dt
+}
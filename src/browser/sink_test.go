@@ -0,0 +1,78 @@
+package browser
+
+import (
+	"testing"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+func TestHonoDateTime(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"2025/09/29 10:30", "25/09/29 10:30"},
+		// A portal value keeps its seconds and padding, as in stored rows
+		{"2025/09/29 10:30:00", "25/09/29 10:30:00"},
+		{"2025/09/29 10:30:15", "25/09/29 10:30:15"},
+		{"2025/9/29 9:05", "25/9/29 9:05"},
+		{"25/09/29 10:30", "25/09/29 10:30"},
+		// Two-digit years from 2020 keep their leading "20"
+		{"20/1/1 00:00", "20/1/1 00:00"},
+		{"20/09/29 10:30", "20/09/29 10:30"},
+		{"", ""},
+		{"<nil>", ""},
+		{"not a date", "not a date"},
+	}
+
+	for _, tt := range tests {
+		if got := honoDateTime(tt.input); got != tt.want {
+			t.Errorf("honoDateTime(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestHonoRecords_DoesNotModifyInput(t *testing.T) {
+	record := map[string]interface{}{"DataDateTime": "2025/09/29 10:30", "Speed": float64(10)}
+
+	out := honoRecords([]interface{}{record, "not a record"})
+
+	if record["DataDateTime"] != "2025/09/29 10:30" {
+		t.Errorf("Input record was modified: %v", record["DataDateTime"])
+	}
+	converted := out[0].(map[string]interface{})
+	if converted["DataDateTime"] != "25/09/29 10:30" || converted["Speed"] != float64(10) {
+		t.Errorf("Unexpected Hono record: %v", converted)
+	}
+	if out[1] != "not a record" {
+		t.Errorf("Expected non-object record to pass through, got %v", out[1])
+	}
+}
+
+func TestTelemetryRecords(t *testing.T) {
+	vehicles := toVehicleData([]interface{}{
+		map[string]interface{}{
+			"VehicleCD":    float64(42),
+			"VehicleName":  "42号車",
+			"Speed":        float64(55.5),
+			"DataDateTime": "25/09/29 10:30",
+			"GPSLatitude":  float64(128440800),
+			"GPSLongitude": float64(503174880),
+			"GPSEnable":    float64(1),
+		},
+		map[string]interface{}{"VehicleCD": float64(43), "VehicleName": "No time"},
+		map[string]interface{}{"VehicleName": "No code", "DataDateTime": "25/09/29 10:30"},
+	}, vehicle.DatumTokyo)
+
+	records, skipped := telemetryRecords(&Snapshot{Vehicles: vehicles})
+	if len(records) != 1 || skipped != 2 {
+		t.Fatalf("Expected 1 record and 2 skipped, got %d and %d", len(records), skipped)
+	}
+	r := records[0]
+	if r.VehicleCD != 42 || r.DataDateTime != "25/09/29 10:30" || r.Speed != 55.5 {
+		t.Errorf("Unexpected record: %+v", r)
+	}
+	if r.Latitude == nil || !r.GPSValid || r.Record == "" {
+		t.Errorf("Expected position and raw record, got %+v", r)
+	}
+}
@@ -0,0 +1,130 @@
+package browser
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+)
+
+// Step is one browser action in a configurable click path.
+//
+// Supported actions:
+//   - navigate: load URL
+//   - click:    click the element matching Selector
+//   - input:    type Value into the element matching Selector
+//   - select:   choose the option with text Value in the <select> matching Selector
+//   - wait:     wait until the element matching Selector is visible
+//   - sleep:    pause for Value milliseconds
+//   - eval:     run the JavaScript function in Script
+//
+// Value, URL and Script may contain {{name}} placeholders that are replaced
+// with the variables passed to runSteps. Navigation is refused for internal
+// hosts, see validateRenderURL.
+type Step struct {
+	Action    string `json:"action" yaml:"action"`
+	Selector  string `json:"selector,omitempty" yaml:"selector,omitempty"`
+	Value     string `json:"value,omitempty" yaml:"value,omitempty"`
+	URL       string `json:"url,omitempty" yaml:"url,omitempty"`
+	Script    string `json:"script,omitempty" yaml:"script,omitempty"`
+	TimeoutMS int    `json:"timeout_ms,omitempty" yaml:"timeout_ms,omitempty"` // per-step timeout (default 30s)
+}
+
+const defaultStepTimeout = 30 * time.Second
+
+// Validate checks that the step has the fields its action needs.
+func (s Step) Validate() error {
+	switch s.Action {
+	case "navigate":
+		if s.URL == "" {
+			return fmt.Errorf("navigate step requires url")
+		}
+	case "click", "wait", "input", "select":
+		if s.Selector == "" {
+			return fmt.Errorf("%s step requires selector", s.Action)
+		}
+	case "sleep":
+		if _, err := strconv.Atoi(s.Value); err != nil {
+			return fmt.Errorf("sleep step requires value in milliseconds")
+		}
+	case "eval":
+		if s.Script == "" {
+			return fmt.Errorf("eval step requires script")
+		}
+	default:
+		return fmt.Errorf("unknown step action: %q", s.Action)
+	}
+	return nil
+}
+
+// ValidateSteps validates every step, reporting the index of the first bad one.
+func ValidateSteps(steps []Step) error {
+	for i, step := range steps {
+		if err := step.Validate(); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// runSteps executes the steps in order on the page.
+func (r *Renderer) runSteps(page *rod.Page, steps []Step, vars map[string]string) error {
+	replacer := newVarReplacer(vars)
+
+	for i, step := range steps {
+		timeout := defaultStepTimeout
+		if step.TimeoutMS > 0 {
+			timeout = time.Duration(step.TimeoutMS) * time.Millisecond
+		}
+		p := page.Timeout(timeout)
+
+		value := replacer.Replace(step.Value)
+		log.Printf("Step %d/%d: %s %s", i+1, len(steps), step.Action, step.Selector)
+
+		target := replacer.Replace(step.URL)
+		if step.Action == "navigate" {
+			if err := validateRenderURL(target, r.config.RenderAllowedHosts); err != nil {
+				return fmt.Errorf("step %d (navigate): %w", i+1, err)
+			}
+		}
+
+		err := rod.Try(func() {
+			switch step.Action {
+			case "navigate":
+				p.MustNavigate(target)
+				p.MustWaitLoad()
+			case "click":
+				p.MustElement(step.Selector).MustClick()
+			case "input":
+				p.MustElement(step.Selector).MustSelectAllText().MustInput(value)
+			case "select":
+				p.MustElement(step.Selector).MustSelect(value)
+			case "wait":
+				p.MustElement(step.Selector).MustWaitVisible()
+			case "sleep":
+				ms, _ := strconv.Atoi(value)
+				time.Sleep(time.Duration(ms) * time.Millisecond)
+			case "eval":
+				p.MustEval(replacer.Replace(step.Script))
+			default:
+				panic(fmt.Errorf("unknown step action: %q", step.Action))
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("step %d (%s %s) failed: %w", i+1, step.Action, step.Selector, err)
+		}
+	}
+
+	return nil
+}
+
+func newVarReplacer(vars map[string]string) *strings.Replacer {
+	pairs := make([]string, 0, len(vars)*2)
+	for k, v := range vars {
+		pairs = append(pairs, "{{"+k+"}}", v)
+	}
+	return strings.NewReplacer(pairs...)
+}
@@ -0,0 +1,57 @@
+package browser
+
+import "testing"
+
+func TestStep_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    Step
+		wantErr bool
+	}{
+		{"Navigate", Step{Action: "navigate", URL: "https://example.com"}, false},
+		{"Navigate without url", Step{Action: "navigate"}, true},
+		{"Click", Step{Action: "click", Selector: "#btn"}, false},
+		{"Click without selector", Step{Action: "click"}, true},
+		{"Input", Step{Action: "input", Selector: "#txt", Value: "{{date_from}}"}, false},
+		{"Sleep", Step{Action: "sleep", Value: "500"}, false},
+		{"Sleep without duration", Step{Action: "sleep", Value: "soon"}, true},
+		{"Eval", Step{Action: "eval", Script: "() => 1"}, false},
+		{"Eval without script", Step{Action: "eval"}, true},
+		{"Unknown action", Step{Action: "hover", Selector: "#x"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.step.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSteps_ReportsIndex(t *testing.T) {
+	err := ValidateSteps([]Step{
+		{Action: "click", Selector: "#ok"},
+		{Action: "click"},
+	})
+	if err == nil {
+		t.Fatal("Expected error for invalid second step")
+	}
+	if got := err.Error(); got[:6] != "step 2" {
+		t.Errorf("Expected error to name step 2, got %q", got)
+	}
+}
+
+func TestVarReplacer(t *testing.T) {
+	r := newVarReplacer(map[string]string{"date_from": "2025/09/01", "date_to": "2025/09/30"})
+
+	got := r.Replace("{{date_from}}-{{date_to}} {{unknown}}")
+	want := "2025/09/01-2025/09/30 {{unknown}}"
+	if got != want {
+		t.Errorf("Replace() = %q, want %q", got, want)
+	}
+
+	if got := newVarReplacer(nil).Replace("{{date_from}}"); got != "{{date_from}}" {
+		t.Errorf("Expected placeholders to be kept without vars, got %q", got)
+	}
+}
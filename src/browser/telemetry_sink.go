@@ -0,0 +1,75 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+)
+
+// telemetrySink keeps every fetched vehicle state in the local telemetry
+// table, so history is available even when Hono is unreachable.
+type telemetrySink struct {
+	storage *storage.Storage
+}
+
+func (s *telemetrySink) Name() string {
+	return "telemetry"
+}
+
+func (s *telemetrySink) Write(_ context.Context, snapshot *Snapshot) error {
+	records, skipped := telemetryRecords(snapshot)
+	if skipped > 0 {
+		log.Printf("Warning: %d vehicles without VehicleCD or DataDateTime were not stored", skipped)
+	}
+	if err := s.storage.SaveTelemetry(records); err != nil {
+		return fmt.Errorf("failed to save telemetry: %w", err)
+	}
+	return nil
+}
+
+// telemetryRecords converts the snapshot's vehicles to storage rows. Vehicles
+// without a portal VehicleCD or a parsable DataDateTime cannot be keyed and
+// are counted as skipped.
+func telemetryRecords(snapshot *Snapshot) ([]storage.TelemetryRecord, int) {
+	records := make([]storage.TelemetryRecord, 0, len(snapshot.Vehicles))
+	skipped := 0
+
+	for _, vd := range snapshot.Vehicles {
+		t := vd.Telemetry
+		if t == nil || t.VehicleCD == 0 || vd.DataTime == nil {
+			skipped++
+			continue
+		}
+
+		raw, err := json.Marshal(vd.Raw)
+		if err != nil {
+			skipped++
+			continue
+		}
+
+		record := storage.TelemetryRecord{
+			VehicleCD:    t.VehicleCD,
+			DataTime:     *vd.DataTime,
+			DataDateTime: t.DataDateTime,
+			VehicleID:    vd.VehicleID,
+			VehicleName:  vd.VehicleName,
+			BranchCD:     t.BranchCD,
+			DriverCD:     t.DriverCD,
+			Speed:        t.Speed,
+			Record:       string(raw),
+			FetchedAt:    snapshot.FetchedAt,
+		}
+		if p := vd.Position; p != nil {
+			lat, lon := p.Latitude, p.Longitude
+			record.Latitude = &lat
+			record.Longitude = &lon
+			record.GPSValid = p.Valid
+		}
+		records = append(records, record)
+	}
+
+	return records, skipped
+}
@@ -0,0 +1,248 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+)
+
+// ScrapeRules configure the validation of full scrapes against the vehicle
+// counts recently accepted for the same branch and filter.
+type ScrapeRules struct {
+	MinRatio       float64 // a count below the baseline times MinRatio is suspect (0 disables validation)
+	BaselineSize   int     // accepted counts whose median is the baseline
+	AgreeTolerance float64 // relative difference within which the next scrape confirms a suspect one
+}
+
+// SuspectScrapeError is returned when a scrape is held back from the sinks
+// because its vehicle count does not match the baseline.
+type SuspectScrapeError struct {
+	BranchID string
+	FilterID string
+	Count    int
+	Baseline int
+	Reason   string
+}
+
+func (e *SuspectScrapeError) Error() string {
+	return fmt.Sprintf("suspect scrape for branch %s filter %s: %s", e.BranchID, e.FilterID, e.Reason)
+}
+
+// PendingScrape is a suspect scrape waiting to be confirmed.
+type PendingScrape struct {
+	BranchID  string    `json:"branch_id"`
+	FilterID  string    `json:"filter_id"`
+	Count     int       `json:"count"`
+	Baseline  int       `json:"baseline"`
+	Reason    string    `json:"reason"`
+	FetchedAt time.Time `json:"fetched_at"`
+
+	snapshot *Snapshot
+}
+
+// scrapeValidator compares every full scrape with the median of the counts
+// recently accepted for its branch and filter. A suspect scrape is held back
+// until an operator confirms it or the next scrape returns about the same
+// count, which then becomes the new baseline. Counts are kept in the kv
+// store; held scrapes are lost on restart.
+type scrapeValidator struct {
+	rules   ScrapeRules
+	storage *storage.Storage
+
+	mu      sync.Mutex
+	counts  map[string][]int
+	pending map[string]*PendingScrape
+}
+
+func newScrapeValidator(rules ScrapeRules, store *storage.Storage) *scrapeValidator {
+	if rules.BaselineSize <= 0 {
+		rules.BaselineSize = 1
+	}
+	return &scrapeValidator{
+		rules:   rules,
+		storage: store,
+		counts:  make(map[string][]int),
+		pending: make(map[string]*PendingScrape),
+	}
+}
+
+func scrapeKey(branchID, filterID string) string {
+	return branchID + "/" + filterID
+}
+
+// check decides whether the snapshot may be published. Partial snapshots
+// only hold changed vehicles and are not checked.
+func (v *scrapeValidator) check(snapshot *Snapshot) *SuspectScrapeError {
+	if v == nil || v.rules.MinRatio <= 0 || snapshot.Partial {
+		return nil
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key := scrapeKey(snapshot.BranchID, snapshot.FilterID)
+	count := len(snapshot.Vehicles)
+	baseline := median(v.load(key))
+
+	reason := v.suspect(count, baseline)
+	if reason == "" {
+		delete(v.pending, key)
+		v.accept(key, count, false)
+		return nil
+	}
+
+	if prev := v.pending[key]; prev != nil && v.agrees(prev.Count, count) {
+		log.Printf("Scrape for branch %s filter %s confirmed by the next scrape: %d vehicles (was %d)",
+			snapshot.BranchID, snapshot.FilterID, count, baseline)
+		delete(v.pending, key)
+		v.accept(key, count, true)
+		return nil
+	}
+
+	v.pending[key] = &PendingScrape{
+		BranchID:  snapshot.BranchID,
+		FilterID:  snapshot.FilterID,
+		Count:     count,
+		Baseline:  baseline,
+		Reason:    reason,
+		FetchedAt: snapshot.FetchedAt,
+		snapshot:  snapshot,
+	}
+	return &SuspectScrapeError{
+		BranchID: snapshot.BranchID,
+		FilterID: snapshot.FilterID,
+		Count:    count,
+		Baseline: baseline,
+		Reason:   reason,
+	}
+}
+
+// suspect returns why count does not fit the baseline, or "" when it does.
+// A baseline of 0 means no scrape has been accepted yet.
+func (v *scrapeValidator) suspect(count, baseline int) string {
+	switch {
+	case count == 0:
+		return "no vehicles returned"
+	case baseline > 0 && float64(count) < float64(baseline)*v.rules.MinRatio:
+		return fmt.Sprintf("%d vehicles, %.0f%% of the baseline %d", count, float64(count)/float64(baseline)*100, baseline)
+	}
+	return ""
+}
+
+func (v *scrapeValidator) agrees(prev, count int) bool {
+	return math.Abs(float64(count-prev)) <= float64(prev)*v.rules.AgreeTolerance
+}
+
+// accept adds count to the baseline, or makes it the only count when reset.
+func (v *scrapeValidator) accept(key string, count int, reset bool) {
+	counts := append(v.counts[key], count)
+	if reset {
+		counts = []int{count}
+	}
+	if len(counts) > v.rules.BaselineSize {
+		counts = counts[len(counts)-v.rules.BaselineSize:]
+	}
+	v.counts[key] = counts
+
+	if v.storage != nil {
+		if err := v.storage.Set("scrape_counts:"+key, counts); err != nil {
+			log.Printf("Warning: failed to save scrape baseline: %v", err)
+		}
+	}
+}
+
+// load returns the accepted counts, reading them from the kv store the first
+// time a key is used.
+func (v *scrapeValidator) load(key string) []int {
+	counts, ok := v.counts[key]
+	if ok || v.storage == nil {
+		return counts
+	}
+	if err := v.storage.Get("scrape_counts:"+key, &counts); err != nil {
+		counts = nil
+	}
+	v.counts[key] = counts
+	return counts
+}
+
+// confirm releases the held scrape, making its count the new baseline.
+func (v *scrapeValidator) confirm(branchID, filterID string) (*PendingScrape, error) {
+	if v == nil {
+		return nil, fmt.Errorf("no suspect scrape for branch %s filter %s", branchID, filterID)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key := scrapeKey(branchID, filterID)
+	p := v.pending[key]
+	if p == nil {
+		return nil, fmt.Errorf("no suspect scrape for branch %s filter %s", branchID, filterID)
+	}
+	delete(v.pending, key)
+	v.accept(key, p.Count, true)
+	return p, nil
+}
+
+func (v *scrapeValidator) list() []PendingScrape {
+	if v == nil {
+		return nil
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	pending := make([]PendingScrape, 0, len(v.pending))
+	for _, p := range v.pending {
+		pending = append(pending, *p)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return scrapeKey(pending[i].BranchID, pending[i].FilterID) < scrapeKey(pending[j].BranchID, pending[j].FilterID)
+	})
+	return pending
+}
+
+func median(counts []int) int {
+	if len(counts) == 0 {
+		return 0
+	}
+	sorted := append([]int(nil), counts...)
+	sort.Ints(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// PendingScrapes returns the suspect scrapes held back from the sinks.
+func (r *Renderer) PendingScrapes() []PendingScrape {
+	return r.validator.list()
+}
+
+// ConfirmScrape publishes the suspect scrape held for the branch and filter
+// and makes its vehicle count the new baseline. Like a scrape, the sinks
+// run detached from the caller, so they are not cut short when it returns.
+func (r *Renderer) ConfirmScrape(branchID, filterID string) (*PendingScrape, error) {
+	if branchID == "" {
+		branchID = DefaultBranchID
+	}
+	if filterID == "" {
+		filterID = DefaultFilterID
+	}
+
+	p, err := r.validator.confirm(branchID, filterID)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Suspect scrape for branch %s filter %s confirmed: %d vehicles", branchID, filterID, p.Count)
+	r.cacheVehicles(p.snapshot.Vehicles)
+	r.publish(context.Background(), p.snapshot)
+	return p, nil
+}
@@ -0,0 +1,134 @@
+package browser
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+)
+
+type snapshotSink struct {
+	snapshots []*Snapshot
+}
+
+func (s *snapshotSink) Name() string {
+	return "snapshots"
+}
+
+func (s *snapshotSink) Write(_ context.Context, snapshot *Snapshot) error {
+	s.snapshots = append(s.snapshots, snapshot)
+	return nil
+}
+
+func scrapeOf(count int) *Snapshot {
+	vehicles := make([]VehicleData, count)
+	for i := range vehicles {
+		vehicles[i].VehicleCD = string(rune('A' + i%26))
+	}
+	return &Snapshot{BranchID: DefaultBranchID, FilterID: DefaultFilterID, FetchedAt: time.Now(), Vehicles: vehicles}
+}
+
+func newTestValidator(t *testing.T) (*scrapeValidator, *storage.Storage) {
+	store, err := storage.NewStorage(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return newScrapeValidator(ScrapeRules{MinRatio: 0.8, BaselineSize: 3, AgreeTolerance: 0.05}, store), store
+}
+
+func TestScrapeValidator_Check(t *testing.T) {
+	tests := []struct {
+		name    string
+		counts  []int
+		suspect []bool
+		partial []bool
+	}{
+		{"first scrape sets the baseline", []int{193}, []bool{false}, nil},
+		{"zero is always suspect", []int{0}, []bool{true}, nil},
+		{"small drop is accepted", []int{193, 190, 160}, []bool{false, false, false}, nil},
+		{"large drop is suspect", []int{193, 193, 120}, []bool{false, false, true}, nil},
+		{"next scrape agrees", []int{193, 120, 118}, []bool{false, true, false}, nil},
+		{"next scrape disagrees", []int{193, 120, 60}, []bool{false, true, true}, nil},
+		{"recovery drops the suspect scrape", []int{193, 0, 192}, []bool{false, true, false}, nil},
+		{"agreed count is the new baseline", []int{193, 120, 120, 110}, []bool{false, true, false, false}, nil},
+		{"partial data is not checked", []int{193, 0, 0}, []bool{false, true, false}, []bool{false, false, true}},
+		{"baseline is the median", []int{193, 160, 193, 150}, []bool{false, false, false, true}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, _ := newTestValidator(t)
+			for i, count := range tt.counts {
+				snapshot := scrapeOf(count)
+				snapshot.Partial = i < len(tt.partial) && tt.partial[i]
+				suspect := v.check(snapshot)
+				if (suspect != nil) != tt.suspect[i] {
+					t.Fatalf("Scrape %d (%d vehicles): suspect = %v, want %v", i, count, suspect, tt.suspect[i])
+				}
+			}
+		})
+	}
+}
+
+func TestScrapeValidator_Disabled(t *testing.T) {
+	v := newScrapeValidator(ScrapeRules{}, nil)
+	if suspect := v.check(scrapeOf(0)); suspect != nil {
+		t.Errorf("Expected no validation with MinRatio 0, got %v", suspect)
+	}
+
+	var none *scrapeValidator
+	if suspect := none.check(scrapeOf(0)); suspect != nil {
+		t.Errorf("Expected a nil validator to accept everything, got %v", suspect)
+	}
+}
+
+func TestScrapeValidator_BaselinePersisted(t *testing.T) {
+	v, store := newTestValidator(t)
+	for _, count := range []int{193, 193, 193} {
+		v.check(scrapeOf(count))
+	}
+
+	restarted := newScrapeValidator(v.rules, store)
+	suspect := restarted.check(scrapeOf(100))
+	if suspect == nil {
+		t.Fatal("Expected the stored baseline to be used after a restart")
+	}
+	if suspect.Baseline != 193 || suspect.Count != 100 {
+		t.Errorf("Unexpected suspect scrape: %+v", suspect)
+	}
+}
+
+func TestRenderer_ConfirmScrape(t *testing.T) {
+	v, store := newTestValidator(t)
+	sink := &snapshotSink{}
+	r := &Renderer{storage: store, validator: v}
+	r.AddSink(sink)
+
+	v.check(scrapeOf(193))
+	if suspect := v.check(scrapeOf(50)); suspect == nil {
+		t.Fatal("Expected a suspect scrape")
+	}
+	if pending := r.PendingScrapes(); len(pending) != 1 || pending[0].Count != 50 || pending[0].Baseline != 193 {
+		t.Fatalf("Unexpected pending scrapes: %+v", pending)
+	}
+
+	confirmed, err := r.ConfirmScrape("", "")
+	if err != nil {
+		t.Fatalf("ConfirmScrape failed: %v", err)
+	}
+	if confirmed.Count != 50 || len(sink.snapshots) != 1 || len(sink.snapshots[0].Vehicles) != 50 {
+		t.Errorf("Expected the held scrape to be published, got %+v and %d snapshots", confirmed, len(sink.snapshots))
+	}
+	if len(r.PendingScrapes()) != 0 {
+		t.Error("Expected no pending scrapes after confirming")
+	}
+	if suspect := v.check(scrapeOf(48)); suspect != nil {
+		t.Errorf("Expected the confirmed count to be the new baseline, got %v", suspect)
+	}
+
+	if _, err := r.ConfirmScrape("", ""); err == nil {
+		t.Error("Expected an error when nothing is pending")
+	}
+}
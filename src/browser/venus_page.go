@@ -0,0 +1,131 @@
+package browser
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+)
+
+// venusPage is a VenusMain tab kept open between requests so that only the
+// bridge call has to run for each refresh (VENUS_KEEP_ALIVE=true).
+type venusPage struct {
+	mu        sync.Mutex
+	page      *rod.Page
+	sessionID string
+}
+
+// warmVenusPage returns the kept-alive page for the account, creating the
+// entry on first use. Callers must hold vp.mu while using the page.
+func (r *Renderer) warmVenusPage(account string) *venusPage {
+	r.venusPagesMu.Lock()
+	defer r.venusPagesMu.Unlock()
+
+	if r.venusPages == nil {
+		r.venusPages = make(map[string]*venusPage)
+	}
+	vp, ok := r.venusPages[account]
+	if !ok {
+		vp = &venusPage{}
+		r.venusPages[account] = vp
+	}
+	return vp
+}
+
+// fetchFromWarmPage calls VehicleStateTableForBranchEx on the account's
+// long-lived VenusMain page. The page is only reloaded when it has been
+// redirected to the login page or the bridge call fails.
+func (r *Renderer) fetchFromWarmPage(sessionID, branchID, filterID string, forceLogin bool) ([]interface{}, string, error) {
+	vp := r.warmVenusPage(r.account())
+	vp.mu.Lock()
+	defer vp.mu.Unlock()
+
+	if forceLogin || (vp.page != nil && !vp.usable(r)) {
+		vp.close()
+	}
+
+	if vp.page == nil {
+		if err := vp.open(r, sessionID, forceLogin); err != nil {
+			return nil, "", err
+		}
+	}
+
+	rawData, err := r.fetchVehicleTable(vp.page.Timeout(2*time.Minute), branchID, filterID)
+	if err == nil {
+		return rawData, vp.sessionID, nil
+	}
+
+	log.Printf("Bridge call on warm VenusMain page failed, reloading: %v", err)
+	vp.close()
+	if err := vp.open(r, vp.sessionID, false); err != nil {
+		return nil, "", err
+	}
+
+	rawData, err = r.fetchVehicleTable(vp.page.Timeout(2*time.Minute), branchID, filterID)
+	if err != nil {
+		vp.close()
+		return nil, "", err
+	}
+	return rawData, vp.sessionID, nil
+}
+
+// open creates a new tab, logs in if needed and waits for VenusMain.
+func (vp *venusPage) open(r *Renderer, sessionID string, forceLogin bool) error {
+	log.Println("Opening long-lived VenusMain page")
+	page := r.browser.MustPage()
+
+	setupPage := page.Timeout(5 * time.Minute)
+	newSessionID, err := r.openVenusMain(setupPage, sessionID, forceLogin)
+	if err != nil {
+		page.MustClose()
+		return err
+	}
+	if !r.hasBridgeMethod(setupPage, "VehicleStateTableForBranchEx") {
+		page.MustClose()
+		return fmt.Errorf("VenusBridgeService not found on page")
+	}
+	r.waitVenusReady(setupPage)
+
+	vp.page = page
+	vp.sessionID = newSessionID
+	return nil
+}
+
+// usable reports whether the page is still on VenusMain with the bridge loaded.
+func (vp *venusPage) usable(r *Renderer) bool {
+	info, err := vp.page.Timeout(10 * time.Second).Info()
+	if err != nil {
+		log.Printf("Warm VenusMain page is not responding: %v", err)
+		return false
+	}
+	if strings.Contains(info.URL, "Login") || strings.Contains(info.URL, "OES1010") {
+		log.Println("Warm VenusMain page was redirected to login")
+		return false
+	}
+	return r.hasBridgeMethod(vp.page.Timeout(10*time.Second), "VehicleStateTableForBranchEx")
+}
+
+func (vp *venusPage) close() {
+	if vp.page == nil {
+		return
+	}
+	if err := vp.page.Close(); err != nil {
+		log.Printf("Error closing VenusMain page: %v", err)
+	}
+	vp.page = nil
+}
+
+// closeVenusPages closes every kept-alive page.
+func (r *Renderer) closeVenusPages() {
+	r.venusPagesMu.Lock()
+	defer r.venusPagesMu.Unlock()
+
+	for _, vp := range r.venusPages {
+		vp.mu.Lock()
+		vp.close()
+		vp.mu.Unlock()
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -25,12 +26,98 @@ type Config struct {
 	BrowserTimeout  time.Duration
 	BrowserDebug    bool
 
+	// Keep one VenusMain page open per account and refresh it in place
+	VenusKeepAlive bool
+
 	// Database
 	SQLitePath string
 
 	// Session settings
 	SessionTTL time.Duration
 	CookieTTL  time.Duration
+
+	// Branch and filter discovery cache
+	DiscoveryTTL time.Duration
+
+	// Hosts, IPs or CIDRs on loopback, private or link-local addresses that
+	// the render endpoints may open (all others are refused)
+	RenderAllowedHosts []string
+
+	// Hono dtakologs API (empty disables sending)
+	HonoAPIURL string
+
+	// In-page polling of vehicle state (0 disables)
+	PollInterval time.Duration
+
+	// Scrape validation: a full scrape with fewer vehicles than ScrapeMinRatio
+	// times the median of the last ScrapeBaselineSize accepted counts is held
+	// back until confirmed, or until the next scrape is within
+	// ScrapeAgreeTolerance of it (a ratio of 0 disables validation)
+	ScrapeMinRatio       float64
+	ScrapeBaselineSize   int
+	ScrapeAgreeTolerance float64
+
+	// How long telemetry history is kept (0 keeps it forever)
+	TelemetryRetention time.Duration
+
+	// Datum of the portal's GPS coordinates: "tokyo" or "wgs84"
+	GPSDatum string
+
+	// Refrigeration: allowed deviation from the set point in degrees Celsius,
+	// how long it must last to count as an excursion, and the TempState
+	// values that mean the unit is off and not checked
+	TempTolerance   float64
+	TempMinDuration time.Duration
+	TempOffStates   []int
+
+	// Trips: speed in km/h that counts as moving, and how long a vehicle must
+	// stand still for a trip to end
+	TripMovingSpeed  float64
+	TripStopDuration time.Duration
+
+	// Driver report thresholds (0 uses the 2024 improvement standards)
+	DriverMaxBinding           time.Duration
+	DriverMaxContinuousDriving time.Duration
+	DriverMinBreak             time.Duration
+	DriverMinBreakSegment      time.Duration
+	DriverMaxSampleGap         time.Duration
+
+	// Driving behaviour: speed limit in km/h, how long an idling engine must
+	// run at speed 0, the RPM or OperationState values that mean the engine
+	// is running, and the speed change in km/h per second between snapshots
+	// at most HarshMaxInterval apart that counts as harsh (0 disables each)
+	OverspeedLimit      float64
+	IdleMinDuration     time.Duration
+	IdleMinRevo         int
+	IdleOperationStates []int
+	HarshSpeedChange    float64
+	HarshMaxInterval    time.Duration
+
+	// Device health: age of the last contact after which a vehicle is stale
+	// or offline, the statuses that are alerted, and how often vehicles
+	// missing from snapshots are rechecked
+	HealthStaleAfter    time.Duration
+	HealthOfflineAfter  time.Duration
+	HealthAlertStatuses []string
+	HealthCheckInterval time.Duration
+
+	// Default time inside a geofence before a dwell event (0 disables)
+	GeofenceDwell time.Duration
+
+	// Average speed in km/h for the straight-line ETA of nearest vehicles
+	NearestAverageSpeed float64
+
+	// Alert notifications (empty webhook URL logs alerts only)
+	NotifyWebhookURL string
+
+	// Change events: meters a vehicle must move, and the silence after which
+	// new data counts as communication resumed
+	EventMinMove float64
+	EventCommGap time.Duration
+
+	// Report downloads
+	DownloadDir     string
+	DownloadTimeout time.Duration
 }
 
 func Load() *Config {
@@ -39,17 +126,59 @@ func Load() *Config {
 
 	cfg := &Config{
 		// Default values
-		GRPCPort:        getEnv("GRPC_PORT", "50051"),
-		HTTPPort:        getEnv("HTTP_PORT", "8080"),
-		UserName:        getEnv("USER_NAME", ""),
-		CompID:          getEnv("COMP_ID", ""),
-		UserPass:        getEnv("USER_PASS", ""),
-		BrowserHeadless: getEnvBool("BROWSER_HEADLESS", true),
-		BrowserTimeout:  getEnvDuration("BROWSER_TIMEOUT", 60*time.Second),
-		BrowserDebug:    getEnvBool("BROWSER_DEBUG", false),
-		SQLitePath:      getEnv("SQLITE_PATH", "./data/browser_render.db"),
-		SessionTTL:      getEnvDuration("SESSION_TTL", 10*time.Minute),
-		CookieTTL:       getEnvDuration("COOKIE_TTL", 24*time.Hour),
+		GRPCPort:           getEnv("GRPC_PORT", "50051"),
+		HTTPPort:           getEnv("HTTP_PORT", "8080"),
+		UserName:           getEnv("USER_NAME", ""),
+		CompID:             getEnv("COMP_ID", ""),
+		UserPass:           getEnv("USER_PASS", ""),
+		BrowserHeadless:    getEnvBool("BROWSER_HEADLESS", true),
+		BrowserTimeout:     getEnvDuration("BROWSER_TIMEOUT", 60*time.Second),
+		BrowserDebug:       getEnvBool("BROWSER_DEBUG", false),
+		VenusKeepAlive:     getEnvBool("VENUS_KEEP_ALIVE", false),
+		SQLitePath:         getEnv("SQLITE_PATH", "./data/browser_render.db"),
+		SessionTTL:         getEnvDuration("SESSION_TTL", 10*time.Minute),
+		CookieTTL:          getEnvDuration("COOKIE_TTL", 24*time.Hour),
+		DiscoveryTTL:       getEnvDuration("DISCOVERY_TTL", 1*time.Hour),
+		RenderAllowedHosts: getEnvList("RENDER_ALLOWED_HOSTS", nil),
+		HonoAPIURL:         getEnv("HONO_API_URL", "https://hono-api.mtamaramu.com/api/dtakologs"),
+		PollInterval:       getEnvDuration("POLL_INTERVAL", 0),
+		GPSDatum:           getEnv("GPS_DATUM", "tokyo"),
+		TelemetryRetention: getEnvDuration("TELEMETRY_RETENTION", 0),
+		EventMinMove:       getEnvFloat("EVENT_MIN_MOVE", 100),
+		EventCommGap:       getEnvDuration("EVENT_COMM_GAP", 30*time.Minute),
+		TempTolerance:      getEnvFloat("TEMP_TOLERANCE", 3),
+		TempMinDuration:    getEnvDuration("TEMP_MIN_DURATION", 10*time.Minute),
+		TempOffStates:      getEnvInts("TEMP_OFF_STATES", []int{0}),
+		GeofenceDwell:      getEnvDuration("GEOFENCE_DWELL", 15*time.Minute),
+		TripMovingSpeed:    getEnvFloat("TRIP_MOVING_SPEED", 5),
+		TripStopDuration:   getEnvDuration("TRIP_STOP_DURATION", 10*time.Minute),
+		NotifyWebhookURL:   getEnv("NOTIFY_WEBHOOK_URL", ""),
+		DownloadDir:        getEnv("DOWNLOAD_DIR", "./data/downloads"),
+		DownloadTimeout:    getEnvDuration("DOWNLOAD_TIMEOUT", 2*time.Minute),
+
+		DriverMaxBinding:           getEnvDuration("DRIVER_MAX_BINDING", 13*time.Hour),
+		DriverMaxContinuousDriving: getEnvDuration("DRIVER_MAX_CONTINUOUS_DRIVING", 4*time.Hour),
+		DriverMinBreak:             getEnvDuration("DRIVER_MIN_BREAK", 30*time.Minute),
+		DriverMinBreakSegment:      getEnvDuration("DRIVER_MIN_BREAK_SEGMENT", 10*time.Minute),
+		DriverMaxSampleGap:         getEnvDuration("DRIVER_MAX_SAMPLE_GAP", 30*time.Minute),
+
+		OverspeedLimit:      getEnvFloat("OVERSPEED_LIMIT", 80),
+		IdleMinDuration:     getEnvDuration("IDLE_MIN_DURATION", 10*time.Minute),
+		IdleMinRevo:         getEnvInt("IDLE_MIN_REVO", 300),
+		IdleOperationStates: getEnvInts("IDLE_OPERATION_STATES", nil),
+		HarshSpeedChange:    getEnvFloat("HARSH_SPEED_CHANGE", 10),
+		HarshMaxInterval:    getEnvDuration("HARSH_MAX_INTERVAL", 30*time.Second),
+
+		HealthStaleAfter:    getEnvDuration("HEALTH_STALE_AFTER", 30*time.Minute),
+		HealthOfflineAfter:  getEnvDuration("HEALTH_OFFLINE_AFTER", 24*time.Hour),
+		HealthAlertStatuses: getEnvList("HEALTH_ALERT_STATUSES", []string{"offline"}),
+		HealthCheckInterval: getEnvDuration("HEALTH_CHECK_INTERVAL", time.Minute),
+
+		ScrapeMinRatio:       getEnvFloat("SCRAPE_MIN_RATIO", 0.8),
+		ScrapeBaselineSize:   getEnvInt("SCRAPE_BASELINE_SIZE", 5),
+		ScrapeAgreeTolerance: getEnvFloat("SCRAPE_AGREE_TOLERANCE", 0.05),
+
+		NearestAverageSpeed: getEnvFloat("NEAREST_AVERAGE_SPEED", 40),
 	}
 
 	// Validate required fields
@@ -104,6 +233,57 @@ func getEnvBool(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+	}
+	return defaultValue
+}
+
+// getEnvList reads a comma separated list, dropping empty entries.
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var list []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+	return list
+}
+
+// getEnvInts reads a comma separated list of integers. An invalid entry makes
+// the whole value fall back to the default.
+func getEnvInts(key string, defaultValue []int) []int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var list []int
+	for _, part := range strings.Split(value, ",") {
+		i, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return defaultValue
+		}
+		list = append(list, i)
+	}
+	return list
+}
+
 func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if d, err := time.ParseDuration(value); err == nil {
@@ -115,4 +295,4 @@ func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 		}
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
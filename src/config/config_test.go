@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -21,6 +22,8 @@ func TestLoad(t *testing.T) {
 		"SQLITE_PATH":      os.Getenv("SQLITE_PATH"),
 		"SESSION_TTL":      os.Getenv("SESSION_TTL"),
 		"COOKIE_TTL":       os.Getenv("COOKIE_TTL"),
+		"VENUS_KEEP_ALIVE": os.Getenv("VENUS_KEEP_ALIVE"),
+		"POLL_INTERVAL":    os.Getenv("POLL_INTERVAL"),
 	}
 
 	// Restore env vars after test
@@ -67,6 +70,12 @@ func TestLoad(t *testing.T) {
 				if cfg.CookieTTL != 24*time.Hour {
 					t.Errorf("Expected CookieTTL to be 24h, got %v", cfg.CookieTTL)
 				}
+				if cfg.VenusKeepAlive {
+					t.Errorf("Expected VenusKeepAlive to be false")
+				}
+				if cfg.PollInterval != 0 {
+					t.Errorf("Expected PollInterval to be 0, got %v", cfg.PollInterval)
+				}
 			},
 		},
 		{
@@ -83,6 +92,8 @@ func TestLoad(t *testing.T) {
 				"SQLITE_PATH":      "/tmp/test.db",
 				"SESSION_TTL":      "20m",
 				"COOKIE_TTL":       "48h",
+				"VENUS_KEEP_ALIVE": "true",
+				"POLL_INTERVAL":    "15s",
 			},
 			validate: func(t *testing.T, cfg *Config) {
 				if cfg.GRPCPort != "9090" {
@@ -118,6 +129,12 @@ func TestLoad(t *testing.T) {
 				if cfg.CookieTTL != 48*time.Hour {
 					t.Errorf("Expected CookieTTL to be 48h, got %v", cfg.CookieTTL)
 				}
+				if !cfg.VenusKeepAlive {
+					t.Errorf("Expected VenusKeepAlive to be true")
+				}
+				if cfg.PollInterval != 15*time.Second {
+					t.Errorf("Expected PollInterval to be 15s, got %v", cfg.PollInterval)
+				}
 			},
 		},
 		{
@@ -424,4 +441,48 @@ func TestGetEnvDuration(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestGetEnvInts(t *testing.T) {
+	tests := []struct {
+		name         string
+		key          string
+		value        string
+		defaultValue []int
+		expected     []int
+	}{
+		{
+			name:     "Comma separated",
+			key:      "INTS_VALID",
+			value:    "1, 2,3",
+			expected: []int{1, 2, 3},
+		},
+		{
+			name:         "Invalid entry",
+			key:          "INTS_INVALID",
+			value:        "1,x",
+			defaultValue: []int{4},
+			expected:     []int{4},
+		},
+		{
+			name:         "Non-existing var",
+			key:          "INTS_NON_EXISTING",
+			value:        "",
+			defaultValue: nil,
+			expected:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.value != "" {
+				os.Setenv(tt.key, tt.value)
+				defer os.Unsetenv(tt.key)
+			}
+
+			result := getEnvInts(tt.key, tt.defaultValue)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package fleet
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/browser"
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+// Driving behaviour event types.
+const (
+	EventOverspeed         = "overspeed"
+	EventOverspeedEnded    = "overspeed_ended"
+	EventIdling            = "idling"
+	EventIdlingEnded       = "idling_ended"
+	EventHarshAcceleration = "harsh_acceleration"
+	EventHarshBraking      = "harsh_braking"
+)
+
+// DrivingEventTypes lists every driving behaviour event type.
+var DrivingEventTypes = []string{
+	EventOverspeed,
+	EventOverspeedEnded,
+	EventIdling,
+	EventIdlingEnded,
+	EventHarshAcceleration,
+	EventHarshBraking,
+}
+
+// DrivingRules configure the DrivingMonitor. A zero limit disables its check.
+type DrivingRules struct {
+	OverspeedLimit      float64       // km/h
+	IdleMinDuration     time.Duration // engine running at speed 0
+	IdleMinRevo         int           // RPM from which the engine counts as running
+	IdleOperationStates []int         // OperationState values that mean the engine is running
+	HarshSpeedChange    float64       // km/h per second
+	HarshMaxInterval    time.Duration // snapshots further apart are not compared
+}
+
+// engineOn reports whether the record says the engine is running.
+func (r DrivingRules) engineOn(t *vehicle.Telemetry) bool {
+	if r.IdleMinRevo > 0 && t.Revo >= r.IdleMinRevo {
+		return true
+	}
+	for _, state := range r.IdleOperationStates {
+		if t.OperationState == state {
+			return true
+		}
+	}
+	return false
+}
+
+type drivingState struct {
+	at           time.Time
+	speed        float64
+	overSince    time.Time
+	overMax      float64
+	idleSince    time.Time
+	idleReported bool
+}
+
+// DrivingMonitor checks each vehicle's speed, engine revolutions and
+// operation state against DrivingRules and stores overspeed, idling and
+// harsh speed change events. Overspeed and idling are reported when they
+// start and when they end; the first snapshot of a vehicle only sets its
+// baseline, and episodes open at a restart are not closed.
+type DrivingMonitor struct {
+	rules   DrivingRules
+	storage *storage.Storage
+
+	mu     sync.Mutex
+	states map[int64]*drivingState
+}
+
+// NewDrivingMonitor creates a monitor with the given rules.
+func NewDrivingMonitor(rules DrivingRules, store *storage.Storage) *DrivingMonitor {
+	return &DrivingMonitor{
+		rules:   rules,
+		storage: store,
+		states:  make(map[int64]*drivingState),
+	}
+}
+
+func (m *DrivingMonitor) Name() string {
+	return "driving"
+}
+
+func (m *DrivingMonitor) Write(_ context.Context, snapshot *browser.Snapshot) error {
+	events := m.evaluate(snapshot)
+	if len(events) == 0 {
+		return nil
+	}
+	if err := m.storage.SaveEvents(events); err != nil {
+		return fmt.Errorf("failed to save driving events: %w", err)
+	}
+	return nil
+}
+
+func (m *DrivingMonitor) evaluate(snapshot *browser.Snapshot) []storage.Event {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var events []storage.Event
+	for _, vd := range snapshot.Vehicles {
+		t := vd.Telemetry
+		if t == nil || t.VehicleCD == 0 {
+			continue
+		}
+		at := snapshot.FetchedAt
+		if vd.DataTime != nil {
+			at = *vd.DataTime
+		}
+
+		st, known := m.states[t.VehicleCD]
+		if !known {
+			m.states[t.VehicleCD] = &drivingState{at: at, speed: t.Speed}
+			continue
+		}
+		if !at.After(st.at) {
+			continue
+		}
+
+		base := storage.Event{
+			VehicleCD:   t.VehicleCD,
+			VehicleID:   vd.VehicleID,
+			VehicleName: vd.VehicleName,
+			BranchCD:    t.BranchCD,
+			Time:        at,
+		}
+		if p := vd.Position; p != nil && p.Valid {
+			lat, lon := p.Latitude, p.Longitude
+			base.Latitude, base.Longitude = &lat, &lon
+		}
+		add := func(eventType, from, to string, detail map[string]interface{}) {
+			e := base
+			e.Type, e.From, e.To, e.Detail = eventType, from, to, detail
+			events = append(events, e)
+		}
+
+		m.checkHarsh(st, at, t.Speed, add)
+		m.checkOverspeed(st, at, t.Speed, add)
+		m.checkIdling(st, at, t.Speed == 0 && m.rules.engineOn(t), t.Revo, add)
+
+		st.at, st.speed = at, t.Speed
+	}
+	return events
+}
+
+type addEvent func(eventType, from, to string, detail map[string]interface{})
+
+// checkHarsh compares the average speed change since the previous snapshot
+// with HarshSpeedChange. It is only meaningful for samples as close as the
+// poller's (POLL_INTERVAL), so snapshots more than HarshMaxInterval apart are
+// skipped rather than reported with a diluted or misplaced rate.
+func (m *DrivingMonitor) checkHarsh(st *drivingState, at time.Time, speed float64, add addEvent) {
+	dt := at.Sub(st.at)
+	if m.rules.HarshSpeedChange <= 0 || dt > m.rules.HarshMaxInterval {
+		return
+	}
+	rate := (speed - st.speed) / dt.Seconds()
+	if math.Abs(rate) < m.rules.HarshSpeedChange {
+		return
+	}
+	eventType := EventHarshAcceleration
+	if rate < 0 {
+		eventType = EventHarshBraking
+	}
+	add(eventType, formatSpeed(st.speed), formatSpeed(speed), map[string]interface{}{
+		"interval_seconds": dt.Seconds(),
+		"rate":             round(rate, 1), // km/h per second
+	})
+}
+
+func (m *DrivingMonitor) checkOverspeed(st *drivingState, at time.Time, speed float64, add addEvent) {
+	limit := m.rules.OverspeedLimit
+	if limit <= 0 {
+		return
+	}
+	switch {
+	case speed > limit && st.overSince.IsZero():
+		st.overSince, st.overMax = at, speed
+		add(EventOverspeed, "", formatSpeed(speed), map[string]interface{}{
+			"speed": speed,
+			"limit": limit,
+		})
+	case speed > limit:
+		st.overMax = math.Max(st.overMax, speed)
+	case !st.overSince.IsZero():
+		add(EventOverspeedEnded, "", formatSpeed(speed), map[string]interface{}{
+			"started_at":       st.overSince,
+			"duration_seconds": int64(at.Sub(st.overSince).Seconds()),
+			"max_speed":        st.overMax,
+			"limit":            limit,
+		})
+		st.overSince, st.overMax = time.Time{}, 0
+	}
+}
+
+func (m *DrivingMonitor) checkIdling(st *drivingState, at time.Time, idling bool, revo int, add addEvent) {
+	if m.rules.IdleMinDuration <= 0 {
+		return
+	}
+	switch {
+	case idling && st.idleSince.IsZero():
+		st.idleSince, st.idleReported = at, false
+		fallthrough
+	case idling:
+		if !st.idleReported && at.Sub(st.idleSince) >= m.rules.IdleMinDuration {
+			st.idleReported = true
+			add(EventIdling, "", "", map[string]interface{}{
+				"started_at":       st.idleSince,
+				"duration_seconds": int64(at.Sub(st.idleSince).Seconds()),
+				"revo":             revo,
+			})
+		}
+	case !st.idleSince.IsZero():
+		if st.idleReported {
+			add(EventIdlingEnded, "", "", map[string]interface{}{
+				"started_at":       st.idleSince,
+				"duration_seconds": int64(at.Sub(st.idleSince).Seconds()),
+			})
+		}
+		st.idleSince, st.idleReported = time.Time{}, false
+	}
+}
+
+func formatSpeed(speed float64) string {
+	return strconv.FormatFloat(speed, 'f', -1, 64)
+}
+
+// DrivingSummary totals one vehicle's driving behaviour events for one day.
+type DrivingSummary struct {
+	Date               string  `json:"date"` // YYYY-MM-DD in Asia/Tokyo
+	VehicleCD          int64   `json:"vehicle_cd"`
+	VehicleName        string  `json:"vehicle_name"`
+	Overspeeds         int     `json:"overspeeds"`
+	OverspeedSeconds   int64   `json:"overspeed_seconds"`
+	MaxSpeed           float64 `json:"max_speed"`
+	Idlings            int     `json:"idlings"`
+	IdlingSeconds      int64   `json:"idling_seconds"`
+	HarshAccelerations int     `json:"harsh_accelerations"`
+	HarshBrakings      int     `json:"harsh_brakings"`
+}
+
+// SummarizeDriving totals driving behaviour events per vehicle and day in
+// Asia/Tokyo. Durations count towards the day the episode ended on, or, for
+// idling still going on, the duration reported when it was detected. Other
+// event types are ignored. The result is ordered by date and vehicle.
+func SummarizeDriving(events []storage.Event) []DrivingSummary {
+	type key struct {
+		date      string
+		vehicleCD int64
+	}
+	index := make(map[key]int)
+	var summaries []DrivingSummary
+
+	// Idling episodes that have ended replace the duration reported at detection
+	ended := make(map[string]bool)
+	for _, e := range events {
+		if e.Type == EventIdlingEnded {
+			ended[idlingKey(e)] = true
+		}
+	}
+
+	for _, e := range events {
+		k := key{e.Time.In(vehicle.Tokyo).Format("2006-01-02"), e.VehicleCD}
+		n, ok := index[k]
+		if !ok {
+			n = len(summaries)
+			index[k] = n
+			summaries = append(summaries, DrivingSummary{Date: k.date, VehicleCD: e.VehicleCD})
+		}
+		sum := &summaries[n]
+		if e.VehicleName != "" {
+			sum.VehicleName = e.VehicleName
+		}
+
+		switch e.Type {
+		case EventOverspeed:
+			sum.Overspeeds++
+			sum.MaxSpeed = math.Max(sum.MaxSpeed, detailFloat(e.Detail, "speed"))
+		case EventOverspeedEnded:
+			sum.OverspeedSeconds += int64(detailFloat(e.Detail, "duration_seconds"))
+			sum.MaxSpeed = math.Max(sum.MaxSpeed, detailFloat(e.Detail, "max_speed"))
+		case EventIdling:
+			sum.Idlings++
+			if !ended[idlingKey(e)] {
+				sum.IdlingSeconds += int64(detailFloat(e.Detail, "duration_seconds"))
+			}
+		case EventIdlingEnded:
+			sum.IdlingSeconds += int64(detailFloat(e.Detail, "duration_seconds"))
+		case EventHarshAcceleration:
+			sum.HarshAccelerations++
+		case EventHarshBraking:
+			sum.HarshBrakings++
+		}
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Date != summaries[j].Date {
+			return summaries[i].Date < summaries[j].Date
+		}
+		return summaries[i].VehicleCD < summaries[j].VehicleCD
+	})
+	return summaries
+}
+
+// idlingKey identifies an idling episode by vehicle and start time.
+func idlingKey(e storage.Event) string {
+	started := e.Detail["started_at"]
+	if t, ok := started.(time.Time); ok {
+		started = t.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprintf("%d/%v", e.VehicleCD, started)
+}
+
+// detailFloat reads a number from an event's detail, which holds float64
+// values after a round trip through storage and native types before.
+func detailFloat(detail map[string]interface{}, key string) float64 {
+	switch v := detail[key].(type) {
+	case float64:
+		return v
+	case int64:
+		return float64(v)
+	case int:
+		return float64(v)
+	}
+	return 0
+}
@@ -0,0 +1,138 @@
+package fleet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/browser"
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+func TestDrivingMonitor(t *testing.T) {
+	store := setupTestDB(t)
+	m := NewDrivingMonitor(DrivingRules{
+		OverspeedLimit:   80,
+		IdleMinDuration:  10 * time.Minute,
+		IdleMinRevo:      300,
+		HarshSpeedChange: 10,
+		HarshMaxInterval: time.Minute,
+	}, store)
+	base := time.Date(2025, 1, 2, 9, 0, 0, 0, vehicle.Tokyo)
+
+	readings := []struct {
+		offset time.Duration
+		speed  float64
+		revo   int
+	}{
+		{0, 60, 1500},                            // baseline
+		{30 * time.Second, 85, 1800},             // overspeed starts
+		{60 * time.Second, 95, 2000},             // still over
+		{90 * time.Second, 70, 1500},             // overspeed ends
+		{95 * time.Second, 0, 600},               // 14 km/h per second: harsh braking
+		{10 * time.Minute, 0, 600},               // idling, not yet long enough
+		{15 * time.Minute, 0, 600},               // idling for 13m25s
+		{20 * time.Minute, 0, 0},                 // engine off: idling ends
+		{20*time.Minute + 5*time.Second, 0, 600}, // engine on, no event
+	}
+	for _, r := range readings {
+		at := base.Add(r.offset)
+		snapshot := &browser.Snapshot{
+			FetchedAt: at,
+			Vehicles: []browser.VehicleData{{
+				VehicleName: "Truck",
+				DataTime:    &at,
+				Telemetry:   &vehicle.Telemetry{VehicleCD: 7, Speed: r.speed, Revo: r.revo},
+			}},
+		}
+		if err := m.Write(context.Background(), snapshot); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	events, err := store.ListEvents(storage.EventFilter{})
+	if err != nil {
+		t.Fatalf("Failed to list events: %v", err)
+	}
+	want := []string{EventOverspeed, EventOverspeedEnded, EventHarshBraking, EventIdling, EventIdlingEnded}
+	if len(events) != len(want) {
+		t.Fatalf("Expected events %v, got %d: %+v", want, len(events), events)
+	}
+	for i, e := range events {
+		if e.Type != want[i] {
+			t.Errorf("Event %d: expected %s, got %s", i, want[i], e.Type)
+		}
+	}
+	if d := events[1].Detail; d["max_speed"] != float64(95) || d["duration_seconds"] != float64(60) {
+		t.Errorf("Unexpected overspeed detail: %v", d)
+	}
+	if d := events[4].Detail; d["duration_seconds"] != float64(1105) {
+		t.Errorf("Unexpected idling detail: %v", d)
+	}
+
+	summaries := SummarizeDriving(events)
+	if len(summaries) != 1 {
+		t.Fatalf("Expected 1 summary, got %d", len(summaries))
+	}
+	got := summaries[0]
+	if got.Overspeeds != 1 || got.OverspeedSeconds != 60 || got.MaxSpeed != 95 ||
+		got.Idlings != 1 || got.IdlingSeconds != 1105 || got.HarshBrakings != 1 || got.HarshAccelerations != 0 {
+		t.Errorf("Unexpected summary: %+v", got)
+	}
+}
+
+func TestDrivingMonitor_HarshSkipsWideGaps(t *testing.T) {
+	store := setupTestDB(t)
+	m := NewDrivingMonitor(DrivingRules{HarshSpeedChange: 1, HarshMaxInterval: 30 * time.Second}, store)
+	base := time.Date(2025, 1, 2, 9, 0, 0, 0, vehicle.Tokyo)
+
+	readings := []struct {
+		offset time.Duration
+		speed  float64
+	}{
+		{0, 60},                 // baseline
+		{45 * time.Second, 0},   // 1.3 km/h per second, but 45s apart: skipped
+		{60 * time.Second, 30},  // 2 km/h per second within 15s: harsh acceleration
+		{120 * time.Second, 90}, // 1 km/h per second, but 60s apart: skipped
+	}
+	for _, r := range readings {
+		at := base.Add(r.offset)
+		snapshot := &browser.Snapshot{
+			FetchedAt: at,
+			Vehicles: []browser.VehicleData{{
+				DataTime:  &at,
+				Telemetry: &vehicle.Telemetry{VehicleCD: 7, Speed: r.speed},
+			}},
+		}
+		if err := m.Write(context.Background(), snapshot); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	events, err := store.ListEvents(storage.EventFilter{})
+	if err != nil {
+		t.Fatalf("Failed to list events: %v", err)
+	}
+	if len(events) != 1 || events[0].Type != EventHarshAcceleration || events[0].Detail["interval_seconds"] != float64(15) {
+		t.Errorf("Expected one harsh acceleration over 15s, got %+v", events)
+	}
+}
+
+func TestDrivingRules_EngineOn(t *testing.T) {
+	rules := DrivingRules{IdleMinRevo: 300, IdleOperationStates: []int{2}}
+	tests := []struct {
+		revo, state int
+		want        bool
+	}{
+		{600, 0, true},
+		{100, 0, false},
+		{0, 2, true},
+		{0, 1, false},
+	}
+	for _, tt := range tests {
+		if got := rules.engineOn(&vehicle.Telemetry{Revo: tt.revo, OperationState: tt.state}); got != tt.want {
+			t.Errorf("engineOn(revo %d, state %d) = %v, want %v", tt.revo, tt.state, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package fleet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/browser"
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+// Event types emitted by the Detector.
+const (
+	EventMoved                 = "moved"
+	EventStateChanged          = "state_changed"
+	EventOperationStateChanged = "operation_state_changed"
+	EventDriverChanged         = "driver_changed"
+	EventWorkStarted           = "work_started"
+	EventWorkEnded             = "work_ended"
+	EventCommunicationResumed  = "communication_resumed"
+)
+
+// EventTypes lists every change event type in a stable order.
+var EventTypes = []string{
+	EventMoved,
+	EventStateChanged,
+	EventOperationStateChanged,
+	EventDriverChanged,
+	EventWorkStarted,
+	EventWorkEnded,
+	EventCommunicationResumed,
+}
+
+// vehicleState is the part of a vehicle record the Detector compares.
+type vehicleState struct {
+	dataTime       time.Time
+	comuTime       time.Time
+	hasPosition    bool
+	latitude       float64
+	longitude      float64
+	state          string
+	operationState int
+	driverCD       int
+	driverName     string
+	startWork      string
+}
+
+// Detector compares each vehicle's new state with the previous one and
+// returns the changes as events. It is safe for concurrent use.
+type Detector struct {
+	minMove float64       // meters a vehicle must move for EventMoved
+	commGap time.Duration // silence after which new data is EventCommunicationResumed
+
+	mu     sync.Mutex
+	states map[int64]vehicleState
+}
+
+// NewDetector creates a detector. minMove is the distance in meters that
+// counts as a move; commGap is how long a vehicle must have been silent for
+// its next communication to count as resumed.
+func NewDetector(minMove float64, commGap time.Duration) *Detector {
+	return &Detector{
+		minMove: minMove,
+		commGap: commGap,
+		states:  make(map[int64]vehicleState),
+	}
+}
+
+// Seed sets the previous state from stored telemetry, so a restart does not
+// lose the baseline. Vehicles the detector already knows are left alone.
+func (d *Detector) Seed(records []storage.TelemetryRecord) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, r := range records {
+		if _, ok := d.states[r.VehicleCD]; ok {
+			continue
+		}
+		var raw map[string]interface{}
+		if err := json.Unmarshal([]byte(r.Record), &raw); err != nil {
+			continue
+		}
+		t, _ := vehicle.Decode(raw)
+		st := stateOf(t)
+		st.dataTime = r.DataTime
+		if r.Latitude != nil && r.Longitude != nil && r.GPSValid {
+			st.hasPosition, st.latitude, st.longitude = true, *r.Latitude, *r.Longitude
+		}
+		d.states[r.VehicleCD] = st
+	}
+}
+
+// Detect updates the detector with the vehicles and returns the changes
+// since each vehicle was last seen. The first sighting of a vehicle only
+// sets its baseline. Records older than the known state are ignored.
+func (d *Detector) Detect(vehicles []browser.VehicleData, now time.Time) []storage.Event {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var events []storage.Event
+	for _, vd := range vehicles {
+		if vd.Telemetry == nil || vd.Telemetry.VehicleCD == 0 {
+			continue
+		}
+
+		st := stateOf(vd.Telemetry)
+		st.dataTime = now
+		if vd.DataTime != nil {
+			st.dataTime = *vd.DataTime
+		}
+		if p := vd.Position; p != nil && p.Valid {
+			st.hasPosition, st.latitude, st.longitude = true, p.Latitude, p.Longitude
+		}
+
+		cd := vd.Telemetry.VehicleCD
+		prev, known := d.states[cd]
+		if known && st.dataTime.Before(prev.dataTime) {
+			continue
+		}
+		d.states[cd] = st
+		if !known {
+			continue
+		}
+
+		base := storage.Event{
+			VehicleCD:   cd,
+			VehicleID:   vd.VehicleID,
+			VehicleName: vd.VehicleName,
+			BranchCD:    vd.Telemetry.BranchCD,
+			Time:        st.dataTime,
+		}
+		if st.hasPosition {
+			lat, lon := st.latitude, st.longitude
+			base.Latitude, base.Longitude = &lat, &lon
+		}
+		events = append(events, d.compare(base, prev, st)...)
+	}
+	return events
+}
+
+func (d *Detector) compare(base storage.Event, prev, cur vehicleState) []storage.Event {
+	var events []storage.Event
+	add := func(eventType, from, to string, detail map[string]interface{}) {
+		e := base
+		e.Type, e.From, e.To, e.Detail = eventType, from, to, detail
+		events = append(events, e)
+	}
+
+	if prev.hasPosition && cur.hasPosition {
+		if dist := vehicle.Distance(prev.latitude, prev.longitude, cur.latitude, cur.longitude); dist >= d.minMove {
+			add(EventMoved, formatLatLong(prev), formatLatLong(cur), map[string]interface{}{
+				"distance_m": math.Round(dist),
+			})
+		}
+	}
+
+	if prev.state != cur.state {
+		add(EventStateChanged, prev.state, cur.state, nil)
+	}
+	if prev.operationState != cur.operationState {
+		add(EventOperationStateChanged, strconv.Itoa(prev.operationState), strconv.Itoa(cur.operationState), nil)
+	}
+	if prev.driverCD != cur.driverCD {
+		add(EventDriverChanged, strconv.Itoa(prev.driverCD), strconv.Itoa(cur.driverCD), map[string]interface{}{
+			"from_name": prev.driverName,
+			"to_name":   cur.driverName,
+		})
+	}
+
+	switch {
+	case cur.startWork != "" && cur.startWork != prev.startWork:
+		add(EventWorkStarted, prev.startWork, cur.startWork, nil)
+	case cur.startWork == "" && prev.startWork != "":
+		add(EventWorkEnded, prev.startWork, "", nil)
+	}
+
+	if !prev.comuTime.IsZero() && !cur.comuTime.IsZero() && d.commGap > 0 {
+		if gap := cur.comuTime.Sub(prev.comuTime); gap >= d.commGap {
+			add(EventCommunicationResumed, prev.comuTime.Format(time.RFC3339), cur.comuTime.Format(time.RFC3339), map[string]interface{}{
+				"gap_seconds": int64(gap.Seconds()),
+			})
+		}
+	}
+
+	return events
+}
+
+func stateOf(t *vehicle.Telemetry) vehicleState {
+	st := vehicleState{
+		state:          t.State,
+		operationState: t.OperationState,
+		driverCD:       t.DriverCD,
+		driverName:     t.DriverName,
+		startWork:      t.StartWorkDateTime,
+	}
+	if comu, err := t.ComuTime(); err == nil {
+		st.comuTime = comu
+	}
+	return st
+}
+
+func formatLatLong(st vehicleState) string {
+	return fmt.Sprintf("%.6f,%.6f", st.latitude, st.longitude)
+}
+
+// EventSink detects changes in every snapshot and stores them.
+type EventSink struct {
+	detector *Detector
+	storage  *storage.Storage
+}
+
+// NewEventSink creates a sink that stores the detector's events.
+func NewEventSink(detector *Detector, store *storage.Storage) *EventSink {
+	return &EventSink{detector: detector, storage: store}
+}
+
+func (s *EventSink) Name() string {
+	return "events"
+}
+
+func (s *EventSink) Write(_ context.Context, snapshot *browser.Snapshot) error {
+	events := s.detector.Detect(snapshot.Vehicles, snapshot.FetchedAt)
+	if len(events) == 0 {
+		return nil
+	}
+	if err := s.storage.SaveEvents(events); err != nil {
+		return fmt.Errorf("failed to save events: %w", err)
+	}
+	log.Printf("Detected %d vehicle events", len(events))
+	return nil
+}
@@ -0,0 +1,101 @@
+package fleet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/browser"
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+func testVehicle(dataTime time.Time, lat, lon float64, mutate func(*vehicle.Telemetry)) browser.VehicleData {
+	t := &vehicle.Telemetry{
+		VehicleCD:         1,
+		VehicleName:       "Truck",
+		State:             "走行",
+		OperationState:    1,
+		DriverCD:          10,
+		StartWorkDateTime: "2025/01/02 08:00",
+		ComuDateTime:      dataTime.In(vehicle.Tokyo).Format("2006/01/02 15:04:05"),
+	}
+	if mutate != nil {
+		mutate(t)
+	}
+	return browser.VehicleData{
+		VehicleName: t.VehicleName,
+		Telemetry:   t,
+		DataTime:    &dataTime,
+		Position:    &vehicle.Position{Latitude: lat, Longitude: lon, Valid: true},
+	}
+}
+
+func eventTypes(events []storage.Event) []string {
+	types := make([]string, len(events))
+	for i, e := range events {
+		types[i] = e.Type
+	}
+	return types
+}
+
+func TestDetector_Detect(t *testing.T) {
+	base := time.Date(2025, 1, 2, 9, 0, 0, 0, vehicle.Tokyo)
+
+	tests := []struct {
+		name   string
+		next   browser.VehicleData
+		events []string
+	}{
+		{"no change", testVehicle(base.Add(time.Minute), 35.0, 139.0, nil), nil},
+		{"small move", testVehicle(base.Add(time.Minute), 35.0001, 139.0, nil), nil},
+		{"moved", testVehicle(base.Add(time.Minute), 35.01, 139.0, nil), []string{EventMoved}},
+		{"state changed", testVehicle(base.Add(time.Minute), 35.0, 139.0, func(t *vehicle.Telemetry) {
+			t.State = "休憩"
+			t.OperationState = 2
+		}), []string{EventStateChanged, EventOperationStateChanged}},
+		{"driver changed", testVehicle(base.Add(time.Minute), 35.0, 139.0, func(t *vehicle.Telemetry) { t.DriverCD = 11 }), []string{EventDriverChanged}},
+		{"work started", testVehicle(base.Add(time.Minute), 35.0, 139.0, func(t *vehicle.Telemetry) { t.StartWorkDateTime = "2025/01/02 09:01" }), []string{EventWorkStarted}},
+		{"work ended", testVehicle(base.Add(time.Minute), 35.0, 139.0, func(t *vehicle.Telemetry) { t.StartWorkDateTime = "" }), []string{EventWorkEnded}},
+		{"communication resumed", testVehicle(base.Add(time.Hour), 35.0, 139.0, nil), []string{EventCommunicationResumed}},
+		{"older record", testVehicle(base.Add(-time.Hour), 36.0, 139.0, nil), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDetector(100, 30*time.Minute)
+			if events := d.Detect([]browser.VehicleData{testVehicle(base, 35.0, 139.0, nil)}, base); len(events) != 0 {
+				t.Fatalf("Expected no events on first sighting, got %v", eventTypes(events))
+			}
+
+			events := d.Detect([]browser.VehicleData{tt.next}, base)
+			got := eventTypes(events)
+			if len(got) != len(tt.events) {
+				t.Fatalf("Expected events %v, got %v", tt.events, got)
+			}
+			for i := range got {
+				if got[i] != tt.events[i] {
+					t.Errorf("Expected events %v, got %v", tt.events, got)
+				}
+			}
+		})
+	}
+}
+
+func TestDetector_Seed(t *testing.T) {
+	base := time.Date(2025, 1, 2, 9, 0, 0, 0, vehicle.Tokyo)
+	lat, lon := 35.0, 139.0
+
+	d := NewDetector(100, 0)
+	d.Seed([]storage.TelemetryRecord{{
+		VehicleCD: 1, DataTime: base, Latitude: &lat, Longitude: &lon, GPSValid: true,
+		Record: `{"VehicleCD":1,"State":"走行","OperationState":1,"DriverCD":10,"StartWorkDateTime":"2025/01/02 08:00"}`,
+	}})
+
+	events := d.Detect([]browser.VehicleData{testVehicle(base.Add(time.Minute), 35.0, 139.0, func(t *vehicle.Telemetry) { t.DriverCD = 12 })}, base)
+	if got := eventTypes(events); len(got) != 1 || got[0] != EventDriverChanged {
+		t.Fatalf("Expected a driver change against the seeded state, got %v", got)
+	}
+	if e := events[0]; e.From != "10" || e.To != "12" || e.Latitude == nil {
+		t.Errorf("Unexpected event: %+v", e)
+	}
+}
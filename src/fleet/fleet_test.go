@@ -0,0 +1,46 @@
+package fleet
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/notify"
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+)
+
+func setupTestDB(t *testing.T) *storage.Storage {
+	t.Helper()
+	store, err := storage.NewStorage(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// recordingNotifier keeps every alert it receives.
+type recordingNotifier struct {
+	mu     sync.Mutex
+	alerts []notify.Alert
+}
+
+func (r *recordingNotifier) Name() string { return "recording" }
+
+func (r *recordingNotifier) Notify(_ context.Context, alert notify.Alert) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.alerts = append(r.alerts, alert)
+	return nil
+}
+
+func (r *recordingNotifier) kinds() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kinds := make([]string, len(r.alerts))
+	for i, a := range r.alerts {
+		kinds[i] = a.Kind
+	}
+	return kinds
+}
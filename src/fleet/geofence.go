@@ -0,0 +1,284 @@
+package fleet
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/browser"
+	"github.com/yhonda-ohishi/browser_render_go/src/notify"
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+// Geofence event types. The event's To holds the geofence ID.
+const (
+	EventGeofenceEnter = "geofence_enter"
+	EventGeofenceExit  = "geofence_exit"
+	EventGeofenceDwell = "geofence_dwell"
+)
+
+// CategoryRestricted marks geofences whose entry is alerted.
+const CategoryRestricted = "restricted"
+
+// AlertRestrictedArea is sent when a vehicle enters a restricted geofence.
+const AlertRestrictedArea = "restricted_area"
+
+// ValidateGeofence checks that a geofence has a name and a usable shape.
+func ValidateGeofence(g *storage.Geofence) error {
+	if strings.TrimSpace(g.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if g.DwellSeconds < 0 {
+		return fmt.Errorf("dwell_seconds must not be negative")
+	}
+
+	switch g.Shape {
+	case storage.ShapeCircle:
+		if !validLatLon(g.Latitude, g.Longitude) {
+			return fmt.Errorf("circle needs a valid latitude and longitude")
+		}
+		if g.Radius <= 0 {
+			return fmt.Errorf("circle needs a positive radius")
+		}
+	case storage.ShapePolygon:
+		if len(g.Polygon) < 3 {
+			return fmt.Errorf("polygon needs at least 3 vertices")
+		}
+		for _, p := range g.Polygon {
+			if !validLatLon(p[0], p[1]) {
+				return fmt.Errorf("invalid polygon vertex %v", p)
+			}
+		}
+	default:
+		return fmt.Errorf("shape must be %q or %q", storage.ShapeCircle, storage.ShapePolygon)
+	}
+	return nil
+}
+
+func validLatLon(lat, lon float64) bool {
+	return lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180 && !(lat == 0 && lon == 0)
+}
+
+// Contains reports whether the WGS84 point lies inside the geofence.
+func Contains(g *storage.Geofence, lat, lon float64) bool {
+	switch g.Shape {
+	case storage.ShapeCircle:
+		return vehicle.Distance(g.Latitude, g.Longitude, lat, lon) <= g.Radius
+	case storage.ShapePolygon:
+		return inPolygon(g.Polygon, lat, lon)
+	}
+	return false
+}
+
+// inPolygon is the even-odd ray casting test, treating latitude and
+// longitude as planar coordinates, which is accurate enough for sites a few
+// kilometers across.
+func inPolygon(polygon [][2]float64, lat, lon float64) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		yi, xi := polygon[i][0], polygon[i][1]
+		yj, xj := polygon[j][0], polygon[j][1]
+		if (yi > lat) != (yj > lat) && lon < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+type fenceKey struct {
+	vehicleCD int64
+	fenceID   int64
+}
+
+type fenceState struct {
+	inside        bool
+	enteredAt     time.Time
+	last          time.Time
+	dwellReported bool
+}
+
+// GeofenceMonitor evaluates every new vehicle position against the stored
+// geofences and records enter, exit and dwell events. The first position
+// seen for a vehicle and geofence only sets the baseline, so a restart does
+// not report every parked vehicle as entering or dwelling in its depot. A
+// geofence whose shape or dwell time changes starts from a new baseline.
+type GeofenceMonitor struct {
+	dwell    time.Duration // default dwell time for geofences without their own
+	storage  *storage.Storage
+	notifier notify.Notifier
+
+	mu     sync.Mutex
+	states map[fenceKey]*fenceState
+	shapes map[int64]string // geofence ID -> shapeKey the states were built with
+}
+
+// NewGeofenceMonitor creates a monitor. dwell is how long a vehicle must stay
+// inside a geofence for a dwell event when the geofence does not set its own;
+// 0 disables dwell events for such geofences.
+func NewGeofenceMonitor(dwell time.Duration, store *storage.Storage, notifier notify.Notifier) *GeofenceMonitor {
+	return &GeofenceMonitor{
+		dwell:    dwell,
+		storage:  store,
+		notifier: notifier,
+		states:   make(map[fenceKey]*fenceState),
+		shapes:   make(map[int64]string),
+	}
+}
+
+func (m *GeofenceMonitor) Name() string {
+	return "geofence"
+}
+
+func (m *GeofenceMonitor) Write(ctx context.Context, snapshot *browser.Snapshot) error {
+	fences, err := m.storage.ListGeofences()
+	if err != nil {
+		return fmt.Errorf("failed to load geofences: %w", err)
+	}
+	if len(fences) == 0 {
+		return nil
+	}
+
+	events := m.evaluate(fences, snapshot)
+	if len(events) == 0 {
+		return nil
+	}
+	if err := m.storage.SaveEvents(events); err != nil {
+		return fmt.Errorf("failed to save geofence events: %w", err)
+	}
+
+	for _, e := range events {
+		if e.Type != EventGeofenceEnter || e.Detail["category"] != CategoryRestricted {
+			continue
+		}
+		alert := notify.Alert{
+			Kind:        AlertRestrictedArea,
+			Severity:    notify.SeverityWarning,
+			Title:       fmt.Sprintf("%s entered %s", e.VehicleName, e.Detail["geofence_name"]),
+			Message:     fmt.Sprintf("Entered restricted area at %s", e.Time.In(vehicle.Tokyo).Format("2006/01/02 15:04")),
+			VehicleCD:   e.VehicleCD,
+			VehicleName: e.VehicleName,
+			Time:        e.Time,
+			Detail:      e.Detail,
+		}
+		if err := m.notifier.Notify(ctx, alert); err != nil {
+			log.Printf("Warning: failed to send geofence alert: %v", err)
+		}
+	}
+	return nil
+}
+
+func (m *GeofenceMonitor) evaluate(fences []storage.Geofence, snapshot *browser.Snapshot) []storage.Event {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Forget geofences that have been deleted or changed
+	shapes := make(map[int64]string, len(fences))
+	for i := range fences {
+		shapes[fences[i].ID] = shapeKey(&fences[i])
+	}
+	for key := range m.states {
+		if shape, ok := shapes[key.fenceID]; !ok || shape != m.shapes[key.fenceID] {
+			delete(m.states, key)
+		}
+	}
+	m.shapes = shapes
+
+	var events []storage.Event
+	for _, vd := range snapshot.Vehicles {
+		p := vd.Position
+		if vd.Telemetry == nil || vd.Telemetry.VehicleCD == 0 || p == nil || !p.Valid {
+			continue
+		}
+		at := snapshot.FetchedAt
+		if vd.DataTime != nil {
+			at = *vd.DataTime
+		}
+
+		lat, lon := p.Latitude, p.Longitude
+		base := storage.Event{
+			VehicleCD:   vd.Telemetry.VehicleCD,
+			VehicleID:   vd.VehicleID,
+			VehicleName: vd.VehicleName,
+			BranchCD:    vd.Telemetry.BranchCD,
+			Time:        at,
+			Latitude:    &lat,
+			Longitude:   &lon,
+		}
+
+		for i := range fences {
+			g := &fences[i]
+			key := fenceKey{base.VehicleCD, g.ID}
+			inside := Contains(g, lat, lon)
+
+			st, known := m.states[key]
+			if !known {
+				// When the vehicle entered is unknown, so no dwell is reported
+				// until it enters again
+				m.states[key] = &fenceState{inside: inside, last: at, dwellReported: true}
+				continue
+			}
+			if at.Before(st.last) {
+				continue
+			}
+			st.last = at
+
+			event := func(eventType string, detail map[string]interface{}) storage.Event {
+				e := base
+				e.Type = eventType
+				e.To = strconv.FormatInt(g.ID, 10)
+				e.Detail = map[string]interface{}{
+					"geofence_id":   g.ID,
+					"geofence_name": g.Name,
+					"category":      g.Category,
+				}
+				for k, v := range detail {
+					e.Detail[k] = v
+				}
+				return e
+			}
+
+			switch {
+			case inside && !st.inside:
+				st.inside, st.enteredAt, st.dwellReported = true, at, false
+				events = append(events, event(EventGeofenceEnter, nil))
+
+			case !inside && st.inside:
+				st.inside = false
+				var detail map[string]interface{}
+				if !st.enteredAt.IsZero() {
+					detail = map[string]interface{}{
+						"entered_at":       st.enteredAt,
+						"duration_seconds": int64(at.Sub(st.enteredAt).Seconds()),
+					}
+				}
+				events = append(events, event(EventGeofenceExit, detail))
+
+			case inside && !st.dwellReported && m.dwellFor(g) > 0 && at.Sub(st.enteredAt) >= m.dwellFor(g):
+				st.dwellReported = true
+				events = append(events, event(EventGeofenceDwell, map[string]interface{}{
+					"entered_at":       st.enteredAt,
+					"duration_seconds": int64(at.Sub(st.enteredAt).Seconds()),
+				}))
+			}
+		}
+	}
+	return events
+}
+
+// shapeKey identifies what a geofence's states depend on; a PUT that changes
+// any of it invalidates them.
+func shapeKey(g *storage.Geofence) string {
+	return fmt.Sprintf("%s|%v|%v|%v|%v|%d", g.Shape, g.Latitude, g.Longitude, g.Radius, g.Polygon, g.DwellSeconds)
+}
+
+func (m *GeofenceMonitor) dwellFor(g *storage.Geofence) time.Duration {
+	if g.DwellSeconds > 0 {
+		return time.Duration(g.DwellSeconds) * time.Second
+	}
+	return m.dwell
+}
@@ -0,0 +1,165 @@
+package fleet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/browser"
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+func TestContains(t *testing.T) {
+	circle := &storage.Geofence{Shape: storage.ShapeCircle, Latitude: 35.681236, Longitude: 139.767125, Radius: 500}
+	square := &storage.Geofence{Shape: storage.ShapePolygon, Polygon: [][2]float64{{35.0, 139.0}, {35.0, 139.1}, {35.1, 139.1}, {35.1, 139.0}}}
+
+	tests := []struct {
+		name     string
+		fence    *storage.Geofence
+		lat, lon float64
+		want     bool
+	}{
+		{"circle center", circle, 35.681236, 139.767125, true},
+		{"circle edge", circle, 35.684, 139.767125, true}, // about 300 m north
+		{"circle outside", circle, 35.69, 139.767125, false},
+		{"polygon inside", square, 35.05, 139.05, true},
+		{"polygon outside", square, 35.15, 139.05, false},
+	}
+
+	for _, tt := range tests {
+		if got := Contains(tt.fence, tt.lat, tt.lon); got != tt.want {
+			t.Errorf("%s: Contains = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateGeofence(t *testing.T) {
+	tests := []struct {
+		name  string
+		fence storage.Geofence
+		ok    bool
+	}{
+		{"circle", storage.Geofence{Name: "Depot", Shape: storage.ShapeCircle, Latitude: 35, Longitude: 139, Radius: 100}, true},
+		{"polygon", storage.Geofence{Name: "Site", Shape: storage.ShapePolygon, Polygon: [][2]float64{{35, 139}, {35, 139.1}, {35.1, 139}}}, true},
+		{"no name", storage.Geofence{Shape: storage.ShapeCircle, Latitude: 35, Longitude: 139, Radius: 100}, false},
+		{"no radius", storage.Geofence{Name: "Depot", Shape: storage.ShapeCircle, Latitude: 35, Longitude: 139}, false},
+		{"too few vertices", storage.Geofence{Name: "Site", Shape: storage.ShapePolygon, Polygon: [][2]float64{{35, 139}, {35, 139.1}}}, false},
+		{"bad vertex", storage.Geofence{Name: "Site", Shape: storage.ShapePolygon, Polygon: [][2]float64{{95, 139}, {35, 139.1}, {35.1, 139}}}, false},
+		{"unknown shape", storage.Geofence{Name: "Site", Shape: "square"}, false},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateGeofence(&tt.fence); (err == nil) != tt.ok {
+			t.Errorf("%s: ValidateGeofence error = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func positionSnapshot(at time.Time, lat, lon float64) *browser.Snapshot {
+	return &browser.Snapshot{
+		FetchedAt: at,
+		Vehicles: []browser.VehicleData{{
+			VehicleName: "Truck",
+			DataTime:    &at,
+			Telemetry:   &vehicle.Telemetry{VehicleCD: 3},
+			Position:    &vehicle.Position{Latitude: lat, Longitude: lon, Valid: true},
+		}},
+	}
+}
+
+func TestGeofenceMonitor(t *testing.T) {
+	store := setupTestDB(t)
+	notifier := &recordingNotifier{}
+	depot := &storage.Geofence{Name: "Depot", Category: "depot", Shape: storage.ShapeCircle, Latitude: 35.0, Longitude: 139.0, Radius: 200}
+	restricted := &storage.Geofence{Name: "Plant", Category: CategoryRestricted, Shape: storage.ShapeCircle, Latitude: 35.1, Longitude: 139.0, Radius: 200, DwellSeconds: 60}
+	for _, g := range []*storage.Geofence{depot, restricted} {
+		if err := store.CreateGeofence(g); err != nil {
+			t.Fatalf("Failed to create geofence: %v", err)
+		}
+	}
+
+	m := NewGeofenceMonitor(15*time.Minute, store, notifier)
+	ctx := context.Background()
+	base := time.Date(2025, 1, 2, 9, 0, 0, 0, vehicle.Tokyo)
+
+	steps := []struct {
+		offset   time.Duration
+		lat, lon float64
+	}{
+		{0, 35.0, 139.0},                 // baseline inside the depot: no event
+		{20 * time.Minute, 35.0, 139.0},  // entry time unknown: no dwell
+		{30 * time.Minute, 35.05, 139.0}, // exit the depot
+		{40 * time.Minute, 35.1, 139.0},  // enter the restricted area
+		{42 * time.Minute, 35.1, 139.0},  // dwell after 60 seconds
+		{50 * time.Minute, 35.0, 139.0},  // enter the depot
+		{70 * time.Minute, 35.0, 139.0},  // dwell in the depot
+		{80 * time.Minute, 35.05, 139.0}, // exit the depot
+	}
+	for _, s := range steps {
+		if err := m.Write(ctx, positionSnapshot(base.Add(s.offset), s.lat, s.lon)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	events, err := store.ListEvents(storage.EventFilter{})
+	if err != nil {
+		t.Fatalf("Failed to list events: %v", err)
+	}
+	want := []struct {
+		eventType string
+		fenceID   int64
+	}{
+		{EventGeofenceExit, depot.ID},
+		{EventGeofenceEnter, restricted.ID},
+		{EventGeofenceDwell, restricted.ID},
+		{EventGeofenceEnter, depot.ID},
+		{EventGeofenceExit, restricted.ID},
+		{EventGeofenceDwell, depot.ID},
+		{EventGeofenceExit, depot.ID},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("Expected %d events, got %+v", len(want), events)
+	}
+	for i, w := range want {
+		if events[i].Type != w.eventType || events[i].Detail["geofence_id"] != float64(w.fenceID) {
+			t.Errorf("Event %d: expected %s for geofence %d, got %s %v", i, w.eventType, w.fenceID, events[i].Type, events[i].Detail)
+		}
+	}
+	if _, ok := events[0].Detail["duration_seconds"]; ok {
+		t.Errorf("Expected no duration when the entry was not seen, got %v", events[0].Detail)
+	}
+	if events[6].Detail["duration_seconds"] != float64(1800) {
+		t.Errorf("Expected exit after 1800 seconds, got %v", events[6].Detail["duration_seconds"])
+	}
+
+	if kinds := notifier.kinds(); len(kinds) != 1 || kinds[0] != AlertRestrictedArea {
+		t.Errorf("Expected one restricted area alert, got %v", kinds)
+	}
+}
+
+func TestGeofenceMonitor_ChangedGeofence(t *testing.T) {
+	store := setupTestDB(t)
+	depot := &storage.Geofence{Name: "Depot", Shape: storage.ShapeCircle, Latitude: 35.0, Longitude: 139.0, Radius: 200}
+	if err := store.CreateGeofence(depot); err != nil {
+		t.Fatalf("Failed to create geofence: %v", err)
+	}
+
+	m := NewGeofenceMonitor(15*time.Minute, store, &recordingNotifier{})
+	ctx := context.Background()
+	base := time.Date(2025, 1, 2, 9, 0, 0, 0, vehicle.Tokyo)
+	m.Write(ctx, positionSnapshot(base, 35.0, 139.0)) // baseline inside
+
+	// Moving the geofence away starts a new baseline instead of an exit
+	depot.Latitude = 35.5
+	if _, err := store.UpdateGeofence(depot); err != nil {
+		t.Fatalf("Failed to update geofence: %v", err)
+	}
+	m.Write(ctx, positionSnapshot(base.Add(time.Minute), 35.0, 139.0))
+	m.Write(ctx, positionSnapshot(base.Add(2*time.Minute), 35.5, 139.0))
+
+	events, _ := store.ListEvents(storage.EventFilter{})
+	if len(events) != 1 || events[0].Type != EventGeofenceEnter {
+		t.Errorf("Expected only an enter into the moved geofence, got %+v", events)
+	}
+}
@@ -0,0 +1,311 @@
+package fleet
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/browser"
+	"github.com/yhonda-ohishi/browser_render_go/src/notify"
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+// Device health statuses, from best to worst.
+const (
+	HealthFresh      = "fresh"
+	HealthGPSInvalid = "gps_invalid"
+	HealthStale      = "stale"
+	HealthOffline    = "offline"
+)
+
+// HealthStatuses lists every health status from best to worst.
+var HealthStatuses = []string{HealthFresh, HealthGPSInvalid, HealthStale, HealthOffline}
+
+// Alert kinds sent by the HealthMonitor.
+const (
+	AlertDeviceUnhealthy = "device_unhealthy"
+	AlertDeviceRecovered = "device_recovered"
+)
+
+// ClassifyHealth returns a vehicle's status from its last contact: offline
+// when it is offlineAfter or more ago (or unknown), stale when it is
+// staleAfter or more ago, and otherwise gps_invalid or fresh depending on the
+// position.
+func ClassifyHealth(lastContact time.Time, gpsValid bool, now time.Time, staleAfter, offlineAfter time.Duration) string {
+	age := now.Sub(lastContact)
+	switch {
+	case lastContact.IsZero() || age >= offlineAfter:
+		return HealthOffline
+	case age >= staleAfter:
+		return HealthStale
+	case !gpsValid:
+		return HealthGPSInvalid
+	}
+	return HealthFresh
+}
+
+// CurrentHealth reclassifies a stored status as of now from its last
+// contact, so a vehicle that stopped reporting reads as stale or offline
+// even before it is checked again. Since is kept while the status does not
+// change. Zero thresholds leave h unchanged.
+func CurrentHealth(h storage.VehicleHealth, now time.Time, staleAfter, offlineAfter time.Duration) storage.VehicleHealth {
+	if staleAfter <= 0 || offlineAfter <= 0 {
+		return h
+	}
+	var contact time.Time
+	if h.LastContact != nil {
+		contact = *h.LastContact
+	}
+	if status := ClassifyHealth(contact, h.GPSValid, now, staleAfter, offlineAfter); status != h.Status {
+		h.Status = status
+		h.Since = healthSince(status, contact, now, staleAfter, offlineAfter)
+	}
+	return h
+}
+
+// HealthMonitor classifies every scraped vehicle's telematics data as fresh,
+// stale, offline or GPS-invalid, stores the status with the time the vehicle
+// entered it, and alerts when a vehicle enters or leaves one of the alert
+// statuses. The first check of a vehicle only sets its baseline. Vehicles
+// missing from a snapshot, such as unchanged ones under polling, are
+// rechecked from their last contact on every snapshot and by Run.
+type HealthMonitor struct {
+	staleAfter    time.Duration
+	offlineAfter  time.Duration
+	alertStatuses map[string]bool
+	storage       *storage.Storage
+	notifier      notify.Notifier
+
+	mu     sync.Mutex
+	states map[int64]*storage.VehicleHealth
+}
+
+// NewHealthMonitor creates a monitor. A vehicle whose last contact is
+// staleAfter ago is stale and offlineAfter ago offline; alertStatuses are the
+// statuses that are notified.
+func NewHealthMonitor(staleAfter, offlineAfter time.Duration, alertStatuses []string, store *storage.Storage, notifier notify.Notifier) *HealthMonitor {
+	alerts := make(map[string]bool, len(alertStatuses))
+	for _, status := range alertStatuses {
+		alerts[status] = true
+	}
+	return &HealthMonitor{
+		staleAfter:    staleAfter,
+		offlineAfter:  offlineAfter,
+		alertStatuses: alerts,
+		storage:       store,
+		notifier:      notifier,
+		states:        make(map[int64]*storage.VehicleHealth),
+	}
+}
+
+// Restore loads the stored statuses, so the time in a status and pending
+// recoveries survive a restart.
+func (m *HealthMonitor) Restore() error {
+	stored, err := m.storage.ListHealth(storage.HealthFilter{})
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range stored {
+		m.states[stored[i].VehicleCD] = &stored[i]
+	}
+	return nil
+}
+
+func (m *HealthMonitor) Name() string {
+	return "health"
+}
+
+func (m *HealthMonitor) Write(ctx context.Context, snapshot *browser.Snapshot) error {
+	health, alerts := m.check(snapshot)
+	return m.save(ctx, health, alerts)
+}
+
+// Run rechecks every tracked vehicle each interval until ctx is cancelled,
+// so stale and offline vehicles are detected while no snapshot arrives.
+func (m *HealthMonitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := m.Recheck(ctx, now); err != nil {
+				log.Printf("Error rechecking vehicle health: %v", err)
+			}
+		}
+	}
+}
+
+// Recheck reclassifies every tracked vehicle as of now from its last
+// contact, storing and alerting the statuses that changed.
+func (m *HealthMonitor) Recheck(ctx context.Context, now time.Time) error {
+	m.mu.Lock()
+	health, alerts := m.recheck(now, nil)
+	m.mu.Unlock()
+	return m.save(ctx, health, alerts)
+}
+
+func (m *HealthMonitor) save(ctx context.Context, health []storage.VehicleHealth, alerts []notify.Alert) error {
+	if err := m.storage.SaveHealth(health); err != nil {
+		return fmt.Errorf("failed to save vehicle health: %w", err)
+	}
+
+	for _, alert := range alerts {
+		if err := m.notifier.Notify(ctx, alert); err != nil {
+			log.Printf("Warning: failed to send health alert: %v", err)
+		}
+	}
+	return nil
+}
+
+func (m *HealthMonitor) check(snapshot *browser.Snapshot) ([]storage.VehicleHealth, []notify.Alert) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := snapshot.FetchedAt
+	seen := make(map[int64]bool, len(snapshot.Vehicles))
+	var health []storage.VehicleHealth
+	var alerts []notify.Alert
+	for _, vd := range snapshot.Vehicles {
+		t := vd.Telemetry
+		if t == nil || t.VehicleCD == 0 {
+			continue
+		}
+
+		var lastContact *time.Time
+		for _, at := range []*time.Time{vd.DataTime, vd.ComuTime} {
+			if at != nil && (lastContact == nil || at.After(*lastContact)) {
+				lastContact = at
+			}
+		}
+		var contact time.Time
+		if lastContact != nil {
+			contact = *lastContact
+		}
+		gpsValid := vd.Position != nil && vd.Position.Valid
+		status := ClassifyHealth(contact, gpsValid, now, m.staleAfter, m.offlineAfter)
+
+		h := storage.VehicleHealth{
+			VehicleCD:   t.VehicleCD,
+			VehicleID:   vd.VehicleID,
+			VehicleName: vd.VehicleName,
+			BranchCD:    t.BranchCD,
+			Status:      status,
+			Since:       healthSince(status, contact, now, m.staleAfter, m.offlineAfter),
+			LastContact: lastContact,
+			GPSValid:    gpsValid,
+			CheckedAt:   now,
+		}
+		if alert := m.apply(&h); alert != nil {
+			alerts = append(alerts, *alert)
+		}
+		seen[t.VehicleCD] = true
+		health = append(health, h)
+	}
+
+	rechecked, recheckAlerts := m.recheck(now, seen)
+	return append(health, rechecked...), append(alerts, recheckAlerts...)
+}
+
+// recheck reclassifies the tracked vehicles not in seen as of now and
+// returns those whose status changed. The caller holds m.mu.
+func (m *HealthMonitor) recheck(now time.Time, seen map[int64]bool) ([]storage.VehicleHealth, []notify.Alert) {
+	var health []storage.VehicleHealth
+	var alerts []notify.Alert
+	for cd, prev := range m.states {
+		if seen[cd] || !now.After(prev.CheckedAt) {
+			continue
+		}
+		h := CurrentHealth(*prev, now, m.staleAfter, m.offlineAfter)
+		if h.Status == prev.Status {
+			continue
+		}
+		h.CheckedAt = now
+		if alert := m.apply(&h); alert != nil {
+			alerts = append(alerts, *alert)
+		}
+		health = append(health, h)
+	}
+	return health, alerts
+}
+
+// apply records a vehicle's new health, keeping Since while the status is
+// unchanged, and returns the alert for a status change, if any.
+func (m *HealthMonitor) apply(h *storage.VehicleHealth) *notify.Alert {
+	prev := m.states[h.VehicleCD]
+	var alert *notify.Alert
+	if prev != nil && prev.Status == h.Status {
+		h.Since = prev.Since
+	}
+	if prev != nil && prev.Status != h.Status {
+		alert = m.alert(prev.Status, h)
+	}
+	stored := *h
+	m.states[h.VehicleCD] = &stored
+	return alert
+}
+
+// healthSince estimates when a vehicle entered a status: stale and offline
+// start when the last contact became too old, the others at this check.
+func healthSince(status string, lastContact, now time.Time, staleAfter, offlineAfter time.Duration) time.Time {
+	var at time.Time
+	switch {
+	case lastContact.IsZero():
+		return now
+	case status == HealthStale:
+		at = lastContact.Add(staleAfter)
+	case status == HealthOffline:
+		at = lastContact.Add(offlineAfter)
+	default:
+		return now
+	}
+	if at.After(now) {
+		return now
+	}
+	return at
+}
+
+func (m *HealthMonitor) alert(prevStatus string, h *storage.VehicleHealth) *notify.Alert {
+	contact := "never"
+	if h.LastContact != nil {
+		contact = h.LastContact.In(vehicle.Tokyo).Format("2006/01/02 15:04")
+	}
+	detail := map[string]interface{}{
+		"from":      prevStatus,
+		"to":        h.Status,
+		"branch_cd": h.BranchCD,
+	}
+
+	switch {
+	case m.alertStatuses[h.Status]:
+		return &notify.Alert{
+			Kind:        AlertDeviceUnhealthy,
+			Severity:    notify.SeverityWarning,
+			Title:       fmt.Sprintf("%s is %s", h.VehicleName, h.Status),
+			Message:     fmt.Sprintf("Last contact %s", contact),
+			VehicleCD:   h.VehicleCD,
+			VehicleName: h.VehicleName,
+			Time:        h.CheckedAt,
+			Detail:      detail,
+		}
+	case m.alertStatuses[prevStatus]:
+		return &notify.Alert{
+			Kind:        AlertDeviceRecovered,
+			Severity:    notify.SeverityInfo,
+			Title:       fmt.Sprintf("%s is no longer %s", h.VehicleName, prevStatus),
+			Message:     fmt.Sprintf("Now %s, last contact %s", h.Status, contact),
+			VehicleCD:   h.VehicleCD,
+			VehicleName: h.VehicleName,
+			Time:        h.CheckedAt,
+			Detail:      detail,
+		}
+	}
+	return nil
+}
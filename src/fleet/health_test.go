@@ -0,0 +1,201 @@
+package fleet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/browser"
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+func TestClassifyHealth(t *testing.T) {
+	now := time.Date(2025, 1, 2, 12, 0, 0, 0, vehicle.Tokyo)
+	tests := []struct {
+		name        string
+		lastContact time.Time
+		gpsValid    bool
+		want        string
+	}{
+		{"fresh", now.Add(-5 * time.Minute), true, HealthFresh},
+		{"gps invalid", now.Add(-5 * time.Minute), false, HealthGPSInvalid},
+		{"stale", now.Add(-time.Hour), true, HealthStale},
+		{"stale without gps", now.Add(-time.Hour), false, HealthStale},
+		{"offline", now.Add(-25 * time.Hour), true, HealthOffline},
+		{"never", time.Time{}, true, HealthOffline},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyHealth(tt.lastContact, tt.gpsValid, now, 30*time.Minute, 24*time.Hour); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestHealthMonitor(t *testing.T) {
+	store := setupTestDB(t)
+	notifier := &recordingNotifier{}
+	m := NewHealthMonitor(30*time.Minute, 2*time.Hour, []string{HealthOffline}, store, notifier)
+	base := time.Date(2025, 1, 2, 9, 0, 0, 0, vehicle.Tokyo)
+
+	scrape := func(now, dataTime time.Time, gpsValid bool) {
+		t.Helper()
+		snapshot := &browser.Snapshot{
+			FetchedAt: now,
+			Vehicles: []browser.VehicleData{{
+				VehicleName: "Truck",
+				DataTime:    &dataTime,
+				Position:    &vehicle.Position{Latitude: 35, Longitude: 139, Valid: gpsValid},
+				Telemetry:   &vehicle.Telemetry{VehicleCD: 7, BranchCD: 3},
+			}},
+		}
+		if err := m.Write(context.Background(), snapshot); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	steps := []struct {
+		now      time.Duration
+		dataTime time.Duration
+		gpsValid bool
+		status   string
+		since    time.Duration
+	}{
+		{0, 0, true, HealthFresh, 0},
+		{10 * time.Minute, 10 * time.Minute, false, HealthGPSInvalid, 10 * time.Minute},
+		{time.Hour, 10 * time.Minute, false, HealthStale, 40 * time.Minute},
+		{2 * time.Hour, 10 * time.Minute, false, HealthStale, 40 * time.Minute}, // still stale: since is kept
+		{3 * time.Hour, 10 * time.Minute, false, HealthOffline, 2*time.Hour + 10*time.Minute},
+		{4 * time.Hour, 4 * time.Hour, true, HealthFresh, 4 * time.Hour},
+	}
+	for i, step := range steps {
+		scrape(base.Add(step.now), base.Add(step.dataTime), step.gpsValid)
+
+		health, err := store.ListHealth(storage.HealthFilter{})
+		if err != nil || len(health) != 1 {
+			t.Fatalf("Step %d: expected 1 row, got %d (%v)", i, len(health), err)
+		}
+		h := health[0]
+		if h.Status != step.status || !h.Since.Equal(base.Add(step.since)) {
+			t.Errorf("Step %d: expected %s since %v, got %s since %v", i, step.status, step.since, h.Status, h.Since.Sub(base))
+		}
+	}
+
+	if kinds := notifier.kinds(); len(kinds) != 2 || kinds[0] != AlertDeviceUnhealthy || kinds[1] != AlertDeviceRecovered {
+		t.Errorf("Expected an offline and a recovery alert, got %v", kinds)
+	}
+}
+
+func TestHealthMonitor_MissingFromPartialSnapshots(t *testing.T) {
+	store := setupTestDB(t)
+	notifier := &recordingNotifier{}
+	m := NewHealthMonitor(30*time.Minute, 2*time.Hour, []string{HealthOffline}, store, notifier)
+	base := time.Date(2025, 1, 2, 9, 0, 0, 0, vehicle.Tokyo)
+
+	// Vehicle 7 is only in the first snapshot; later partial snapshots only
+	// hold vehicle 8, as under polling when vehicle 7 stops reporting
+	scrape := func(now time.Time, vehicleCDs ...int64) {
+		t.Helper()
+		snapshot := &browser.Snapshot{FetchedAt: now, Partial: true}
+		for _, cd := range vehicleCDs {
+			snapshot.Vehicles = append(snapshot.Vehicles, browser.VehicleData{
+				DataTime:  &now,
+				Position:  &vehicle.Position{Latitude: 35, Longitude: 139, Valid: true},
+				Telemetry: &vehicle.Telemetry{VehicleCD: cd},
+			})
+		}
+		if err := m.Write(context.Background(), snapshot); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	statusOf := func(cd int64) storage.VehicleHealth {
+		t.Helper()
+		health, err := store.ListHealth(storage.HealthFilter{})
+		if err != nil {
+			t.Fatalf("ListHealth failed: %v", err)
+		}
+		for _, h := range health {
+			if h.VehicleCD == cd {
+				return h
+			}
+		}
+		t.Fatalf("No health stored for vehicle %d", cd)
+		return storage.VehicleHealth{}
+	}
+
+	scrape(base, 7, 8)
+	scrape(base.Add(time.Hour), 8)
+	if h := statusOf(7); h.Status != HealthStale || !h.Since.Equal(base.Add(30*time.Minute)) {
+		t.Errorf("Expected vehicle 7 stale since 30m, got %s since %v", h.Status, h.Since.Sub(base))
+	}
+
+	// With no snapshot at all, the timer recheck takes it offline
+	if err := m.Recheck(context.Background(), base.Add(3*time.Hour)); err != nil {
+		t.Fatalf("Recheck failed: %v", err)
+	}
+	if h := statusOf(7); h.Status != HealthOffline || !h.Since.Equal(base.Add(2*time.Hour)) {
+		t.Errorf("Expected vehicle 7 offline since 2h, got %s since %v", h.Status, h.Since.Sub(base))
+	}
+	if h := statusOf(8); h.Status != HealthOffline {
+		t.Errorf("Expected vehicle 8 offline after 3h without data, got %s", h.Status)
+	}
+	if kinds := notifier.kinds(); len(kinds) != 2 || kinds[0] != AlertDeviceUnhealthy || kinds[1] != AlertDeviceUnhealthy {
+		t.Errorf("Expected two offline alerts, got %v", kinds)
+	}
+
+	// Rechecking again changes nothing and sends no more alerts
+	if err := m.Recheck(context.Background(), base.Add(4*time.Hour)); err != nil {
+		t.Fatalf("Recheck failed: %v", err)
+	}
+	if kinds := notifier.kinds(); len(kinds) != 2 {
+		t.Errorf("Expected no more alerts, got %v", kinds)
+	}
+}
+
+func TestCurrentHealth(t *testing.T) {
+	now := time.Date(2025, 1, 2, 12, 0, 0, 0, vehicle.Tokyo)
+	contact := now.Add(-time.Hour)
+	stored := storage.VehicleHealth{Status: HealthFresh, Since: contact, LastContact: &contact, GPSValid: true}
+
+	if h := CurrentHealth(stored, now, 30*time.Minute, 24*time.Hour); h.Status != HealthStale || !h.Since.Equal(contact.Add(30*time.Minute)) {
+		t.Errorf("Expected stale since 30m after contact, got %s since %v", h.Status, h.Since)
+	}
+	if h := CurrentHealth(stored, now.Add(-50*time.Minute), 30*time.Minute, 24*time.Hour); h.Status != HealthFresh || !h.Since.Equal(contact) {
+		t.Errorf("Expected fresh with since kept, got %s since %v", h.Status, h.Since)
+	}
+	if h := CurrentHealth(stored, now, 0, 0); h.Status != HealthFresh {
+		t.Errorf("Expected unchanged status without thresholds, got %s", h.Status)
+	}
+}
+
+func TestHealthMonitor_Restore(t *testing.T) {
+	store := setupTestDB(t)
+	base := time.Date(2025, 1, 2, 9, 0, 0, 0, vehicle.Tokyo)
+	if err := store.SaveHealth([]storage.VehicleHealth{{VehicleCD: 7, Status: HealthOffline, Since: base, CheckedAt: base}}); err != nil {
+		t.Fatalf("Failed to save health: %v", err)
+	}
+
+	notifier := &recordingNotifier{}
+	m := NewHealthMonitor(30*time.Minute, 2*time.Hour, []string{HealthOffline}, store, notifier)
+	if err := m.Restore(); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+
+	now := base.Add(time.Hour)
+	snapshot := &browser.Snapshot{
+		FetchedAt: now,
+		Vehicles: []browser.VehicleData{{
+			DataTime:  &now,
+			Position:  &vehicle.Position{Latitude: 35, Longitude: 139, Valid: true},
+			Telemetry: &vehicle.Telemetry{VehicleCD: 7},
+		}},
+	}
+	if err := m.Write(context.Background(), snapshot); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if kinds := notifier.kinds(); len(kinds) != 1 || kinds[0] != AlertDeviceRecovered {
+		t.Errorf("Expected a recovery alert after restore, got %v", kinds)
+	}
+}
@@ -0,0 +1,116 @@
+package fleet
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+// NearestQuery selects vehicles and ranks them by distance from a point.
+type NearestQuery struct {
+	Latitude     float64 // WGS84
+	Longitude    float64
+	BranchCD     int      // 0 for any branch
+	States       []string // AllState values, empty for any
+	HasDriver    *bool    // whether a driver must or must not be assigned
+	Refrigerated *bool    // whether the vehicle must or must not report compartment temperatures
+	MaxDistance  float64  // meters, 0 for no limit
+	Limit        int      // 0 for no limit
+	AverageSpeed float64  // km/h for the ETA, 0 leaves the ETA unset
+}
+
+// NearbyVehicle is a vehicle's last known position with its distance from
+// the queried point.
+type NearbyVehicle struct {
+	VehicleCD    int64     `json:"vehicle_cd"`
+	VehicleID    int64     `json:"vehicle_id,omitempty"`
+	VehicleName  string    `json:"vehicle_name"`
+	BranchCD     int       `json:"branch_cd"`
+	BranchName   string    `json:"branch_name"`
+	DriverCD     int       `json:"driver_cd"`
+	DriverName   string    `json:"driver_name"`
+	AllState     string    `json:"all_state"`
+	Refrigerated bool      `json:"refrigerated"`
+	Latitude     float64   `json:"latitude"`
+	Longitude    float64   `json:"longitude"`
+	DataTime     time.Time `json:"data_time"`
+	DistanceM    float64   `json:"distance_m"`            // great-circle distance
+	ETASeconds   int64     `json:"eta_seconds,omitempty"` // straight line at AverageSpeed
+}
+
+// NearestVehicles ranks the records, normally each vehicle's latest, by
+// great-circle distance from the queried point, nearest first. Records
+// without a valid position or not matching the filters are left out. A
+// vehicle counts as refrigerated when its record has a compartment reading.
+func NearestVehicles(records []storage.TelemetryRecord, q NearestQuery) []NearbyVehicle {
+	states := make(map[string]bool, len(q.States))
+	for _, s := range q.States {
+		states[s] = true
+	}
+
+	var nearby []NearbyVehicle
+	for _, r := range records {
+		if r.Latitude == nil || r.Longitude == nil || !r.GPSValid {
+			continue
+		}
+		if q.BranchCD != 0 && r.BranchCD != q.BranchCD {
+			continue
+		}
+		if q.HasDriver != nil && (r.DriverCD != 0) != *q.HasDriver {
+			continue
+		}
+
+		var raw map[string]interface{}
+		if err := json.Unmarshal([]byte(r.Record), &raw); err != nil {
+			raw = nil
+		}
+		t, _ := vehicle.Decode(raw)
+		if len(states) > 0 && !states[t.AllState] {
+			continue
+		}
+		refrigerated := len(t.Compartments()) > 0
+		if q.Refrigerated != nil && refrigerated != *q.Refrigerated {
+			continue
+		}
+
+		distance := vehicle.Distance(q.Latitude, q.Longitude, *r.Latitude, *r.Longitude)
+		if q.MaxDistance > 0 && distance > q.MaxDistance {
+			continue
+		}
+
+		v := NearbyVehicle{
+			VehicleCD:    r.VehicleCD,
+			VehicleID:    r.VehicleID,
+			VehicleName:  r.VehicleName,
+			BranchCD:     r.BranchCD,
+			BranchName:   t.BranchName,
+			DriverCD:     r.DriverCD,
+			DriverName:   t.DriverName,
+			AllState:     t.AllState,
+			Refrigerated: refrigerated,
+			Latitude:     *r.Latitude,
+			Longitude:    *r.Longitude,
+			DataTime:     r.DataTime,
+			DistanceM:    math.Round(distance),
+		}
+		if q.AverageSpeed > 0 {
+			v.ETASeconds = int64(math.Round(distance / (q.AverageSpeed * 1000 / 3600)))
+		}
+		nearby = append(nearby, v)
+	}
+
+	sort.Slice(nearby, func(i, j int) bool {
+		if nearby[i].DistanceM != nearby[j].DistanceM {
+			return nearby[i].DistanceM < nearby[j].DistanceM
+		}
+		return nearby[i].VehicleCD < nearby[j].VehicleCD
+	})
+	if q.Limit > 0 && len(nearby) > q.Limit {
+		nearby = nearby[:q.Limit]
+	}
+	return nearby
+}
@@ -0,0 +1,106 @@
+package fleet
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+)
+
+func nearRecord(t *testing.T, cd int64, lat, lon float64, branchCD, driverCD int, state, temp string) storage.TelemetryRecord {
+	t.Helper()
+	record, err := json.Marshal(map[string]interface{}{
+		"VehicleCD":   cd,
+		"BranchCD":    branchCD,
+		"BranchName":  "本社",
+		"DriverCD":    driverCD,
+		"AllState":    state,
+		"Temp1":       temp,
+		"SettingTemp": "-20",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return storage.TelemetryRecord{
+		VehicleCD:   cd,
+		VehicleName: "Truck",
+		BranchCD:    branchCD,
+		DriverCD:    driverCD,
+		DataTime:    time.Date(2025, 1, 2, 9, 0, 0, 0, time.UTC),
+		Latitude:    &lat,
+		Longitude:   &lon,
+		GPSValid:    true,
+		Record:      string(record),
+	}
+}
+
+func TestNearestVehicles(t *testing.T) {
+	records := []storage.TelemetryRecord{
+		nearRecord(t, 1, 35.10, 139, 1, 10, "空車", ""),    // ~11 km
+		nearRecord(t, 2, 35.01, 139, 1, 0, "空車", "-18"),  // ~1.1 km
+		nearRecord(t, 3, 35.05, 139, 2, 11, "実車", "-19"), // ~5.6 km
+		nearRecord(t, 4, 35.00, 139, 1, 12, "空車", ""),    // at the point, but no valid GPS
+	}
+	records[3].GPSValid = false
+
+	yes, no := true, false
+	tests := []struct {
+		name  string
+		query NearestQuery
+		want  []int64
+	}{
+		{"all by distance", NearestQuery{}, []int64{2, 3, 1}},
+		{"limit", NearestQuery{Limit: 2}, []int64{2, 3}},
+		{"max distance", NearestQuery{MaxDistance: 6000}, []int64{2, 3}},
+		{"branch", NearestQuery{BranchCD: 1}, []int64{2, 1}},
+		{"state", NearestQuery{States: []string{"空車"}}, []int64{2, 1}},
+		{"driver assigned", NearestQuery{HasDriver: &yes}, []int64{3, 1}},
+		{"no driver", NearestQuery{HasDriver: &no}, []int64{2}},
+		{"refrigerated", NearestQuery{Refrigerated: &yes}, []int64{2, 3}},
+		{"not refrigerated", NearestQuery{Refrigerated: &no}, []int64{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.query.Latitude, tt.query.Longitude = 35, 139
+			got := NearestVehicles(records, tt.query)
+			var cds []int64
+			for _, v := range got {
+				cds = append(cds, v.VehicleCD)
+			}
+			if len(cds) != len(tt.want) {
+				t.Fatalf("Got vehicles %v, want %v", cds, tt.want)
+			}
+			for i := range cds {
+				if cds[i] != tt.want[i] {
+					t.Fatalf("Got vehicles %v, want %v", cds, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestNearestVehicles_ETA(t *testing.T) {
+	records := []storage.TelemetryRecord{nearRecord(t, 2, 35.10, 139, 1, 0, "空車", "-18")}
+
+	got := NearestVehicles(records, NearestQuery{Latitude: 35, Longitude: 139, AverageSpeed: 40})
+	if len(got) != 1 {
+		t.Fatalf("Expected 1 vehicle, got %d", len(got))
+	}
+	v := got[0]
+	if v.DistanceM < 11100 || v.DistanceM > 11150 {
+		t.Errorf("Unexpected distance %v", v.DistanceM)
+	}
+	// 11.12 km at 40 km/h is about 1000 seconds
+	if v.ETASeconds < 995 || v.ETASeconds > 1005 {
+		t.Errorf("Unexpected ETA %d", v.ETASeconds)
+	}
+	if !v.Refrigerated || v.BranchName != "本社" || v.AllState != "空車" {
+		t.Errorf("Unexpected vehicle: %+v", v)
+	}
+
+	if got := NearestVehicles(records, NearestQuery{Latitude: 35, Longitude: 139}); got[0].ETASeconds != 0 {
+		t.Errorf("Expected no ETA without an average speed, got %d", got[0].ETASeconds)
+	}
+}
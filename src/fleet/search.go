@@ -0,0 +1,140 @@
+package fleet
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/browser"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+// BBox is a WGS84 bounding box.
+type BBox struct {
+	MinLatitude  float64
+	MinLongitude float64
+	MaxLatitude  float64
+	MaxLongitude float64
+}
+
+// Contains reports whether the point lies inside the box, edges included.
+func (b BBox) Contains(lat, lon float64) bool {
+	return lat >= b.MinLatitude && lat <= b.MaxLatitude && lon >= b.MinLongitude && lon <= b.MaxLongitude
+}
+
+// VehicleSearch filters and orders vehicles. Unset filters match every
+// vehicle.
+type VehicleSearch struct {
+	BranchCDs  []int
+	States     []string // AllState values
+	DriverName string   // substring of DriverName
+	Name       string   // substring of VehicleName
+	BBox       *BBox    // only vehicles with a valid position inside
+	Sort       string   // one of SearchSortKeys, "-" prefix for descending
+}
+
+// SearchSortKeys lists the keys vehicles can be sorted by.
+var SearchSortKeys = []string{"vehicle_cd", "vehicle_name", "branch_cd", "driver_name", "all_state", "speed", "data_time"}
+
+// searchText normalizes text for substring matching: NFKC (full-width
+// letters and digits become ASCII, half-width katakana full-width), lower
+// case and no whitespace.
+func searchText(s string) string {
+	return strings.ToLower(strings.ReplaceAll(vehicle.NormalizePlateText(s), " ", ""))
+}
+
+// SearchVehicles returns the vehicles matching every set filter in the
+// requested order, by VehicleCD by default and for ties. Names are matched
+// and sorted in normalized form.
+func SearchVehicles(vehicles []browser.VehicleData, q VehicleSearch) ([]browser.VehicleData, error) {
+	key, desc := strings.CutPrefix(q.Sort, "-")
+	if key == "" {
+		key = "vehicle_cd"
+	}
+	less, ok := searchOrders[key]
+	if !ok {
+		return nil, fmt.Errorf("invalid sort %q, must be one of %s", q.Sort, strings.Join(SearchSortKeys, ", "))
+	}
+
+	branches := make(map[int]bool, len(q.BranchCDs))
+	for _, cd := range q.BranchCDs {
+		branches[cd] = true
+	}
+	states := make(map[string]bool, len(q.States))
+	for _, s := range q.States {
+		states[s] = true
+	}
+	driverName := searchText(q.DriverName)
+	name := searchText(q.Name)
+
+	matched := make([]browser.VehicleData, 0, len(vehicles))
+	for _, vd := range vehicles {
+		t := vd.Telemetry
+		if t == nil {
+			continue
+		}
+		if len(branches) > 0 && !branches[t.BranchCD] {
+			continue
+		}
+		if len(states) > 0 && !states[t.AllState] {
+			continue
+		}
+		if driverName != "" && !strings.Contains(searchText(t.DriverName), driverName) {
+			continue
+		}
+		if name != "" && !strings.Contains(searchText(vd.VehicleName), name) {
+			continue
+		}
+		if q.BBox != nil {
+			p := vd.Position
+			if p == nil || !p.Valid || !q.BBox.Contains(p.Latitude, p.Longitude) {
+				continue
+			}
+		}
+		matched = append(matched, vd)
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		a, b := &matched[i], &matched[j]
+		if less(a, b) {
+			return !desc
+		}
+		if less(b, a) {
+			return desc
+		}
+		return a.Telemetry.VehicleCD < b.Telemetry.VehicleCD
+	})
+	return matched, nil
+}
+
+var searchOrders = map[string]func(a, b *browser.VehicleData) bool{
+	"vehicle_cd": func(a, b *browser.VehicleData) bool {
+		return a.Telemetry.VehicleCD < b.Telemetry.VehicleCD
+	},
+	"vehicle_name": func(a, b *browser.VehicleData) bool {
+		return searchText(a.VehicleName) < searchText(b.VehicleName)
+	},
+	"branch_cd": func(a, b *browser.VehicleData) bool {
+		return a.Telemetry.BranchCD < b.Telemetry.BranchCD
+	},
+	"driver_name": func(a, b *browser.VehicleData) bool {
+		return searchText(a.Telemetry.DriverName) < searchText(b.Telemetry.DriverName)
+	},
+	"all_state": func(a, b *browser.VehicleData) bool {
+		return a.Telemetry.AllState < b.Telemetry.AllState
+	},
+	"speed": func(a, b *browser.VehicleData) bool {
+		return a.Telemetry.Speed < b.Telemetry.Speed
+	},
+	"data_time": func(a, b *browser.VehicleData) bool {
+		return timeOf(a.DataTime).Before(timeOf(b.DataTime))
+	},
+}
+
+func timeOf(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
@@ -0,0 +1,81 @@
+package fleet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/browser"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+func searchVehicle(cd int64, name string, branchCD int, driver, state string, speed, lat float64) browser.VehicleData {
+	at := time.Date(2025, 1, 2, 9, 0, 0, 0, vehicle.Tokyo).Add(time.Duration(cd) * time.Minute)
+	return browser.VehicleData{
+		VehicleName: name,
+		DataTime:    &at,
+		Position:    &vehicle.Position{Latitude: lat, Longitude: 139, Valid: true},
+		Telemetry: &vehicle.Telemetry{
+			VehicleCD:  cd,
+			BranchCD:   branchCD,
+			DriverName: driver,
+			AllState:   state,
+			Speed:      speed,
+		},
+	}
+}
+
+func TestSearchVehicles(t *testing.T) {
+	vehicles := []browser.VehicleData{
+		searchVehicle(3, "ＡＢＣ１号車", 1, "佐藤 太郎", "運行中", 40, 35.1),
+		searchVehicle(1, "abc2号車", 2, "鈴木　花子", "休憩中", 0, 35.5),
+		searchVehicle(2, "ｶﾞｿﾘﾝ車", 1, "佐藤 次郎", "運行中", 60, 36.0),
+		{VehicleName: "undecoded"},
+	}
+
+	tests := []struct {
+		name  string
+		query VehicleSearch
+		want  []int64
+	}{
+		{"all by vehicle_cd", VehicleSearch{}, []int64{1, 2, 3}},
+		{"branch", VehicleSearch{BranchCDs: []int{1}}, []int64{2, 3}},
+		{"several branches", VehicleSearch{BranchCDs: []int{1, 2}}, []int64{1, 2, 3}},
+		{"state", VehicleSearch{States: []string{"休憩中"}}, []int64{1}},
+		{"driver substring", VehicleSearch{DriverName: "佐藤"}, []int64{2, 3}},
+		{"driver full-width space", VehicleSearch{DriverName: "鈴木 花子"}, []int64{1}},
+		{"name full-width", VehicleSearch{Name: "abc"}, []int64{1, 3}},
+		{"name upper case", VehicleSearch{Name: "ＡＢＣ1"}, []int64{3}},
+		{"name half-width katakana", VehicleSearch{Name: "ガソリン"}, []int64{2}},
+		{"bbox", VehicleSearch{BBox: &BBox{35, 138, 35.6, 140}}, []int64{1, 3}},
+		{"sort by speed", VehicleSearch{Sort: "speed"}, []int64{1, 3, 2}},
+		{"sort descending", VehicleSearch{Sort: "-speed"}, []int64{2, 3, 1}},
+		{"ties by vehicle_cd", VehicleSearch{Sort: "-branch_cd"}, []int64{1, 2, 3}},
+		{"sort by normalized name", VehicleSearch{Sort: "vehicle_name"}, []int64{3, 1, 2}},
+		{"sort by data_time", VehicleSearch{Sort: "-data_time"}, []int64{3, 2, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SearchVehicles(vehicles, tt.query)
+			if err != nil {
+				t.Fatalf("SearchVehicles failed: %v", err)
+			}
+			var cds []int64
+			for _, vd := range got {
+				cds = append(cds, vd.Telemetry.VehicleCD)
+			}
+			if len(cds) != len(tt.want) {
+				t.Fatalf("Got vehicles %v, want %v", cds, tt.want)
+			}
+			for i := range cds {
+				if cds[i] != tt.want[i] {
+					t.Fatalf("Got vehicles %v, want %v", cds, tt.want)
+				}
+			}
+		})
+	}
+
+	if _, err := SearchVehicles(vehicles, VehicleSearch{Sort: "color"}); err == nil {
+		t.Error("Expected an error for an unknown sort key")
+	}
+}
@@ -0,0 +1,293 @@
+package fleet
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/browser"
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+// summaryKey is the kv store key of the cached fleet summary.
+const summaryKey = "fleet_summary"
+
+// GroupCount is the number of vehicles sharing a value.
+type GroupCount struct {
+	Key   string `json:"key"`
+	Name  string `json:"name,omitempty"` // branch name for branches
+	Count int    `json:"count"`
+}
+
+// FleetSummary counts the vehicles of the latest snapshot by branch, state,
+// current work and data freshness.
+type FleetSummary struct {
+	GeneratedAt     time.Time    `json:"generated_at"` // time of the snapshot it was computed from
+	Total           int          `json:"total"`
+	Branches        []GroupCount `json:"branches"`         // by BranchCD
+	AllStates       []GroupCount `json:"all_states"`       // by AllState
+	OperationStates []GroupCount `json:"operation_states"` // by OperationState
+	CurrentWorks    []GroupCount `json:"current_works"`    // by CurrentWorkName
+	Freshness       []GroupCount `json:"freshness"`        // by health status, see ClassifyHealth
+}
+
+// VehicleContact is what a vehicle's freshness is classified from.
+type VehicleContact struct {
+	LastContact time.Time `json:"last_contact"` // zero when never heard from
+	GPSValid    bool      `json:"gps_valid"`
+}
+
+// cachedSummary is the stored form of a summary. It keeps every vehicle's
+// last contact, so freshness is classified when the summary is read rather
+// than frozen at the time of the snapshot.
+type cachedSummary struct {
+	FleetSummary
+	Contacts []VehicleContact `json:"contacts"`
+}
+
+// Summarize counts the vehicles as of now. Branches are ordered by BranchCD,
+// freshness by HealthStatuses and the other groups by count, largest first.
+func Summarize(vehicles []browser.VehicleData, now time.Time, staleAfter, offlineAfter time.Duration) FleetSummary {
+	summary, contacts := summarize(vehicles, now)
+	summary.Freshness = countFreshness(contacts, now, staleAfter, offlineAfter)
+	return summary
+}
+
+// countFreshness classifies the contacts as of now, in HealthStatuses order.
+func countFreshness(contacts []VehicleContact, now time.Time, staleAfter, offlineAfter time.Duration) []GroupCount {
+	counts := make(map[string]int)
+	for _, c := range contacts {
+		counts[ClassifyHealth(c.LastContact, c.GPSValid, now, staleAfter, offlineAfter)]++
+	}
+	freshness := make([]GroupCount, len(HealthStatuses))
+	for i, status := range HealthStatuses {
+		freshness[i] = GroupCount{Key: status, Count: counts[status]}
+	}
+	return freshness
+}
+
+// summarize counts the vehicles except for freshness and returns the
+// contacts it is classified from.
+func summarize(vehicles []browser.VehicleData, now time.Time) (FleetSummary, []VehicleContact) {
+	branches := make(map[int]*GroupCount)
+	allStates := make(map[string]int)
+	operationStates := make(map[string]int)
+	works := make(map[string]int)
+	var contacts []VehicleContact
+
+	total := 0
+	for _, vd := range vehicles {
+		t := vd.Telemetry
+		if t == nil {
+			continue
+		}
+		total++
+
+		b := branches[t.BranchCD]
+		if b == nil {
+			b = &GroupCount{Key: strconv.Itoa(t.BranchCD)}
+			branches[t.BranchCD] = b
+		}
+		b.Count++
+		if t.BranchName != "" {
+			b.Name = t.BranchName
+		}
+		allStates[t.AllState]++
+		operationStates[strconv.Itoa(t.OperationState)]++
+		works[t.CurrentWorkName]++
+
+		var contact time.Time
+		for _, at := range []*time.Time{vd.DataTime, vd.ComuTime} {
+			if at != nil && at.After(contact) {
+				contact = *at
+			}
+		}
+		contacts = append(contacts, VehicleContact{LastContact: contact, GPSValid: vd.Position != nil && vd.Position.Valid})
+	}
+
+	summary := FleetSummary{
+		GeneratedAt:     now,
+		Total:           total,
+		Branches:        make([]GroupCount, 0, len(branches)),
+		AllStates:       groupCounts(allStates),
+		OperationStates: groupCounts(operationStates),
+		CurrentWorks:    groupCounts(works),
+	}
+	codes := make([]int, 0, len(branches))
+	for cd := range branches {
+		codes = append(codes, cd)
+	}
+	sort.Ints(codes)
+	for _, cd := range codes {
+		summary.Branches = append(summary.Branches, *branches[cd])
+	}
+	return summary, contacts
+}
+
+func groupCounts(counts map[string]int) []GroupCount {
+	groups := make([]GroupCount, 0, len(counts))
+	for key, count := range counts {
+		groups = append(groups, GroupCount{Key: key, Count: count})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	return groups
+}
+
+// LoadSummary returns the cached fleet summary with freshness classified as
+// of now, or nil when no snapshot has been summarized yet. Zero thresholds
+// keep the freshness classified when the summary was cached.
+func LoadSummary(store *storage.Storage, now time.Time, staleAfter, offlineAfter time.Duration) (*FleetSummary, error) {
+	var cached cachedSummary
+	if err := store.Get(summaryKey, &cached); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if staleAfter > 0 && offlineAfter > 0 {
+		cached.Freshness = countFreshness(cached.Contacts, now, staleAfter, offlineAfter)
+	}
+	return &cached.FleetSummary, nil
+}
+
+// SummaryCache keeps the latest state of every vehicle and caches the fleet
+// summary in the kv store whenever a snapshot arrives, so readers do not
+// recount the fleet on every request. Polled changes are merged into the
+// last full snapshot; a full snapshot of all branches replaces it.
+type SummaryCache struct {
+	staleAfter   time.Duration
+	offlineAfter time.Duration
+	storage      *storage.Storage
+
+	mu       sync.Mutex
+	vehicles map[int64]browser.VehicleData
+}
+
+// NewSummaryCache creates a cache that classifies freshness with the same
+// thresholds as the HealthMonitor.
+func NewSummaryCache(staleAfter, offlineAfter time.Duration, store *storage.Storage) *SummaryCache {
+	return &SummaryCache{
+		staleAfter:   staleAfter,
+		offlineAfter: offlineAfter,
+		storage:      store,
+		vehicles:     make(map[int64]browser.VehicleData),
+	}
+}
+
+// Restore loads the latest stored record of every vehicle and caches their
+// summary, so one is available before the first scrape after a restart. The
+// summary is dated by the newest record, not by the restart.
+func (c *SummaryCache) Restore() error {
+	records, err := c.storage.ListFleetAt(time.Now(), time.Time{}, 0, 0)
+	if err != nil || len(records) == 0 {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var newest time.Time
+	for _, r := range records {
+		if _, ok := c.vehicles[r.VehicleCD]; !ok {
+			c.vehicles[r.VehicleCD] = StoredVehicle(r)
+		}
+		at := r.FetchedAt
+		if at.IsZero() {
+			at = r.DataTime
+		}
+		if at.After(newest) {
+			newest = at
+		}
+	}
+	return c.save(newest)
+}
+
+func (c *SummaryCache) Name() string {
+	return "summary"
+}
+
+func (c *SummaryCache) Write(_ context.Context, snapshot *browser.Snapshot) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !snapshot.Partial && snapshot.BranchID == browser.DefaultBranchID {
+		c.vehicles = make(map[int64]browser.VehicleData, len(snapshot.Vehicles))
+	}
+	for _, vd := range snapshot.Vehicles {
+		if vd.Telemetry == nil || vd.Telemetry.VehicleCD == 0 {
+			continue
+		}
+		c.vehicles[vd.Telemetry.VehicleCD] = vd
+	}
+	return c.save(snapshot.FetchedAt)
+}
+
+func (c *SummaryCache) save(now time.Time) error {
+	vehicles := make([]browser.VehicleData, 0, len(c.vehicles))
+	for _, vd := range c.vehicles {
+		vehicles = append(vehicles, vd)
+	}
+	summary, contacts := summarize(vehicles, now)
+	summary.Freshness = countFreshness(contacts, now, c.staleAfter, c.offlineAfter)
+	if err := c.storage.Set(summaryKey, cachedSummary{summary, contacts}); err != nil {
+		return fmt.Errorf("failed to save fleet summary: %w", err)
+	}
+	return nil
+}
+
+// StoredVehicle rebuilds a vehicle from a stored telemetry record. The
+// position is the stored WGS84 one.
+func StoredVehicle(r storage.TelemetryRecord) browser.VehicleData {
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(r.Record), &raw); err != nil {
+		raw = nil
+	}
+	t, _ := vehicle.Decode(raw)
+	if t.VehicleCD == 0 {
+		t.VehicleCD = r.VehicleCD
+	}
+	if t.BranchCD == 0 {
+		t.BranchCD = r.BranchCD
+	}
+
+	dataTime := r.DataTime
+	vd := browser.VehicleData{
+		VehicleID:   r.VehicleID,
+		VehicleCD:   strconv.FormatInt(r.VehicleCD, 10),
+		VehicleName: r.VehicleName,
+		Telemetry:   t,
+		Raw:         raw,
+		DataTime:    &dataTime,
+	}
+	if plate, ok := vehicle.ParsePlate(r.VehicleName); ok {
+		vd.Plate = plate
+	}
+	if r.Latitude != nil && r.Longitude != nil {
+		// Direction and fix quality come from the record, the coordinates
+		// were converted to WGS84 when stored
+		p := t.Position(vehicle.DatumWGS84)
+		if p == nil {
+			p = &vehicle.Position{}
+		}
+		p.Latitude, p.Longitude, p.Valid = *r.Latitude, *r.Longitude, r.GPSValid
+		vd.Position = p
+	}
+	if comu, err := t.ComuTime(); err == nil && !comu.IsZero() {
+		vd.ComuTime = &comu
+	}
+	if start, err := t.StartWorkTime(); err == nil && !start.IsZero() {
+		vd.StartWorkTime = &start
+	}
+	return vd
+}
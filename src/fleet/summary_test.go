@@ -0,0 +1,157 @@
+package fleet
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/browser"
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+func summaryVehicle(cd int64, branchCD int, branchName, state string, operationState int, work string, dataTime time.Time, gpsValid bool) browser.VehicleData {
+	return browser.VehicleData{
+		DataTime: &dataTime,
+		Position: &vehicle.Position{Latitude: 35, Longitude: 139, Valid: gpsValid},
+		Telemetry: &vehicle.Telemetry{
+			VehicleCD:       cd,
+			BranchCD:        branchCD,
+			BranchName:      branchName,
+			AllState:        state,
+			OperationState:  operationState,
+			CurrentWorkName: work,
+		},
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	now := time.Date(2025, 1, 2, 12, 0, 0, 0, vehicle.Tokyo)
+	vehicles := []browser.VehicleData{
+		summaryVehicle(1, 2, "大阪", "運行中", 1, "運転", now.Add(-time.Minute), true),
+		summaryVehicle(2, 1, "本社", "運行中", 1, "運転", now.Add(-time.Minute), false),
+		summaryVehicle(3, 1, "本社", "休憩中", 2, "休憩", now.Add(-time.Hour), true),
+		summaryVehicle(4, 1, "本社", "", 0, "", now.Add(-48*time.Hour), true),
+		{VehicleName: "undecoded"},
+	}
+
+	got := Summarize(vehicles, now, 30*time.Minute, 24*time.Hour)
+	if got.Total != 4 || !got.GeneratedAt.Equal(now) {
+		t.Errorf("Unexpected total or time: %d, %v", got.Total, got.GeneratedAt)
+	}
+
+	checks := []struct {
+		name string
+		got  []GroupCount
+		want []GroupCount
+	}{
+		{"branches", got.Branches, []GroupCount{{Key: "1", Name: "本社", Count: 3}, {Key: "2", Name: "大阪", Count: 1}}},
+		{"all states", got.AllStates, []GroupCount{{Key: "運行中", Count: 2}, {Key: "", Count: 1}, {Key: "休憩中", Count: 1}}},
+		{"operation states", got.OperationStates, []GroupCount{{Key: "1", Count: 2}, {Key: "0", Count: 1}, {Key: "2", Count: 1}}},
+		{"current works", got.CurrentWorks, []GroupCount{{Key: "運転", Count: 2}, {Key: "", Count: 1}, {Key: "休憩", Count: 1}}},
+		{"freshness", got.Freshness, []GroupCount{
+			{Key: HealthFresh, Count: 1},
+			{Key: HealthGPSInvalid, Count: 1},
+			{Key: HealthStale, Count: 1},
+			{Key: HealthOffline, Count: 1},
+		}},
+	}
+	for _, c := range checks {
+		if !reflect.DeepEqual(c.got, c.want) {
+			t.Errorf("%s: got %+v, want %+v", c.name, c.got, c.want)
+		}
+	}
+}
+
+func TestSummaryCache(t *testing.T) {
+	store := setupTestDB(t)
+	c := NewSummaryCache(30*time.Minute, 24*time.Hour, store)
+	now := time.Date(2025, 1, 2, 12, 0, 0, 0, vehicle.Tokyo)
+
+	if summary, err := LoadSummary(store, now, 30*time.Minute, 24*time.Hour); err != nil || summary != nil {
+		t.Fatalf("Expected no summary before the first snapshot, got %v (%v)", summary, err)
+	}
+
+	write := func(snapshot *browser.Snapshot) *FleetSummary {
+		t.Helper()
+		if err := c.Write(context.Background(), snapshot); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		summary, err := LoadSummary(store, snapshot.FetchedAt, 30*time.Minute, 24*time.Hour)
+		if err != nil || summary == nil {
+			t.Fatalf("Failed to load summary: %v", err)
+		}
+		return summary
+	}
+
+	full := write(&browser.Snapshot{BranchID: browser.DefaultBranchID, FetchedAt: now, Vehicles: []browser.VehicleData{
+		summaryVehicle(1, 1, "本社", "運行中", 1, "運転", now, true),
+		summaryVehicle(2, 1, "本社", "運行中", 1, "運転", now, true),
+	}})
+	if full.Total != 2 || full.AllStates[0].Count != 2 {
+		t.Fatalf("Unexpected summary after a full snapshot: %+v", full)
+	}
+
+	// A polled change only replaces the vehicle it holds
+	partial := write(&browser.Snapshot{Partial: true, FetchedAt: now.Add(time.Minute), Vehicles: []browser.VehicleData{
+		summaryVehicle(2, 1, "本社", "休憩中", 2, "休憩", now.Add(time.Minute), true),
+	}})
+	if partial.Total != 2 || len(partial.AllStates) != 2 || !partial.GeneratedAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("Unexpected summary after a partial snapshot: %+v", partial)
+	}
+
+	// A full snapshot of all branches drops vehicles it no longer holds
+	replaced := write(&browser.Snapshot{BranchID: browser.DefaultBranchID, FetchedAt: now.Add(2 * time.Minute), Vehicles: []browser.VehicleData{
+		summaryVehicle(1, 1, "本社", "運行中", 1, "運転", now.Add(2*time.Minute), true),
+	}})
+	if replaced.Total != 1 {
+		t.Errorf("Expected 1 vehicle after a full snapshot, got %d", replaced.Total)
+	}
+
+	// Freshness is classified when read, not when the snapshot was cached
+	later, err := LoadSummary(store, now.Add(3*time.Hour), 30*time.Minute, 24*time.Hour)
+	if err != nil || later == nil {
+		t.Fatalf("Failed to load summary: %v", err)
+	}
+	if later.Freshness[0].Count != 0 || later.Freshness[2].Key != HealthStale || later.Freshness[2].Count != 1 {
+		t.Errorf("Expected the vehicle to read as stale, got %+v", later.Freshness)
+	}
+	if !later.GeneratedAt.Equal(now.Add(2 * time.Minute)) {
+		t.Errorf("Expected the snapshot time to be kept, got %v", later.GeneratedAt)
+	}
+}
+
+func TestSummaryCache_Restore(t *testing.T) {
+	store := setupTestDB(t)
+	if err := NewSummaryCache(30*time.Minute, 24*time.Hour, store).Restore(); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if summary, err := LoadSummary(store, time.Now(), 30*time.Minute, 24*time.Hour); err != nil || summary != nil {
+		t.Fatalf("Expected no summary without stored telemetry, got %v (%v)", summary, err)
+	}
+
+	lat, lon := 35.0, 139.0
+	fetchedAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+	if err := store.SaveTelemetry([]storage.TelemetryRecord{{
+		VehicleCD: 7, DataTime: fetchedAt.Add(-time.Minute), FetchedAt: fetchedAt, VehicleName: "Truck", BranchCD: 3,
+		Latitude: &lat, Longitude: &lon, GPSValid: true,
+		Record: `{"VehicleCD":7,"BranchCD":3,"BranchName":"横浜","AllState":"運行中","ComuDateTime":"2025/01/02 12:00"}`,
+	}}); err != nil {
+		t.Fatalf("Failed to save telemetry: %v", err)
+	}
+
+	if err := NewSummaryCache(30*time.Minute, 24*time.Hour, store).Restore(); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	summary, err := LoadSummary(store, time.Now(), 30*time.Minute, 24*time.Hour)
+	if err != nil || summary == nil {
+		t.Fatalf("Expected a summary after restoring, got %v (%v)", summary, err)
+	}
+	if summary.Total != 1 || summary.Branches[0].Name != "横浜" || summary.Freshness[0].Count != 1 {
+		t.Errorf("Unexpected restored summary: %+v", summary)
+	}
+	if !summary.GeneratedAt.Equal(fetchedAt) {
+		t.Errorf("Expected the summary to be dated by the stored record, got %v", summary.GeneratedAt)
+	}
+}
@@ -0,0 +1,265 @@
+package fleet
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/browser"
+	"github.com/yhonda-ohishi/browser_render_go/src/notify"
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+// Alert kinds sent by the TemperatureMonitor.
+const (
+	AlertTemperatureExcursion = "temperature_excursion"
+	AlertTemperatureRecovered = "temperature_recovered"
+	AlertTemperatureNoReading = "temperature_no_reading"
+)
+
+type compartmentKey struct {
+	vehicleCD   int64
+	compartment int
+}
+
+// tempTrack follows one compartment while it is out of range. excursion is
+// nil until the deviation has lasted long enough to be recorded.
+type tempTrack struct {
+	since     time.Time
+	last      time.Time
+	excursion *storage.TemperatureExcursion
+	minTemp   float64
+	maxTemp   float64
+	lastTemp  float64
+	maxDev    float64
+	samples   int
+}
+
+// TemperatureMonitor flags compartments whose temperature deviates from the
+// set point by more than tolerance for at least minDuration. Each excursion
+// is stored when it opens, updated while it lasts and closed when the
+// temperature is back in range, or when the compartment stops reporting or
+// its unit is switched off; opening and closing are notified.
+type TemperatureMonitor struct {
+	tolerance   float64
+	minDuration time.Duration
+	offStates   []int
+	storage     *storage.Storage
+	notifier    notify.Notifier
+
+	mu     sync.Mutex
+	tracks map[compartmentKey]*tempTrack
+}
+
+// NewTemperatureMonitor creates a monitor. tolerance is in degrees Celsius;
+// compartments of vehicles whose TempState is one of offStates are not
+// checked.
+func NewTemperatureMonitor(tolerance float64, minDuration time.Duration, offStates []int, store *storage.Storage, notifier notify.Notifier) *TemperatureMonitor {
+	return &TemperatureMonitor{
+		tolerance:   tolerance,
+		minDuration: minDuration,
+		offStates:   offStates,
+		storage:     store,
+		notifier:    notifier,
+		tracks:      make(map[compartmentKey]*tempTrack),
+	}
+}
+
+// Restore picks up the excursions that were open when the service stopped,
+// so they are closed rather than opened a second time.
+func (m *TemperatureMonitor) Restore() error {
+	open, err := m.storage.ListExcursions(storage.ExcursionFilter{OpenOnly: true})
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range open {
+		e := &open[i]
+		m.tracks[compartmentKey{e.VehicleCD, e.Compartment}] = &tempTrack{
+			since:     e.StartedAt,
+			last:      e.StartedAt,
+			excursion: e,
+			minTemp:   e.MinTemp,
+			maxTemp:   e.MaxTemp,
+			lastTemp:  e.LastTemp,
+			maxDev:    e.MaxDeviation,
+			samples:   e.Samples,
+		}
+	}
+	return nil
+}
+
+func (m *TemperatureMonitor) Name() string {
+	return "temperature"
+}
+
+func (m *TemperatureMonitor) Write(ctx context.Context, snapshot *browser.Snapshot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var alerts []notify.Alert
+	for _, vd := range snapshot.Vehicles {
+		if vd.Telemetry == nil || vd.Telemetry.VehicleCD == 0 {
+			continue
+		}
+		at := snapshot.FetchedAt
+		if vd.DataTime != nil {
+			at = *vd.DataTime
+		}
+
+		reporting := make(map[int]bool)
+		for _, c := range vd.Telemetry.ActiveCompartments(m.offStates) {
+			reporting[c.Index] = true
+			alert, err := m.observe(vd, c.Index, c.Temp, c.SetPoint, at)
+			if err != nil {
+				return fmt.Errorf("failed to save excursion: %w", err)
+			}
+			if alert != nil {
+				alerts = append(alerts, *alert)
+			}
+		}
+
+		// A compartment missing from the record, or with its unit off, has no
+		// reading that could ever bring it back in range
+		for key, track := range m.tracks {
+			if key.vehicleCD != vd.Telemetry.VehicleCD || reporting[key.compartment] || at.Before(track.last) {
+				continue
+			}
+			alert, err := m.expire(vd, key, track, at)
+			if err != nil {
+				return fmt.Errorf("failed to save excursion: %w", err)
+			}
+			if alert != nil {
+				alerts = append(alerts, *alert)
+			}
+		}
+	}
+
+	for _, alert := range alerts {
+		if err := m.notifier.Notify(ctx, alert); err != nil {
+			log.Printf("Warning: failed to send temperature alert: %v", err)
+		}
+	}
+	return nil
+}
+
+// expire drops the track of a compartment without a reading and closes its
+// excursion at the time of the record that no longer has it.
+func (m *TemperatureMonitor) expire(vd browser.VehicleData, key compartmentKey, track *tempTrack, at time.Time) (*notify.Alert, error) {
+	delete(m.tracks, key)
+	if track.excursion == nil {
+		return nil, nil
+	}
+
+	e := track.excursion
+	e.EndedAt = &at
+	if err := m.storage.SaveExcursion(e); err != nil {
+		return nil, err
+	}
+	return &notify.Alert{
+		Kind:        AlertTemperatureNoReading,
+		Severity:    notify.SeverityInfo,
+		Title:       fmt.Sprintf("%s compartment %d no longer reporting", vd.VehicleName, key.compartment),
+		Message:     fmt.Sprintf("Excursion closed without a reading after %s out of range, last %.1f°C", at.Sub(e.StartedAt).Round(time.Minute), e.LastTemp),
+		VehicleCD:   e.VehicleCD,
+		VehicleName: vd.VehicleName,
+		Time:        at,
+		Detail:      map[string]interface{}{"excursion_id": e.ID, "compartment": key.compartment},
+	}, nil
+}
+
+// observe applies one reading and returns the alert to send, if any.
+func (m *TemperatureMonitor) observe(vd browser.VehicleData, compartment int, temp, setPoint float64, at time.Time) (*notify.Alert, error) {
+	key := compartmentKey{vd.Telemetry.VehicleCD, compartment}
+	track := m.tracks[key]
+	if track != nil && at.Before(track.last) {
+		return nil, nil
+	}
+
+	dev := math.Abs(temp - setPoint)
+	if dev <= m.tolerance {
+		if track == nil {
+			return nil, nil
+		}
+		delete(m.tracks, key)
+		if track.excursion == nil {
+			return nil, nil
+		}
+
+		e := track.excursion
+		e.EndedAt = &at
+		if err := m.storage.SaveExcursion(e); err != nil {
+			return nil, err
+		}
+		return &notify.Alert{
+			Kind:        AlertTemperatureRecovered,
+			Severity:    notify.SeverityInfo,
+			Title:       fmt.Sprintf("%s compartment %d back in range", vd.VehicleName, compartment),
+			Message:     fmt.Sprintf("%.1f°C (set %.1f°C) after %s out of range", temp, setPoint, at.Sub(e.StartedAt).Round(time.Minute)),
+			VehicleCD:   e.VehicleCD,
+			VehicleName: vd.VehicleName,
+			Time:        at,
+			Detail:      map[string]interface{}{"excursion_id": e.ID, "compartment": compartment},
+		}, nil
+	}
+
+	if track == nil {
+		track = &tempTrack{since: at, minTemp: temp, maxTemp: temp}
+		m.tracks[key] = track
+	}
+	track.last = at
+	track.lastTemp = temp
+	track.minTemp = math.Min(track.minTemp, temp)
+	track.maxTemp = math.Max(track.maxTemp, temp)
+	track.maxDev = math.Max(track.maxDev, dev)
+	track.samples++
+
+	if track.excursion == nil && at.Sub(track.since) < m.minDuration {
+		return nil, nil
+	}
+
+	opened := track.excursion == nil
+	if opened {
+		track.excursion = &storage.TemperatureExcursion{
+			VehicleCD:   vd.Telemetry.VehicleCD,
+			VehicleID:   vd.VehicleID,
+			Compartment: compartment,
+			SetPoint:    setPoint,
+			Tolerance:   m.tolerance,
+			StartedAt:   track.since,
+		}
+	}
+	e := track.excursion
+	e.VehicleName = vd.VehicleName
+	e.MinTemp, e.MaxTemp, e.LastTemp = track.minTemp, track.maxTemp, track.lastTemp
+	e.MaxDeviation = math.Round(track.maxDev*10) / 10
+	e.Samples = track.samples
+	if err := m.storage.SaveExcursion(e); err != nil {
+		return nil, err
+	}
+	if !opened {
+		return nil, nil
+	}
+
+	return &notify.Alert{
+		Kind:        AlertTemperatureExcursion,
+		Severity:    notify.SeverityWarning,
+		Title:       fmt.Sprintf("%s compartment %d out of range", vd.VehicleName, compartment),
+		Message:     fmt.Sprintf("%.1f°C against set point %.1f°C (±%.1f°C) since %s", temp, setPoint, m.tolerance, e.StartedAt.In(vehicle.Tokyo).Format("2006/01/02 15:04")),
+		VehicleCD:   e.VehicleCD,
+		VehicleName: vd.VehicleName,
+		Time:        at,
+		Detail: map[string]interface{}{
+			"excursion_id": e.ID,
+			"compartment":  compartment,
+			"temp":         temp,
+			"set_point":    setPoint,
+		},
+	}, nil
+}
@@ -0,0 +1,137 @@
+package fleet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/browser"
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+func tempSnapshot(at time.Time, temp1 string) *browser.Snapshot {
+	return &browser.Snapshot{
+		FetchedAt: at,
+		Vehicles: []browser.VehicleData{{
+			VehicleName: "Reefer",
+			DataTime:    &at,
+			Telemetry:   &vehicle.Telemetry{VehicleCD: 7, Temp1: temp1, SettingTemp1: "-20", TempState: 1},
+		}},
+	}
+}
+
+func TestTemperatureMonitor(t *testing.T) {
+	store := setupTestDB(t)
+	notifier := &recordingNotifier{}
+	m := NewTemperatureMonitor(3, 10*time.Minute, []int{0}, store, notifier)
+	ctx := context.Background()
+	base := time.Date(2025, 1, 2, 9, 0, 0, 0, vehicle.Tokyo)
+
+	readings := []struct {
+		offset time.Duration
+		temp   string
+	}{
+		{0, "-19"},                // in range
+		{5 * time.Minute, "-15"},  // out of range, pending
+		{10 * time.Minute, "-20"}, // back in range before min duration: nothing recorded
+		{20 * time.Minute, "-16"}, // out of range again
+		{25 * time.Minute, "-12"},
+		{31 * time.Minute, "-14"}, // 11 minutes out: excursion opens
+		{40 * time.Minute, "-18"}, // in range: excursion closes
+	}
+	for _, r := range readings {
+		if err := m.Write(ctx, tempSnapshot(base.Add(r.offset), r.temp)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	if kinds := notifier.kinds(); len(kinds) != 2 || kinds[0] != AlertTemperatureExcursion || kinds[1] != AlertTemperatureRecovered {
+		t.Fatalf("Expected an excursion and a recovery alert, got %v", kinds)
+	}
+
+	excursions, err := store.ListExcursions(storage.ExcursionFilter{})
+	if err != nil || len(excursions) != 1 {
+		t.Fatalf("Expected 1 excursion, got %d (%v)", len(excursions), err)
+	}
+	e := excursions[0]
+	if !e.StartedAt.Equal(base.Add(20*time.Minute)) || e.EndedAt == nil || !e.EndedAt.Equal(base.Add(40*time.Minute)) {
+		t.Errorf("Unexpected excursion period: %v - %v", e.StartedAt, e.EndedAt)
+	}
+	if e.MaxTemp != -12 || e.MinTemp != -16 || e.MaxDeviation != 8 || e.Samples != 3 {
+		t.Errorf("Unexpected excursion stats: %+v", e)
+	}
+}
+
+func TestTemperatureMonitor_Restore(t *testing.T) {
+	store := setupTestDB(t)
+	base := time.Date(2025, 1, 2, 9, 0, 0, 0, vehicle.Tokyo)
+	if err := store.SaveExcursion(&storage.TemperatureExcursion{
+		VehicleCD: 7, Compartment: 1, SetPoint: -20, Tolerance: 3, StartedAt: base,
+		MinTemp: -15, MaxTemp: -15, LastTemp: -15, MaxDeviation: 5, Samples: 2,
+	}); err != nil {
+		t.Fatalf("Failed to save excursion: %v", err)
+	}
+
+	notifier := &recordingNotifier{}
+	m := NewTemperatureMonitor(3, 10*time.Minute, []int{0}, store, notifier)
+	if err := m.Restore(); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+
+	// Still out of range: the open excursion continues without a new alert
+	m.Write(context.Background(), tempSnapshot(base.Add(time.Hour), "-14"))
+	if len(notifier.kinds()) != 0 {
+		t.Errorf("Expected no alert for a continuing excursion, got %v", notifier.kinds())
+	}
+	open, _ := store.ListExcursions(storage.ExcursionFilter{OpenOnly: true})
+	if len(open) != 1 || open[0].Samples != 3 {
+		t.Fatalf("Expected the restored excursion to be updated, got %+v", open)
+	}
+
+	m.Write(context.Background(), tempSnapshot(base.Add(2*time.Hour), "-20"))
+	open, _ = store.ListExcursions(storage.ExcursionFilter{OpenOnly: true})
+	if len(open) != 0 || len(notifier.kinds()) != 1 {
+		t.Errorf("Expected the restored excursion to close, got %d open and alerts %v", len(open), notifier.kinds())
+	}
+}
+
+func TestTemperatureMonitor_NoReading(t *testing.T) {
+	base := time.Date(2025, 1, 2, 9, 0, 0, 0, vehicle.Tokyo)
+	tests := []struct {
+		name   string
+		update func(*vehicle.Telemetry)
+	}{
+		{"compartment stops reporting", func(tm *vehicle.Telemetry) { tm.Temp1 = "" }},
+		{"unit switched off", func(tm *vehicle.Telemetry) { tm.TempState = 0 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := setupTestDB(t)
+			notifier := &recordingNotifier{}
+			m := NewTemperatureMonitor(3, 10*time.Minute, []int{0}, store, notifier)
+			ctx := context.Background()
+
+			m.Write(ctx, tempSnapshot(base, "-12"))
+			m.Write(ctx, tempSnapshot(base.Add(15*time.Minute), "-12")) // excursion opens
+
+			snapshot := tempSnapshot(base.Add(30*time.Minute), "-12")
+			tt.update(snapshot.Vehicles[0].Telemetry)
+			if err := m.Write(ctx, snapshot); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+
+			if kinds := notifier.kinds(); len(kinds) != 2 || kinds[1] != AlertTemperatureNoReading {
+				t.Fatalf("Expected the excursion to close without a reading, got %v", kinds)
+			}
+			excursions, _ := store.ListExcursions(storage.ExcursionFilter{})
+			if len(excursions) != 1 || excursions[0].EndedAt == nil || !excursions[0].EndedAt.Equal(base.Add(30*time.Minute)) {
+				t.Errorf("Expected the excursion to end with the record, got %+v", excursions)
+			}
+			if len(m.tracks) != 0 {
+				t.Errorf("Expected the track to be dropped, got %v", m.tracks)
+			}
+		})
+	}
+}
@@ -0,0 +1,353 @@
+package fleet
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/browser"
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+// tripSample is the part of a vehicle record the TripBuilder uses.
+type tripSample struct {
+	at          time.Time
+	speed       float64
+	hasPosition bool
+	latitude    float64
+	longitude   float64
+	hasOdometer bool
+	odometer    float64
+	startWork   string
+	driverCD    int
+}
+
+// tripState is a vehicle's open segment and what the builder needs to extend
+// it.
+type tripState struct {
+	segment    *storage.Trip
+	last       tripSample
+	gpsKm      float64   // GPS distance of the open trip
+	stillSince time.Time // start of the current standstill within a trip
+	stillAt    tripSample
+}
+
+// TripBuilder segments each vehicle's timeline into trips and stops. A trip
+// starts when the speed reaches movingSpeed and ends once the vehicle has
+// stood still for stopDuration or work has ended; shorter standstills count
+// as idle time within the trip. Segments are stored as they change, so the
+// open ones can be picked up again after a restart.
+type TripBuilder struct {
+	movingSpeed  float64 // km/h
+	stopDuration time.Duration
+	storage      *storage.Storage
+
+	mu     sync.Mutex
+	states map[int64]*tripState
+}
+
+// NewTripBuilder creates a trip builder.
+func NewTripBuilder(movingSpeed float64, stopDuration time.Duration, store *storage.Storage) *TripBuilder {
+	return &TripBuilder{
+		movingSpeed:  movingSpeed,
+		stopDuration: stopDuration,
+		storage:      store,
+		states:       make(map[int64]*tripState),
+	}
+}
+
+// Restore picks up the segments that were open when the service stopped.
+func (b *TripBuilder) Restore() error {
+	open, err := b.storage.ListTrips(storage.TripFilter{OpenOnly: true})
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i := range open {
+		seg := &open[i]
+		last := tripSample{at: seg.EndTime, driverCD: seg.DriverCD}
+		if seg.EndLatitude != nil && seg.EndLongitude != nil {
+			last.hasPosition, last.latitude, last.longitude = true, *seg.EndLatitude, *seg.EndLongitude
+		}
+		if seg.EndOdometer != nil {
+			last.hasOdometer, last.odometer = true, *seg.EndOdometer
+		}
+		if seg.Kind == storage.SegmentTrip {
+			last.speed = b.movingSpeed
+		}
+		st := &tripState{segment: seg, last: last}
+		if seg.DistanceSource == "gps" {
+			st.gpsKm = seg.DistanceKm
+		}
+		b.states[seg.VehicleCD] = st
+	}
+	return nil
+}
+
+func (b *TripBuilder) Name() string {
+	return "trips"
+}
+
+func (b *TripBuilder) Write(_ context.Context, snapshot *browser.Snapshot) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, vd := range snapshot.Vehicles {
+		if vd.Telemetry == nil || vd.Telemetry.VehicleCD == 0 {
+			continue
+		}
+		if err := b.observe(vd, sampleOf(vd, snapshot.FetchedAt)); err != nil {
+			return fmt.Errorf("failed to save trip: %w", err)
+		}
+	}
+	return nil
+}
+
+func sampleOf(vd browser.VehicleData, fetchedAt time.Time) tripSample {
+	s := tripSample{
+		at:        fetchedAt,
+		speed:     vd.Telemetry.Speed,
+		startWork: vd.Telemetry.StartWorkDateTime,
+		driverCD:  vd.Telemetry.DriverCD,
+	}
+	if vd.DataTime != nil {
+		s.at = *vd.DataTime
+	}
+	if p := vd.Position; p != nil && p.Valid {
+		s.hasPosition, s.latitude, s.longitude = true, p.Latitude, p.Longitude
+	}
+	s.odometer, s.hasOdometer = vd.Telemetry.Odometer()
+	return s
+}
+
+func (b *TripBuilder) observe(vd browser.VehicleData, s tripSample) error {
+	cd := vd.Telemetry.VehicleCD
+	moving := s.speed >= b.movingSpeed
+
+	st := b.states[cd]
+	if st == nil {
+		kind := storage.SegmentStop
+		if moving {
+			kind = storage.SegmentTrip
+		}
+		st = &tripState{last: s}
+		b.states[cd] = st
+		return b.open(st, vd, kind, s)
+	}
+	if !s.at.After(st.last.at) {
+		return nil
+	}
+	defer func() { st.last = s }()
+
+	seg := st.segment
+	seg.VehicleName = vd.VehicleName
+
+	if seg.Kind == storage.SegmentStop {
+		if !moving {
+			b.extend(st, s)
+			return b.storage.SaveTrip(seg)
+		}
+		// The vehicle left somewhere between the last standstill sample and
+		// now; the stop ends and the trip starts at the last standstill.
+		if err := b.close(st, st.last); err != nil {
+			return err
+		}
+		if err := b.open(st, vd, storage.SegmentTrip, st.last); err != nil {
+			return err
+		}
+		b.extend(st, s)
+		return b.storage.SaveTrip(st.segment)
+	}
+
+	workEnded := s.startWork == "" && st.last.startWork != ""
+	switch {
+	case moving:
+		if !st.stillSince.IsZero() {
+			seg.IdleSeconds += int64(s.at.Sub(st.stillSince).Seconds())
+			st.stillSince = time.Time{}
+		}
+	case st.stillSince.IsZero():
+		st.stillSince, st.stillAt = s.at, s
+	}
+	b.extend(st, s)
+
+	switch {
+	case !st.stillSince.IsZero() && s.at.Sub(st.stillSince) >= b.stopDuration:
+		// Long enough to be a stop: the trip ended when the vehicle stopped
+		stopAt := st.stillAt
+		st.stillSince = time.Time{}
+		if err := b.close(st, stopAt); err != nil {
+			return err
+		}
+		if err := b.open(st, vd, storage.SegmentStop, stopAt); err != nil {
+			return err
+		}
+		b.extend(st, s)
+		return b.storage.SaveTrip(st.segment)
+
+	case workEnded:
+		if !st.stillSince.IsZero() {
+			seg.IdleSeconds += int64(s.at.Sub(st.stillSince).Seconds())
+			st.stillSince = time.Time{}
+		}
+		if err := b.close(st, s); err != nil {
+			return err
+		}
+		return b.open(st, vd, storage.SegmentStop, s)
+	}
+	return b.storage.SaveTrip(seg)
+}
+
+// open starts a new segment at sample s.
+func (b *TripBuilder) open(st *tripState, vd browser.VehicleData, kind string, s tripSample) error {
+	seg := &storage.Trip{
+		VehicleCD:   vd.Telemetry.VehicleCD,
+		VehicleID:   vd.VehicleID,
+		VehicleName: vd.VehicleName,
+		Kind:        kind,
+		StartTime:   s.at,
+		EndTime:     s.at,
+		DriverCD:    s.driverCD,
+	}
+	if s.hasPosition {
+		lat, lon := s.latitude, s.longitude
+		seg.StartLatitude, seg.StartLongitude = &lat, &lon
+		seg.EndLatitude, seg.EndLongitude = &lat, &lon
+	}
+	if s.hasOdometer {
+		odo := s.odometer
+		seg.StartOdometer, seg.EndOdometer = &odo, &odo
+	}
+	if kind == storage.SegmentTrip {
+		seg.DistanceSource = "gps"
+		seg.MaxSpeed = s.speed
+	}
+	st.segment = seg
+	st.gpsKm = 0
+	st.stillSince = time.Time{}
+	return b.storage.SaveTrip(seg)
+}
+
+// extend moves the open segment's end to sample s.
+func (b *TripBuilder) extend(st *tripState, s tripSample) {
+	seg := st.segment
+	if seg.Kind == storage.SegmentTrip {
+		if s.hasPosition && st.last.hasPosition {
+			st.gpsKm += vehicle.Distance(st.last.latitude, st.last.longitude, s.latitude, s.longitude) / 1000
+		}
+		seg.MaxSpeed = math.Max(seg.MaxSpeed, s.speed)
+	}
+	b.endAt(st, s)
+}
+
+// endAt sets the segment's end fields to sample s and recomputes the
+// distance, preferring the odometer when it gives a usable reading.
+func (b *TripBuilder) endAt(st *tripState, s tripSample) {
+	seg := st.segment
+	seg.EndTime = s.at
+	if s.hasPosition {
+		lat, lon := s.latitude, s.longitude
+		seg.EndLatitude, seg.EndLongitude = &lat, &lon
+	}
+	if s.hasOdometer {
+		odo := s.odometer
+		seg.EndOdometer = &odo
+	}
+	if s.driverCD != 0 {
+		seg.DriverCD = s.driverCD
+	}
+
+	if seg.Kind != storage.SegmentTrip {
+		return
+	}
+	if seg.StartOdometer != nil && seg.EndOdometer != nil && *seg.EndOdometer >= *seg.StartOdometer {
+		seg.DistanceKm = round(*seg.EndOdometer-*seg.StartOdometer, 1)
+		seg.DistanceSource = "odometer"
+	} else {
+		seg.DistanceKm = round(st.gpsKm, 1)
+		seg.DistanceSource = "gps"
+	}
+}
+
+// close ends the open segment at sample s.
+func (b *TripBuilder) close(st *tripState, s tripSample) error {
+	b.endAt(st, s)
+	st.segment.Closed = true
+	return b.storage.SaveTrip(st.segment)
+}
+
+func round(v float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(v*scale) / scale
+}
+
+// TripSummary totals one vehicle's segments for one day.
+type TripSummary struct {
+	Date           string    `json:"date"` // YYYY-MM-DD in Asia/Tokyo
+	VehicleCD      int64     `json:"vehicle_cd"`
+	VehicleName    string    `json:"vehicle_name"`
+	Trips          int       `json:"trips"`
+	Stops          int       `json:"stops"`
+	DistanceKm     float64   `json:"distance_km"`
+	DrivingSeconds int64     `json:"driving_seconds"` // trip time excluding idle
+	IdleSeconds    int64     `json:"idle_seconds"`
+	StopSeconds    int64     `json:"stop_seconds"`
+	FirstStart     time.Time `json:"first_start"`
+	LastEnd        time.Time `json:"last_end"`
+}
+
+// SummarizeTrips totals segments per vehicle and day. Segments count towards
+// the day they start on in Asia/Tokyo. The result is ordered by date and
+// vehicle.
+func SummarizeTrips(trips []storage.Trip) []TripSummary {
+	type key struct {
+		date      string
+		vehicleCD int64
+	}
+	index := make(map[key]int)
+	var summaries []TripSummary
+
+	for i := range trips {
+		t := &trips[i]
+		k := key{t.StartTime.In(vehicle.Tokyo).Format("2006-01-02"), t.VehicleCD}
+		n, ok := index[k]
+		if !ok {
+			n = len(summaries)
+			index[k] = n
+			summaries = append(summaries, TripSummary{Date: k.date, VehicleCD: t.VehicleCD, FirstStart: t.StartTime, LastEnd: t.EndTime})
+		}
+		sum := &summaries[n]
+		sum.VehicleName = t.VehicleName
+
+		seconds := int64(t.Duration().Seconds())
+		if t.Kind == storage.SegmentTrip {
+			sum.Trips++
+			sum.DistanceKm = round(sum.DistanceKm+t.DistanceKm, 1)
+			sum.DrivingSeconds += seconds - t.IdleSeconds
+			sum.IdleSeconds += t.IdleSeconds
+		} else {
+			sum.Stops++
+			sum.StopSeconds += seconds
+		}
+		if t.StartTime.Before(sum.FirstStart) {
+			sum.FirstStart = t.StartTime
+		}
+		if t.EndTime.After(sum.LastEnd) {
+			sum.LastEnd = t.EndTime
+		}
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Date != summaries[j].Date {
+			return summaries[i].Date < summaries[j].Date
+		}
+		return summaries[i].VehicleCD < summaries[j].VehicleCD
+	})
+	return summaries
+}
@@ -0,0 +1,179 @@
+package fleet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/browser"
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+type tripReading struct {
+	offset    time.Duration
+	speed     float64
+	lat       float64
+	odometer  string
+	startWork string
+}
+
+func writeTripReadings(t *testing.T, b *TripBuilder, base time.Time, readings []tripReading) {
+	t.Helper()
+	for _, r := range readings {
+		at := base.Add(r.offset)
+		snapshot := &browser.Snapshot{
+			FetchedAt: at,
+			Vehicles: []browser.VehicleData{{
+				VehicleName: "Truck",
+				DataTime:    &at,
+				Position:    &vehicle.Position{Latitude: r.lat, Longitude: 139, Valid: true},
+				Telemetry: &vehicle.Telemetry{
+					VehicleCD:         7,
+					Speed:             r.speed,
+					ODOMeter:          r.odometer,
+					StartWorkDateTime: r.startWork,
+				},
+			}},
+		}
+		if err := b.Write(context.Background(), snapshot); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+}
+
+func TestTripBuilder(t *testing.T) {
+	store := setupTestDB(t)
+	b := NewTripBuilder(5, 10*time.Minute, store)
+	base := time.Date(2025, 1, 2, 9, 0, 0, 0, vehicle.Tokyo)
+
+	writeTripReadings(t, b, base, []tripReading{
+		{0, 0, 35.00, "", "x"},
+		{5 * time.Minute, 0, 35.00, "", "x"},
+		{10 * time.Minute, 40, 35.01, "", "x"}, // leaves: trip starts at 09:05
+		{20 * time.Minute, 0, 35.02, "", "x"},  // short standstill
+		{25 * time.Minute, 30, 35.02, "", "x"}, // moving again: 5 minutes idle
+		{40 * time.Minute, 0, 35.03, "", "x"},
+		{55 * time.Minute, 0, 35.03, "", "x"}, // 15 minutes still: trip ended at 09:40
+	})
+
+	trips, err := store.ListTrips(storage.TripFilter{})
+	if err != nil {
+		t.Fatalf("Failed to list trips: %v", err)
+	}
+	if len(trips) != 3 {
+		t.Fatalf("Expected stop, trip and stop, got %d segments", len(trips))
+	}
+
+	tests := []struct {
+		kind   string
+		start  time.Duration
+		end    time.Duration
+		closed bool
+	}{
+		{storage.SegmentStop, 0, 5 * time.Minute, true},
+		{storage.SegmentTrip, 5 * time.Minute, 40 * time.Minute, true},
+		{storage.SegmentStop, 40 * time.Minute, 55 * time.Minute, false},
+	}
+	for i, tt := range tests {
+		got := trips[i]
+		if got.Kind != tt.kind || !got.StartTime.Equal(base.Add(tt.start)) || !got.EndTime.Equal(base.Add(tt.end)) || got.Closed != tt.closed {
+			t.Errorf("Segment %d: expected %s %v-%v closed=%v, got %s %v-%v closed=%v",
+				i, tt.kind, tt.start, tt.end, tt.closed, got.Kind, got.StartTime.Sub(base), got.EndTime.Sub(base), got.Closed)
+		}
+	}
+
+	trip := trips[1]
+	if trip.IdleSeconds != 300 {
+		t.Errorf("Expected 300 idle seconds, got %d", trip.IdleSeconds)
+	}
+	if trip.DistanceSource != "gps" || trip.DistanceKm != 3.3 {
+		t.Errorf("Expected 3.3 km by gps, got %v km by %s", trip.DistanceKm, trip.DistanceSource)
+	}
+	if trip.MaxSpeed != 40 {
+		t.Errorf("Expected max speed 40, got %v", trip.MaxSpeed)
+	}
+	if trip.StartLatitude == nil || *trip.StartLatitude != 35.00 || trip.EndLatitude == nil || *trip.EndLatitude != 35.03 {
+		t.Errorf("Unexpected trip locations: %v, %v", trip.StartLatitude, trip.EndLatitude)
+	}
+}
+
+func TestTripBuilder_Odometer(t *testing.T) {
+	store := setupTestDB(t)
+	b := NewTripBuilder(5, 10*time.Minute, store)
+	base := time.Date(2025, 1, 2, 9, 0, 0, 0, vehicle.Tokyo)
+
+	writeTripReadings(t, b, base, []tripReading{
+		{0, 30, 35.00, "10,000.0", "x"},
+		{10 * time.Minute, 50, 35.01, "10,004.5", "x"},
+		{20 * time.Minute, 0, 35.01, "10,006.2", ""}, // work ended: the trip closes
+	})
+
+	trips, err := store.ListTrips(storage.TripFilter{})
+	if err != nil || len(trips) != 2 {
+		t.Fatalf("Expected a trip and a stop, got %d (%v)", len(trips), err)
+	}
+	trip := trips[0]
+	if !trip.Closed || !trip.EndTime.Equal(base.Add(20*time.Minute)) {
+		t.Errorf("Expected the trip to close at work end, got %+v", trip)
+	}
+	if trip.DistanceSource != "odometer" || trip.DistanceKm != 6.2 {
+		t.Errorf("Expected 6.2 km by odometer, got %v km by %s", trip.DistanceKm, trip.DistanceSource)
+	}
+	if trips[1].Kind != storage.SegmentStop || trips[1].Closed {
+		t.Errorf("Expected an open stop, got %+v", trips[1])
+	}
+}
+
+func TestTripBuilder_Restore(t *testing.T) {
+	store := setupTestDB(t)
+	base := time.Date(2025, 1, 2, 9, 0, 0, 0, vehicle.Tokyo)
+	writeTripReadings(t, NewTripBuilder(5, 10*time.Minute, store), base, []tripReading{
+		{0, 30, 35.00, "", "x"},
+		{10 * time.Minute, 30, 35.01, "", "x"},
+	})
+
+	b := NewTripBuilder(5, 10*time.Minute, store)
+	if err := b.Restore(); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	writeTripReadings(t, b, base, []tripReading{{20 * time.Minute, 30, 35.02, "", "x"}})
+
+	trips, err := store.ListTrips(storage.TripFilter{})
+	if err != nil || len(trips) != 1 {
+		t.Fatalf("Expected the restored trip to continue, got %d (%v)", len(trips), err)
+	}
+	if !trips[0].EndTime.Equal(base.Add(20*time.Minute)) || trips[0].DistanceKm != 2.2 {
+		t.Errorf("Unexpected restored trip: %+v", trips[0])
+	}
+}
+
+func TestSummarizeTrips(t *testing.T) {
+	day := time.Date(2025, 1, 2, 0, 0, 0, 0, vehicle.Tokyo)
+	trips := []storage.Trip{
+		{VehicleCD: 2, Kind: storage.SegmentTrip, StartTime: day.Add(9 * time.Hour), EndTime: day.Add(10 * time.Hour), DistanceKm: 30.5, IdleSeconds: 600},
+		{VehicleCD: 1, Kind: storage.SegmentTrip, StartTime: day.Add(8 * time.Hour), EndTime: day.Add(9 * time.Hour), DistanceKm: 20},
+		{VehicleCD: 1, Kind: storage.SegmentStop, StartTime: day.Add(9 * time.Hour), EndTime: day.Add(10 * time.Hour)},
+		{VehicleCD: 1, Kind: storage.SegmentTrip, StartTime: day.Add(10 * time.Hour), EndTime: day.Add(11 * time.Hour), DistanceKm: 15.3, IdleSeconds: 60},
+		{VehicleCD: 1, Kind: storage.SegmentTrip, StartTime: day.Add(24 * time.Hour), EndTime: day.Add(25 * time.Hour), DistanceKm: 5},
+	}
+
+	summaries := SummarizeTrips(trips)
+	if len(summaries) != 3 {
+		t.Fatalf("Expected 3 summaries, got %d", len(summaries))
+	}
+
+	got := summaries[0]
+	if got.Date != "2025-01-02" || got.VehicleCD != 1 {
+		t.Fatalf("Expected vehicle 1 on 2025-01-02 first, got %s %d", got.Date, got.VehicleCD)
+	}
+	if got.Trips != 2 || got.Stops != 1 || got.DistanceKm != 35.3 || got.DrivingSeconds != 7140 || got.IdleSeconds != 60 || got.StopSeconds != 3600 {
+		t.Errorf("Unexpected summary: %+v", got)
+	}
+	if !got.FirstStart.Equal(day.Add(8*time.Hour)) || !got.LastEnd.Equal(day.Add(11*time.Hour)) {
+		t.Errorf("Unexpected summary period: %v - %v", got.FirstStart, got.LastEnd)
+	}
+	if summaries[1].VehicleCD != 2 || summaries[2].Date != "2025-01-03" {
+		t.Errorf("Unexpected order: %+v", summaries)
+	}
+}
@@ -0,0 +1,389 @@
+package fleet
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/config"
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+// Work activities in the driver report.
+const (
+	ActivityDriving = "driving"
+	ActivityBreak   = "break"
+	ActivityRest    = "rest"
+	ActivityLoading = "loading"
+	ActivityOther   = "other" // any other work, such as waiting
+)
+
+// Violation kinds in the driver report.
+const (
+	ViolationBindingTime       = "binding_time"
+	ViolationContinuousDriving = "continuous_driving"
+)
+
+// WorkRules are the limits the driver report checks. The defaults follow the
+// improvement standards (改善基準告示) for truck drivers in force since April
+// 2024: at most 13 hours of binding time per shift, and no more than 4 hours
+// of driving without 30 minutes of breaks taken in blocks of 10 minutes or
+// more.
+type WorkRules struct {
+	MaxBinding           time.Duration // binding time (拘束時間) per shift
+	MaxContinuousDriving time.Duration // driving before a break is due
+	MinBreak             time.Duration // total break that ends continuous driving
+	MinBreakSegment      time.Duration // shorter breaks do not count
+	MaxSampleGap         time.Duration // longer gaps between records are not attributed to any activity
+	MovingSpeed          float64       // km/h; counts as driving when the work state says nothing
+}
+
+// DefaultWorkRules returns the thresholds of the 2024 standards.
+func DefaultWorkRules() WorkRules {
+	return WorkRules{
+		MaxBinding:           13 * time.Hour,
+		MaxContinuousDriving: 4 * time.Hour,
+		MinBreak:             30 * time.Minute,
+		MinBreakSegment:      10 * time.Minute,
+		MaxSampleGap:         30 * time.Minute,
+		MovingSpeed:          5,
+	}
+}
+
+// NewWorkRules reads the thresholds from the configuration, using the default
+// for every one that is not set.
+func NewWorkRules(cfg *config.Config) WorkRules {
+	rules := DefaultWorkRules()
+	setDuration := func(dst *time.Duration, v time.Duration) {
+		if v > 0 {
+			*dst = v
+		}
+	}
+	setDuration(&rules.MaxBinding, cfg.DriverMaxBinding)
+	setDuration(&rules.MaxContinuousDriving, cfg.DriverMaxContinuousDriving)
+	setDuration(&rules.MinBreak, cfg.DriverMinBreak)
+	setDuration(&rules.MinBreakSegment, cfg.DriverMinBreakSegment)
+	setDuration(&rules.MaxSampleGap, cfg.DriverMaxSampleGap)
+	if cfg.TripMovingSpeed > 0 {
+		rules.MovingSpeed = cfg.TripMovingSpeed
+	}
+	return rules
+}
+
+// workKeywords maps words in CurrentWorkName to activities. The work codes
+// are configured per company in the portal, so the names are the only
+// portable signal. Earlier entries win.
+var workKeywords = []struct {
+	activity string
+	words    []string
+}{
+	{ActivityRest, []string{"休息", "仮眠", "睡眠"}},
+	{ActivityBreak, []string{"休憩", "食事"}},
+	{ActivityLoading, []string{"荷積", "荷卸", "荷降", "積込", "積卸", "荷役"}},
+	{ActivityDriving, []string{"運転", "走行", "実車", "空車", "回送"}},
+	{ActivityOther, []string{"待機", "手待", "作業", "点検", "給油"}},
+}
+
+// ClassifyWork returns the activity of a record from its work name. A record
+// whose work name is empty or unknown counts as driving when the vehicle
+// moves at movingSpeed or more, and as other work otherwise.
+func ClassifyWork(t *vehicle.Telemetry, movingSpeed float64) string {
+	name := strings.TrimSpace(t.CurrentWorkName)
+	for _, k := range workKeywords {
+		for _, w := range k.words {
+			if strings.Contains(name, w) {
+				return k.activity
+			}
+		}
+	}
+	if t.Speed >= movingSpeed {
+		return ActivityDriving
+	}
+	return ActivityOther
+}
+
+// WorkViolation is a likely breach of a WorkRules limit. The report works
+// from sampled records, so the times are approximate.
+type WorkViolation struct {
+	Kind         string    `json:"kind"`
+	At           time.Time `json:"at"`            // when the limit was passed
+	Seconds      int64     `json:"seconds"`       // the longest value reached
+	LimitSeconds int64     `json:"limit_seconds"` // the limit
+}
+
+// DriverDay is one driver's working time for the day the shift started on in
+// Asia/Tokyo. Time between records further apart than MaxSampleGap is
+// reported as GapSeconds rather than attributed to an activity.
+type DriverDay struct {
+	Date                        string          `json:"date"` // YYYY-MM-DD in Asia/Tokyo
+	DriverCD                    int             `json:"driver_cd"`
+	DriverName                  string          `json:"driver_name"`
+	Vehicles                    []string        `json:"vehicles"`
+	WorkStart                   time.Time       `json:"work_start"`
+	WorkEnd                     time.Time       `json:"work_end"`
+	BindingSeconds              int64           `json:"binding_seconds"`
+	DrivingSeconds              int64           `json:"driving_seconds"`
+	BreakSeconds                int64           `json:"break_seconds"`
+	RestSeconds                 int64           `json:"rest_seconds"`
+	LoadingSeconds              int64           `json:"loading_seconds"`
+	OtherSeconds                int64           `json:"other_seconds"`
+	GapSeconds                  int64           `json:"gap_seconds"`
+	MaxContinuousDrivingSeconds int64           `json:"max_continuous_driving_seconds"`
+	Violations                  []WorkViolation `json:"violations"`
+}
+
+// workSample is the part of a record the driver report uses.
+type workSample struct {
+	at          time.Time
+	driverName  string
+	vehicleName string
+	activity    string
+}
+
+type shiftKey struct {
+	driverCD  int
+	startWork string
+}
+
+// DriverReport loads the records of shifts starting in [from, to) and builds
+// the driver report. A non-zero driverCD limits it to that driver.
+func DriverReport(store *storage.Storage, rules WorkRules, from, to time.Time, driverCD int) ([]DriverDay, error) {
+	// Shifts starting just before to run on into the next day
+	records, err := store.ListTelemetryBetween(from, to.Add(24*time.Hour), driverCD)
+	if err != nil {
+		return nil, err
+	}
+	return BuildDriverReport(records, rules, from, to), nil
+}
+
+// BuildDriverReport splits the records into shifts by driver and work start
+// time, works out each shift's activities and limit breaches, and totals
+// them per driver and day. Only shifts starting in [from, to) are reported;
+// records without a driver or outside a shift are ignored. The result is
+// ordered by date and driver.
+func BuildDriverReport(records []storage.TelemetryRecord, rules WorkRules, from, to time.Time) []DriverDay {
+	shifts := make(map[shiftKey][]workSample)
+	for _, r := range records {
+		var raw map[string]interface{}
+		if err := json.Unmarshal([]byte(r.Record), &raw); err != nil {
+			continue
+		}
+		t, _ := vehicle.Decode(raw)
+		if t.DriverCD == 0 || t.StartWorkDateTime == "" {
+			continue
+		}
+		key := shiftKey{t.DriverCD, t.StartWorkDateTime}
+		shifts[key] = append(shifts[key], workSample{
+			at:          r.DataTime,
+			driverName:  t.DriverName,
+			vehicleName: r.VehicleName,
+			activity:    ClassifyWork(t, rules.MovingSpeed),
+		})
+	}
+
+	type dayKey struct {
+		date     string
+		driverCD int
+	}
+	index := make(map[dayKey]int)
+	var days []DriverDay
+
+	for key, samples := range shifts {
+		sort.Slice(samples, func(i, j int) bool { return samples[i].at.Before(samples[j].at) })
+		start, err := vehicle.ParseTime(key.startWork)
+		if err != nil || start.IsZero() || start.After(samples[0].at) {
+			start = samples[0].at
+		}
+		if start.Before(from) || !start.Before(to) {
+			continue
+		}
+
+		shift := rules.shift(start, samples)
+		shift.DriverCD = key.driverCD
+		shift.Date = start.In(vehicle.Tokyo).Format("2006-01-02")
+
+		k := dayKey{shift.Date, key.driverCD}
+		n, ok := index[k]
+		if !ok {
+			index[k] = len(days)
+			days = append(days, shift)
+			continue
+		}
+		days[n].merge(shift)
+	}
+
+	for i := range days {
+		sort.Strings(days[i].Vehicles)
+		sort.Slice(days[i].Violations, func(a, b int) bool {
+			return days[i].Violations[a].At.Before(days[i].Violations[b].At)
+		})
+	}
+	sort.Slice(days, func(i, j int) bool {
+		if days[i].Date != days[j].Date {
+			return days[i].Date < days[j].Date
+		}
+		return days[i].DriverCD < days[j].DriverCD
+	})
+	return days
+}
+
+// shift works out one shift. Each record's activity lasts until the next
+// record; the shift ends at the last record.
+func (r WorkRules) shift(start time.Time, samples []workSample) DriverDay {
+	last := samples[len(samples)-1]
+	day := DriverDay{
+		DriverName: last.driverName,
+		WorkStart:  start,
+		WorkEnd:    last.at,
+		Violations: []WorkViolation{},
+	}
+	day.BindingSeconds = int64(last.at.Sub(start).Seconds())
+	day.GapSeconds = int64(samples[0].at.Sub(start).Seconds())
+
+	vehicles := make(map[string]bool)
+	var driving, breakRun, breakTotal time.Duration
+	current := -1 // index of the open continuous driving violation
+
+	for i, s := range samples {
+		if s.vehicleName != "" && !vehicles[s.vehicleName] {
+			vehicles[s.vehicleName] = true
+			day.Vehicles = append(day.Vehicles, s.vehicleName)
+		}
+		if i == len(samples)-1 {
+			break
+		}
+		d := samples[i+1].at.Sub(s.at)
+		activity := s.activity
+		if d > r.MaxSampleGap {
+			activity = ""
+		}
+
+		if activity == ActivityBreak || activity == ActivityRest {
+			breakRun += d
+		} else {
+			// A block of breaks ends; it counts when it was long enough
+			if breakRun >= r.MinBreakSegment {
+				breakTotal += breakRun
+			}
+			breakRun = 0
+			if breakTotal >= r.MinBreak {
+				driving, breakTotal, current = 0, 0, -1
+			}
+		}
+
+		seconds := int64(d.Seconds())
+		switch activity {
+		case ActivityDriving:
+			before := driving
+			driving += d
+			if driving > r.MaxContinuousDriving {
+				if current < 0 {
+					current = len(day.Violations)
+					day.Violations = append(day.Violations, WorkViolation{
+						Kind:         ViolationContinuousDriving,
+						At:           s.at.Add(r.MaxContinuousDriving - before),
+						LimitSeconds: int64(r.MaxContinuousDriving.Seconds()),
+					})
+				}
+				day.Violations[current].Seconds = int64(driving.Seconds())
+			}
+			if secs := int64(driving.Seconds()); secs > day.MaxContinuousDrivingSeconds {
+				day.MaxContinuousDrivingSeconds = secs
+			}
+			day.DrivingSeconds += seconds
+		case ActivityBreak:
+			day.BreakSeconds += seconds
+		case ActivityRest:
+			day.RestSeconds += seconds
+		case ActivityLoading:
+			day.LoadingSeconds += seconds
+		case ActivityOther:
+			day.OtherSeconds += seconds
+		default:
+			day.GapSeconds += seconds
+		}
+	}
+
+	if binding := last.at.Sub(start); binding > r.MaxBinding {
+		day.Violations = append(day.Violations, WorkViolation{
+			Kind:         ViolationBindingTime,
+			At:           start.Add(r.MaxBinding),
+			Seconds:      int64(binding.Seconds()),
+			LimitSeconds: int64(r.MaxBinding.Seconds()),
+		})
+	}
+	return day
+}
+
+// merge adds another shift of the same driver and day.
+func (d *DriverDay) merge(o DriverDay) {
+	if o.WorkStart.Before(d.WorkStart) {
+		d.WorkStart = o.WorkStart
+	}
+	if o.WorkEnd.After(d.WorkEnd) {
+		d.WorkEnd, d.DriverName = o.WorkEnd, o.DriverName
+	}
+	for _, v := range o.Vehicles {
+		if !containsString(d.Vehicles, v) {
+			d.Vehicles = append(d.Vehicles, v)
+		}
+	}
+	d.BindingSeconds += o.BindingSeconds
+	d.DrivingSeconds += o.DrivingSeconds
+	d.BreakSeconds += o.BreakSeconds
+	d.RestSeconds += o.RestSeconds
+	d.LoadingSeconds += o.LoadingSeconds
+	d.OtherSeconds += o.OtherSeconds
+	d.GapSeconds += o.GapSeconds
+	if o.MaxContinuousDrivingSeconds > d.MaxContinuousDrivingSeconds {
+		d.MaxContinuousDrivingSeconds = o.MaxContinuousDrivingSeconds
+	}
+	d.Violations = append(d.Violations, o.Violations...)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// DriverReportRows renders the report as CSV rows with a header. Times are in
+// Asia/Tokyo.
+func DriverReportRows(days []DriverDay) [][]string {
+	const layout = "2006/01/02 15:04:05"
+	rows := [][]string{{
+		"date", "driver_cd", "driver_name", "vehicles", "work_start", "work_end",
+		"binding_seconds", "driving_seconds", "break_seconds", "rest_seconds", "loading_seconds",
+		"other_seconds", "gap_seconds", "max_continuous_driving_seconds", "violations",
+	}}
+	for _, d := range days {
+		violations := make([]string, len(d.Violations))
+		for i, v := range d.Violations {
+			violations[i] = v.Kind + "@" + v.At.In(vehicle.Tokyo).Format("15:04")
+		}
+		rows = append(rows, []string{
+			d.Date,
+			strconv.Itoa(d.DriverCD),
+			d.DriverName,
+			strings.Join(d.Vehicles, " / "),
+			d.WorkStart.In(vehicle.Tokyo).Format(layout),
+			d.WorkEnd.In(vehicle.Tokyo).Format(layout),
+			strconv.FormatInt(d.BindingSeconds, 10),
+			strconv.FormatInt(d.DrivingSeconds, 10),
+			strconv.FormatInt(d.BreakSeconds, 10),
+			strconv.FormatInt(d.RestSeconds, 10),
+			strconv.FormatInt(d.LoadingSeconds, 10),
+			strconv.FormatInt(d.OtherSeconds, 10),
+			strconv.FormatInt(d.GapSeconds, 10),
+			strconv.FormatInt(d.MaxContinuousDrivingSeconds, 10),
+			strings.Join(violations, ";"),
+		})
+	}
+	return rows
+}
@@ -0,0 +1,147 @@
+package fleet
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+func TestClassifyWork(t *testing.T) {
+	tests := []struct {
+		name  string
+		speed float64
+		want  string
+	}{
+		{"運転", 0, ActivityDriving},
+		{"休憩", 0, ActivityBreak},
+		{"休息", 0, ActivityRest},
+		{"荷積み", 0, ActivityLoading},
+		{"荷卸し", 0, ActivityLoading},
+		{"待機", 40, ActivityOther},
+		{"", 40, ActivityDriving},
+		{"", 0, ActivityOther},
+	}
+	for _, tt := range tests {
+		got := ClassifyWork(&vehicle.Telemetry{CurrentWorkName: tt.name, Speed: tt.speed}, 5)
+		if got != tt.want {
+			t.Errorf("ClassifyWork(%q, %v) = %s, want %s", tt.name, tt.speed, got, tt.want)
+		}
+	}
+}
+
+func workRecord(t *testing.T, at time.Time, driverCD int, startWork, work string) storage.TelemetryRecord {
+	t.Helper()
+	record, err := json.Marshal(map[string]interface{}{
+		"VehicleCD":         7,
+		"DriverCD":          driverCD,
+		"DriverName":        "Sato",
+		"StartWorkDateTime": startWork,
+		"CurrentWorkName":   work,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return storage.TelemetryRecord{VehicleCD: 7, VehicleName: "Truck", DataTime: at, Record: string(record)}
+}
+
+func TestBuildDriverReport(t *testing.T) {
+	day := time.Date(2025, 1, 2, 0, 0, 0, 0, vehicle.Tokyo)
+	start := day.Add(6 * time.Hour)
+	startWork := "2025/01/02 06:00:00"
+
+	var records []storage.TelemetryRecord
+	for offset := time.Duration(0); offset <= 14*time.Hour; offset += 10 * time.Minute {
+		var work string
+		switch {
+		case offset < 30*time.Minute:
+			work = "荷積"
+		case offset < 5*time.Hour:
+			work = "運転" // 4.5 hours without a break
+		case offset < 5*time.Hour+30*time.Minute:
+			work = "休憩"
+		case offset < 8*time.Hour:
+			work = "運転"
+		case offset > 12*time.Hour && offset < 13*time.Hour:
+			continue // no records for an hour
+		default:
+			work = "待機"
+		}
+		records = append(records, workRecord(t, start.Add(offset), 5, startWork, work))
+	}
+	// Off duty, and a shift from the day before: neither is reported
+	records = append(records,
+		workRecord(t, start, 6, "", "休息"),
+		workRecord(t, day.Add(-time.Hour), 8, "2025/01/01 22:00:00", "運転"),
+	)
+
+	days := BuildDriverReport(records, DefaultWorkRules(), day, day.AddDate(0, 0, 1))
+	if len(days) != 1 {
+		t.Fatalf("Expected 1 driver day, got %d", len(days))
+	}
+	d := days[0]
+	if d.Date != "2025-01-02" || d.DriverCD != 5 || d.DriverName != "Sato" || len(d.Vehicles) != 1 {
+		t.Errorf("Unexpected driver day: %+v", d)
+	}
+
+	want := map[string][2]int64{
+		"binding":  {d.BindingSeconds, 14 * 3600},
+		"driving":  {d.DrivingSeconds, 7 * 3600},
+		"break":    {d.BreakSeconds, 1800},
+		"loading":  {d.LoadingSeconds, 1800},
+		"other":    {d.OtherSeconds, 5 * 3600},
+		"gap":      {d.GapSeconds, 3600},
+		"max cont": {d.MaxContinuousDrivingSeconds, 16200},
+	}
+	for name, v := range want {
+		if v[0] != v[1] {
+			t.Errorf("%s: expected %d seconds, got %d", name, v[1], v[0])
+		}
+	}
+
+	if len(d.Violations) != 2 {
+		t.Fatalf("Expected 2 violations, got %+v", d.Violations)
+	}
+	if v := d.Violations[0]; v.Kind != ViolationContinuousDriving || !v.At.Equal(start.Add(4*time.Hour+30*time.Minute)) || v.Seconds != 16200 {
+		t.Errorf("Unexpected continuous driving violation: %+v", v)
+	}
+	if v := d.Violations[1]; v.Kind != ViolationBindingTime || !v.At.Equal(start.Add(13*time.Hour)) {
+		t.Errorf("Unexpected binding time violation: %+v", v)
+	}
+
+	rows := DriverReportRows(days)
+	if len(rows) != 2 || rows[1][14] != "continuous_driving@10:30;binding_time@19:00" {
+		t.Errorf("Unexpected CSV rows: %v", rows)
+	}
+}
+
+func TestBuildDriverReport_ShortBreaks(t *testing.T) {
+	day := time.Date(2025, 1, 2, 0, 0, 0, 0, vehicle.Tokyo)
+	start := day.Add(6 * time.Hour)
+	rules := DefaultWorkRules()
+	rules.MinBreakSegment = 15 * time.Minute
+
+	// Ten-minute breaks every hour are shorter than the segment minimum, so
+	// the driving is continuous
+	var records []storage.TelemetryRecord
+	for offset := time.Duration(0); offset <= 5*time.Hour; offset += 10 * time.Minute {
+		work := "運転"
+		if offset%time.Hour == 50*time.Minute {
+			work = "休憩"
+		}
+		records = append(records, workRecord(t, start.Add(offset), 5, "2025/01/02 06:00", work))
+	}
+
+	days := BuildDriverReport(records, rules, day, day.AddDate(0, 0, 1))
+	if len(days) != 1 || len(days[0].Violations) != 1 || days[0].Violations[0].Kind != ViolationContinuousDriving {
+		t.Fatalf("Expected a continuous driving violation, got %+v", days)
+	}
+
+	rules.MinBreakSegment = 10 * time.Minute
+	days = BuildDriverReport(records, rules, day, day.AddDate(0, 0, 1))
+	if len(days[0].Violations) != 0 {
+		t.Errorf("Expected the breaks to count, got %+v", days[0].Violations)
+	}
+}
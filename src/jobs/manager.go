@@ -2,6 +2,7 @@ package jobs
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
@@ -18,16 +19,34 @@ const (
 	JobStatusRunning   JobStatus = "running"
 	JobStatusCompleted JobStatus = "completed"
 	JobStatusFailed    JobStatus = "failed"
+	// JobStatusSuspect means the scrape looked incomplete and was not sent to
+	// the sinks; see browser.Renderer.ConfirmScrape.
+	JobStatusSuspect JobStatus = "suspect"
 )
 
+type JobType string
+
+const (
+	JobTypeVehicleData JobType = "vehicle_data"
+	JobTypeDownload    JobType = "download"
+	JobTypeRecipe      JobType = "recipe"
+)
+
+// jobRetention is how long finished jobs stay queryable
+const jobRetention = 10 * time.Minute
+
 type Job struct {
 	ID           string                   `json:"id"`
+	Type         JobType                  `json:"type"`
 	Status       JobStatus                `json:"status"`
+	BranchID     string                   `json:"branch_id,omitempty"`
+	FilterID     string                   `json:"filter_id,omitempty"`
 	CreatedAt    time.Time                `json:"created_at"`
 	CompletedAt  *time.Time               `json:"completed_at,omitempty"`
 	Error        string                   `json:"error,omitempty"`
 	VehicleCount int                      `json:"vehicle_count,omitempty"`
 	HonoResponse *browser.HonoAPIResponse `json:"hono_response,omitempty"`
+	Result       interface{}              `json:"result,omitempty"`
 }
 
 type Manager struct {
@@ -43,37 +62,50 @@ func NewManager(renderer *browser.Renderer) *Manager {
 	}
 }
 
-func (m *Manager) CreateJob() string {
+// CreateJob starts a vehicle data job. Empty branchID/filterID fall back to
+// browser.DefaultBranchID and browser.DefaultFilterID; deleted vehicles are
+// included with browser.IncludeDeletedFilterID.
+func (m *Manager) CreateJob(branchID, filterID string) string {
 	jobID := uuid.New().String()
 
+	if branchID == "" {
+		branchID = browser.DefaultBranchID
+	}
+	if filterID == "" {
+		filterID = browser.DefaultFilterID
+	}
+
 	m.mu.Lock()
 	m.jobs[jobID] = &Job{
 		ID:        jobID,
+		Type:      JobTypeVehicleData,
 		Status:    JobStatusPending,
+		BranchID:  branchID,
+		FilterID:  filterID,
 		CreatedAt: time.Now(),
 	}
 	m.mu.Unlock()
 
 	// Start processing in background
-	go m.processJob(jobID)
+	go m.processJob(jobID, branchID, filterID)
 
 	return jobID
 }
 
-func (m *Manager) processJob(jobID string) {
+func (m *Manager) processJob(jobID, branchID, filterID string) {
 	// Update status to running
 	m.updateJobStatus(jobID, JobStatusRunning)
 
 	// Create independent context for background processing
 	ctx := context.Background()
 
-	// Call the renderer with fixed parameters
+	// Call the renderer with the job's branch and filter
 	vehicleData, _, honoAPIResponse, err := m.renderer.GetVehicleData(
 		ctx,
-		"",         // Session ID
-		"00000000", // Branch ID
-		"0",        // Filter ID
-		false,      // Force login
+		"",       // Session ID
+		branchID, // Branch ID
+		filterID, // Filter ID
+		false,    // Force login
 	)
 
 	// Update job with results
@@ -83,14 +115,23 @@ func (m *Manager) processJob(jobID string) {
 		now := time.Now()
 		job.CompletedAt = &now
 
-		if err != nil {
+		var suspect *browser.SuspectScrapeError
+		switch {
+		case errors.As(err, &suspect):
+			job.Status = JobStatusSuspect
+			job.Error = suspect.Error()
+			job.VehicleCount = len(vehicleData)
+			job.Result = vehicleData
+			log.Printf("Job %s is suspect: %v", jobID, err)
+		case err != nil:
 			job.Status = JobStatusFailed
 			job.Error = err.Error()
 			log.Printf("Job %s failed: %v", jobID, err)
-		} else {
+		default:
 			job.Status = JobStatusCompleted
 			job.VehicleCount = len(vehicleData)
 			job.HonoResponse = honoAPIResponse
+			job.Result = vehicleData
 			log.Printf("Job %s completed successfully with %d vehicles", jobID, len(vehicleData))
 
 			if honoAPIResponse != nil {
@@ -104,9 +145,80 @@ func (m *Manager) processJob(jobID string) {
 	}
 	m.mu.Unlock()
 
-	// Clean up old jobs after 10 minutes
+	m.scheduleCleanup(jobID)
+}
+
+// CreateDownloadJob starts a report download job.
+func (m *Manager) CreateDownloadJob(req browser.DownloadRequest) string {
+	jobID := uuid.New().String()
+	req.JobID = jobID
+
+	m.mu.Lock()
+	m.jobs[jobID] = &Job{
+		ID:        jobID,
+		Type:      JobTypeDownload,
+		Status:    JobStatusPending,
+		CreatedAt: time.Now(),
+	}
+	m.mu.Unlock()
+
+	go m.runJob(jobID, func(ctx context.Context) (interface{}, error) {
+		return m.renderer.DownloadReport(ctx, req)
+	})
+
+	return jobID
+}
+
+// CreateRecipeJob starts a job that runs an extraction recipe.
+func (m *Manager) CreateRecipeJob(recipe *browser.Recipe, sessionID string, vars map[string]string) string {
+	jobID := uuid.New().String()
+
+	m.mu.Lock()
+	m.jobs[jobID] = &Job{
+		ID:        jobID,
+		Type:      JobTypeRecipe,
+		Status:    JobStatusPending,
+		CreatedAt: time.Now(),
+	}
+	m.mu.Unlock()
+
+	go m.runJob(jobID, func(ctx context.Context) (interface{}, error) {
+		return m.renderer.RunRecipe(ctx, recipe, sessionID, vars)
+	})
+
+	return jobID
+}
+
+// runJob executes a job whose outcome is a single result value.
+func (m *Manager) runJob(jobID string, run func(ctx context.Context) (interface{}, error)) {
+	m.updateJobStatus(jobID, JobStatusRunning)
+
+	result, err := run(context.Background())
+
+	m.mu.Lock()
+	if job := m.jobs[jobID]; job != nil {
+		now := time.Now()
+		job.CompletedAt = &now
+
+		if err != nil {
+			job.Status = JobStatusFailed
+			job.Error = err.Error()
+			log.Printf("Job %s failed: %v", jobID, err)
+		} else {
+			job.Status = JobStatusCompleted
+			job.Result = result
+			log.Printf("Job %s completed successfully", jobID)
+		}
+	}
+	m.mu.Unlock()
+
+	m.scheduleCleanup(jobID)
+}
+
+// scheduleCleanup removes the job once jobRetention has passed.
+func (m *Manager) scheduleCleanup(jobID string) {
 	go func() {
-		time.Sleep(10 * time.Minute)
+		time.Sleep(jobRetention)
 		m.mu.Lock()
 		delete(m.jobs, jobID)
 		m.mu.Unlock()
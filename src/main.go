@@ -14,6 +14,8 @@ import (
 
 	"github.com/yhonda-ohishi/browser_render_go/src/browser"
 	"github.com/yhonda-ohishi/browser_render_go/src/config"
+	"github.com/yhonda-ohishi/browser_render_go/src/fleet"
+	"github.com/yhonda-ohishi/browser_render_go/src/notify"
 	"github.com/yhonda-ohishi/browser_render_go/src/server"
 	"github.com/yhonda-ohishi/browser_render_go/src/storage"
 )
@@ -64,6 +66,16 @@ func main() {
 	defer store.Close()
 	log.Println("Storage initialized successfully")
 
+	// Commands that only read the database run and exit here
+	if flag.Arg(0) == "driver-report" {
+		if err := runDriverReport(cfg, store, flag.Args()[1:]); err != nil {
+			log.Printf("driver-report: %v", err)
+			store.Close()
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Initialize browser renderer
 	renderer, err := browser.NewRenderer(cfg, store)
 	if err != nil {
@@ -72,6 +84,56 @@ func main() {
 	defer renderer.Close()
 	log.Println("Browser renderer initialized successfully")
 
+	// Detect vehicle changes, starting from the last stored state
+	detector := fleet.NewDetector(cfg.EventMinMove, cfg.EventCommGap)
+	if latest, err := store.ListFleetAt(time.Now(), time.Time{}, 0, 0); err != nil {
+		log.Printf("Warning: failed to load last vehicle states: %v", err)
+	} else {
+		detector.Seed(latest)
+	}
+	renderer.AddSink(fleet.NewEventSink(detector, store))
+
+	// Alerts go to the log and, when configured, a webhook
+	notifiers := []notify.Notifier{notify.LogNotifier{}}
+	if cfg.NotifyWebhookURL != "" {
+		notifiers = append(notifiers, notify.NewWebhookNotifier(cfg.NotifyWebhookURL))
+	}
+	notifier := notify.NewDispatcher(notifiers...)
+
+	tempMonitor := fleet.NewTemperatureMonitor(cfg.TempTolerance, cfg.TempMinDuration, cfg.TempOffStates, store, notifier)
+	if err := tempMonitor.Restore(); err != nil {
+		log.Printf("Warning: failed to restore open temperature excursions: %v", err)
+	}
+	renderer.AddSink(tempMonitor)
+	renderer.AddSink(fleet.NewGeofenceMonitor(cfg.GeofenceDwell, store, notifier))
+
+	tripBuilder := fleet.NewTripBuilder(cfg.TripMovingSpeed, cfg.TripStopDuration, store)
+	if err := tripBuilder.Restore(); err != nil {
+		log.Printf("Warning: failed to restore open trips: %v", err)
+	}
+	renderer.AddSink(tripBuilder)
+
+	renderer.AddSink(fleet.NewDrivingMonitor(fleet.DrivingRules{
+		OverspeedLimit:      cfg.OverspeedLimit,
+		IdleMinDuration:     cfg.IdleMinDuration,
+		IdleMinRevo:         cfg.IdleMinRevo,
+		IdleOperationStates: cfg.IdleOperationStates,
+		HarshSpeedChange:    cfg.HarshSpeedChange,
+		HarshMaxInterval:    cfg.HarshMaxInterval,
+	}, store))
+
+	healthMonitor := fleet.NewHealthMonitor(cfg.HealthStaleAfter, cfg.HealthOfflineAfter, cfg.HealthAlertStatuses, store, notifier)
+	if err := healthMonitor.Restore(); err != nil {
+		log.Printf("Warning: failed to restore vehicle health: %v", err)
+	}
+	renderer.AddSink(healthMonitor)
+
+	summaryCache := fleet.NewSummaryCache(cfg.HealthStaleAfter, cfg.HealthOfflineAfter, store)
+	if err := summaryCache.Restore(); err != nil {
+		log.Printf("Warning: failed to restore fleet summary: %v", err)
+	}
+	renderer.AddSink(summaryCache)
+
 	// Create servers
 	grpcServer := server.NewGRPCServer(cfg, store, renderer)
 	httpServer := server.NewHTTPServer(cfg, store, renderer)
@@ -95,10 +157,25 @@ func main() {
 				if err := store.CleanupExpired(); err != nil {
 					log.Printf("Error cleaning up expired data: %v", err)
 				}
+				if cfg.TelemetryRetention > 0 {
+					if _, err := store.DeleteTelemetryBefore(time.Now().Add(-cfg.TelemetryRetention)); err != nil {
+						log.Printf("Error cleaning up telemetry history: %v", err)
+					}
+				}
 			}
 		}
 	}()
 
+	// Start vehicle state polling
+	if cfg.PollInterval > 0 {
+		go renderer.RunPoller(ctx, cfg.PollInterval, "", "")
+	}
+
+	// Recheck the health of vehicles that stopped reporting
+	if cfg.HealthCheckInterval > 0 {
+		go healthMonitor.Run(ctx, cfg.HealthCheckInterval)
+	}
+
 	// Start servers based on server type
 	var wg sync.WaitGroup
 	errChan := make(chan error, 2)
@@ -0,0 +1,124 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Alert severities.
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Alert is a notification about one vehicle or the fleet.
+type Alert struct {
+	Kind        string                 `json:"kind"` // e.g. "temperature_excursion"
+	Severity    string                 `json:"severity"`
+	Title       string                 `json:"title"`
+	Message     string                 `json:"message"`
+	VehicleCD   int64                  `json:"vehicle_cd,omitempty"`
+	VehicleName string                 `json:"vehicle_name,omitempty"`
+	Time        time.Time              `json:"time"`
+	Detail      map[string]interface{} `json:"detail,omitempty"`
+}
+
+// Notifier delivers alerts to one channel.
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// Dispatcher sends every alert to all of its channels. A failing channel is
+// logged and does not stop the others.
+type Dispatcher struct {
+	notifiers []Notifier
+}
+
+// NewDispatcher creates a dispatcher for the given channels.
+func NewDispatcher(notifiers ...Notifier) *Dispatcher {
+	return &Dispatcher{notifiers: notifiers}
+}
+
+func (d *Dispatcher) Name() string {
+	return "dispatcher"
+}
+
+// Notify sends the alert to every channel and returns the joined errors.
+func (d *Dispatcher) Notify(ctx context.Context, alert Alert) error {
+	var errs []error
+	for _, n := range d.notifiers {
+		if err := n.Notify(ctx, alert); err != nil {
+			log.Printf("Warning: notifier %s failed: %v", n.Name(), err)
+			errs = append(errs, fmt.Errorf("%s: %w", n.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// LogNotifier writes alerts to the server log.
+type LogNotifier struct{}
+
+func (LogNotifier) Name() string {
+	return "log"
+}
+
+func (LogNotifier) Notify(_ context.Context, alert Alert) error {
+	log.Printf("[%s] %s: %s", alert.Severity, alert.Title, alert.Message)
+	return nil
+}
+
+// WebhookNotifier posts alerts as JSON. The payload also carries a "text"
+// field so Slack-compatible incoming webhooks can display it as is.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a notifier that posts to url.
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (w *WebhookNotifier) Name() string {
+	return "webhook"
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, alert Alert) error {
+	payload := struct {
+		Alert
+		Text string `json:"text"`
+	}{alert, fmt.Sprintf("[%s] %s\n%s", alert.Severity, alert.Title, alert.Message)}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send alert: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type failingNotifier struct{ calls int }
+
+func (f *failingNotifier) Name() string { return "failing" }
+
+func (f *failingNotifier) Notify(context.Context, Alert) error {
+	f.calls++
+	return errors.New("unavailable")
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	alert := Alert{Kind: "test", Severity: SeverityWarning, Title: "Title", Message: "Message", VehicleCD: 42, Time: time.Now()}
+	if err := NewWebhookNotifier(srv.URL).Notify(context.Background(), alert); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if got["kind"] != "test" || got["vehicle_cd"] != float64(42) || got["text"] != "[warning] Title\nMessage" {
+		t.Errorf("Unexpected payload: %v", got)
+	}
+
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	if err := NewWebhookNotifier(srv.URL).Notify(context.Background(), alert); err == nil {
+		t.Error("Expected an error for a failing webhook")
+	}
+}
+
+func TestDispatcher_ContinuesAfterFailure(t *testing.T) {
+	first, second := &failingNotifier{}, &failingNotifier{}
+	err := NewDispatcher(first, LogNotifier{}, second).Notify(context.Background(), Alert{Title: "Title"})
+	if err == nil {
+		t.Error("Expected the joined error")
+	}
+	if first.calls != 1 || second.calls != 1 {
+		t.Errorf("Expected every notifier to be called, got %d and %d", first.calls, second.calls)
+	}
+}
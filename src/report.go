@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/config"
+	"github.com/yhonda-ohishi/browser_render_go/src/fleet"
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+// runDriverReport implements the driver-report command, which writes the
+// driver working-time report for a day or a period as JSON or CSV:
+//
+//	browser_render driver-report [-date 2025-01-02 | -from T -to T] [-driver CD] [-format json|csv] [-o file]
+func runDriverReport(cfg *config.Config, store *storage.Storage, args []string) error {
+	fs := flag.NewFlagSet("driver-report", flag.ContinueOnError)
+	date := fs.String("date", "", "Day in Asia/Tokyo (YYYY-MM-DD, default today)")
+	fromFlag := fs.String("from", "", "Start of the period (instead of -date)")
+	toFlag := fs.String("to", "", "End of the period, exclusive (instead of -date)")
+	driverCD := fs.Int("driver", 0, "Only this DriverCD")
+	format := fs.String("format", "json", "Output format: json or csv")
+	output := fs.String("o", "", "Output file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var from, to time.Time
+	if *fromFlag != "" || *toFlag != "" {
+		var err error
+		if from, err = vehicle.ParseTime(*fromFlag); err != nil {
+			return err
+		}
+		if to, err = vehicle.ParseTime(*toFlag); err != nil {
+			return err
+		}
+		if from.IsZero() || to.IsZero() {
+			return fmt.Errorf("both -from and -to are required")
+		}
+	} else {
+		day := time.Now().In(vehicle.Tokyo)
+		if *date != "" {
+			var err error
+			if day, err = time.ParseInLocation("2006-01-02", *date, vehicle.Tokyo); err != nil {
+				return fmt.Errorf("invalid -date: %q", *date)
+			}
+		}
+		from = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, vehicle.Tokyo)
+		to = from.AddDate(0, 0, 1)
+	}
+
+	days, err := fleet.DriverReport(store, fleet.NewWorkRules(cfg), from, to, *driverCD)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch *format {
+	case "csv":
+		// BOM so Excel opens the Japanese names as UTF-8
+		if _, err := io.WriteString(w, "\ufeff"); err != nil {
+			return err
+		}
+		cw := csv.NewWriter(w)
+		if err := cw.WriteAll(fleet.DriverReportRows(days)); err != nil {
+			return err
+		}
+	case "json":
+		if days == nil {
+			days = []fleet.DriverDay{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(days); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown format: %q", *format)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/browser"
+)
+
+// Downloads endpoint - lists stored report files or starts a download job
+func (s *HTTPServer) handleDownloads(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		limit, err := parseLimitParam(r)
+		if err != nil {
+			s.sendError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if limit == 0 {
+			limit = 100
+		}
+
+		downloads, err := s.storage.ListDownloads(query.Get("report_type"), limit)
+		if err != nil {
+			s.sendError(w, fmt.Sprintf("Failed to list downloads: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		s.sendJSON(w, map[string]interface{}{
+			"downloads": downloads,
+			"count":     len(downloads),
+		}, http.StatusOK)
+
+	case http.MethodPost:
+		var req browser.DownloadRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.sendError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := req.Validate(); err != nil {
+			s.sendError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		jobID := s.jobManager.CreateDownloadJob(req)
+		log.Printf("Created download job %s for report %s", jobID, req.ReportType)
+
+		s.sendJSON(w, map[string]interface{}{
+			"job_id":  jobID,
+			"status":  "pending",
+			"message": "Download job created successfully. Use /v1/job/{id} to check status.",
+		}, http.StatusAccepted)
+
+	default:
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Download endpoint - returns or deletes a single stored report file
+func (s *HTTPServer) handleDownload(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Path[len("/v1/downloads/"):]
+	if id == "" {
+		s.sendError(w, "Download ID is required", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		download, err := s.storage.GetDownload(id)
+		if err != nil {
+			s.sendError(w, fmt.Sprintf("Failed to get download: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if download == nil {
+			s.sendError(w, "Download not found", http.StatusNotFound)
+			return
+		}
+		s.sendBinary(w, download.ContentType, download.Filename, download.Data)
+
+	case http.MethodDelete:
+		if err := s.storage.DeleteDownload(id); err != nil {
+			s.sendError(w, fmt.Sprintf("Failed to delete download: %v", err), http.StatusInternalServerError)
+			return
+		}
+		s.sendJSON(w, map[string]interface{}{
+			"success": true,
+			"message": "Download deleted successfully",
+		}, http.StatusOK)
+
+	default:
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPServer_DownloadsValidation(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	tests := []struct {
+		name       string
+		method     string
+		endpoint   string
+		body       string
+		wantStatus int
+	}{
+		{"List", "GET", "/v1/downloads", "", http.StatusOK},
+		{"List with limit", "GET", "/v1/downloads?limit=10", "", http.StatusOK},
+		{"List invalid limit", "GET", "/v1/downloads?limit=abc", "", http.StatusBadRequest},
+		{"List negative limit", "GET", "/v1/downloads?limit=-5", "", http.StatusBadRequest},
+		{"Create invalid JSON", "POST", "/v1/downloads", "invalid", http.StatusBadRequest},
+		{"Wrong method", "PUT", "/v1/downloads", "", http.StatusMethodNotAllowed},
+		{"Unknown download", "GET", "/v1/downloads/missing", "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.endpoint, bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			server.mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+		})
+	}
+}
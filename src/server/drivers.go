@@ -0,0 +1,73 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/fleet"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+// Driver report endpoint - per-driver, per-day working time with likely
+// breaches of the binding-time and continuous-driving limits
+func (s *HTTPServer) handleDriverReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	from, to, err := parseDayRange(r, true)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if from.IsZero() || to.IsZero() {
+		s.sendError(w, "both from and to are required", http.StatusBadRequest)
+		return
+	}
+
+	var driverCD int
+	if v := r.URL.Query().Get("driver_cd"); v != "" {
+		driverCD, err = strconv.Atoi(v)
+		if err != nil {
+			s.sendError(w, fmt.Sprintf("invalid driver_cd: %q", v), http.StatusBadRequest)
+			return
+		}
+	}
+
+	rules := fleet.NewWorkRules(s.config)
+	days, err := fleet.DriverReport(s.storage, rules, from, to, driverCD)
+	if err != nil {
+		s.sendError(w, fmt.Sprintf("Failed to build driver report: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "csv" {
+		s.sendCSV(w, fmt.Sprintf("driver_report_%s.csv", from.In(vehicle.Tokyo).Format("20060102")), fleet.DriverReportRows(days))
+		return
+	}
+
+	for i := range days {
+		days[i].WorkStart = days[i].WorkStart.In(vehicle.Tokyo)
+		days[i].WorkEnd = days[i].WorkEnd.In(vehicle.Tokyo)
+		for j := range days[i].Violations {
+			days[i].Violations[j].At = days[i].Violations[j].At.In(vehicle.Tokyo)
+		}
+	}
+	if days == nil {
+		days = []fleet.DriverDay{}
+	}
+	s.sendJSON(w, map[string]interface{}{
+		"from":    from.In(vehicle.Tokyo),
+		"to":      to.In(vehicle.Tokyo),
+		"drivers": days,
+		"count":   len(days),
+		"rules": map[string]interface{}{
+			"max_binding_seconds":            int64(rules.MaxBinding.Seconds()),
+			"max_continuous_driving_seconds": int64(rules.MaxContinuousDriving.Seconds()),
+			"min_break_seconds":              int64(rules.MinBreak.Seconds()),
+			"min_break_segment_seconds":      int64(rules.MinBreakSegment.Seconds()),
+		},
+	}, http.StatusOK)
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+func TestHTTPServer_DriverReport(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	start := time.Date(2025, 1, 2, 8, 0, 0, 0, vehicle.Tokyo)
+	var records []storage.TelemetryRecord
+	for i := 0; i <= 30; i++ {
+		records = append(records, storage.TelemetryRecord{
+			VehicleCD: 1, VehicleName: "Truck", DriverCD: 5, DataTime: start.Add(time.Duration(i) * 10 * time.Minute),
+			Record: `{"VehicleCD":1,"DriverCD":5,"DriverName":"Sato","StartWorkDateTime":"2025/01/02 08:00:00","CurrentWorkName":"運転"}`,
+		})
+	}
+	if err := server.storage.SaveTelemetry(records); err != nil {
+		t.Fatalf("Failed to save telemetry: %v", err)
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		path   string
+		status int
+		count  float64
+	}{
+		{"/v1/reports/drivers?date=2025-01-02", http.StatusOK, 1},
+		{"/v1/reports/drivers?date=2025-01-02&driver_cd=6", http.StatusOK, 0},
+		{"/v1/reports/drivers?date=2025-01-03", http.StatusOK, 0},
+		{"/v1/reports/drivers?date=2025-01-02&driver_cd=x", http.StatusBadRequest, 0},
+		{"/v1/reports/drivers?from=2025-01-02T00:00:00%2B09:00", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		w := get(tt.path)
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.status, w.Code)
+			continue
+		}
+		var body map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&body)
+		if tt.status == http.StatusOK && body["count"] != tt.count {
+			t.Errorf("%s: expected count %v, got %v", tt.path, tt.count, body["count"])
+		}
+	}
+
+	// Five hours of driving without a break
+	var body struct {
+		Drivers []struct {
+			DrivingSeconds int64 `json:"driving_seconds"`
+			Violations     []struct {
+				Kind string `json:"kind"`
+			} `json:"violations"`
+		} `json:"drivers"`
+	}
+	json.NewDecoder(get("/v1/reports/drivers?date=2025-01-02").Body).Decode(&body)
+	if len(body.Drivers) != 1 || body.Drivers[0].DrivingSeconds != 5*3600 || len(body.Drivers[0].Violations) != 1 || body.Drivers[0].Violations[0].Kind != "continuous_driving" {
+		t.Errorf("Unexpected report: %+v", body)
+	}
+
+	w := get("/v1/reports/drivers?date=2025-01-02&format=csv")
+	rows, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(w.Body.String(), "\ufeff"))).ReadAll()
+	if err != nil || len(rows) != 2 || rows[0][0] != "date" || rows[1][2] != "Sato" {
+		t.Errorf("Unexpected CSV: %v (%v)", rows, err)
+	}
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/fleet"
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+// Driving report endpoint - per-vehicle, per-day overspeed, idling and harsh
+// speed change totals. The events themselves are on /v1/events.
+func (s *HTTPServer) handleDrivingReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	from, to, err := parseDayRange(r, true)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter := storage.EventFilter{Types: fleet.DrivingEventTypes, From: from, To: to}
+	if v := r.URL.Query().Get("vehicle_cd"); v != "" {
+		if filter.VehicleCD, err = strconv.ParseInt(v, 10, 64); err != nil {
+			s.sendError(w, fmt.Sprintf("invalid vehicle_cd: %q", v), http.StatusBadRequest)
+			return
+		}
+	}
+
+	events, err := s.storage.ListEvents(filter)
+	if err != nil {
+		s.sendError(w, fmt.Sprintf("Failed to list events: %v", err), http.StatusInternalServerError)
+		return
+	}
+	summaries := fleet.SummarizeDriving(events)
+
+	if r.URL.Query().Get("format") == "csv" {
+		s.sendCSV(w, fmt.Sprintf("driving_report_%s.csv", from.In(vehicle.Tokyo).Format("20060102")), drivingSummaryRows(summaries))
+		return
+	}
+
+	if summaries == nil {
+		summaries = []fleet.DrivingSummary{}
+	}
+	s.sendJSON(w, map[string]interface{}{
+		"from":     from.In(vehicle.Tokyo),
+		"to":       to.In(vehicle.Tokyo),
+		"vehicles": summaries,
+		"count":    len(summaries),
+	}, http.StatusOK)
+}
+
+func drivingSummaryRows(summaries []fleet.DrivingSummary) [][]string {
+	rows := [][]string{{
+		"date", "vehicle_cd", "vehicle_name", "overspeeds", "overspeed_seconds", "max_speed",
+		"idlings", "idling_seconds", "harsh_accelerations", "harsh_brakings",
+	}}
+	for _, sum := range summaries {
+		rows = append(rows, []string{
+			sum.Date,
+			strconv.FormatInt(sum.VehicleCD, 10),
+			sum.VehicleName,
+			strconv.Itoa(sum.Overspeeds),
+			strconv.FormatInt(sum.OverspeedSeconds, 10),
+			formatFloat(sum.MaxSpeed),
+			strconv.Itoa(sum.Idlings),
+			strconv.FormatInt(sum.IdlingSeconds, 10),
+			strconv.Itoa(sum.HarshAccelerations),
+			strconv.Itoa(sum.HarshBrakings),
+		})
+	}
+	return rows
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+func TestHTTPServer_DrivingReport(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	base := time.Date(2025, 1, 2, 9, 0, 0, 0, vehicle.Tokyo)
+	events := []storage.Event{
+		{Type: "overspeed", VehicleCD: 1, VehicleName: "Truck", Time: base, To: "92", Detail: map[string]interface{}{"speed": 92}},
+		{Type: "overspeed_ended", VehicleCD: 1, Time: base.Add(2 * time.Minute), Detail: map[string]interface{}{"duration_seconds": 120, "max_speed": 98}},
+		{Type: "harsh_braking", VehicleCD: 1, Time: base.Add(time.Hour)},
+		{Type: "harsh_acceleration", VehicleCD: 2, VehicleName: "Van", Time: base},
+		{Type: "moved", VehicleCD: 3, Time: base},
+		{Type: "harsh_braking", VehicleCD: 1, Time: base.Add(24 * time.Hour)},
+	}
+	if err := server.storage.SaveEvents(events); err != nil {
+		t.Fatalf("Failed to save events: %v", err)
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		path   string
+		status int
+		count  float64
+	}{
+		{"/v1/reports/driving?date=2025-01-02", http.StatusOK, 2},
+		{"/v1/reports/driving?date=2025-01-02&vehicle_cd=1", http.StatusOK, 1},
+		{"/v1/reports/driving?from=2025/01/02&to=2025/01/04", http.StatusOK, 3},
+		{"/v1/reports/driving?vehicle_cd=x", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		w := get(tt.path)
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.status, w.Code)
+			continue
+		}
+		var body map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&body)
+		if tt.status == http.StatusOK && body["count"] != tt.count {
+			t.Errorf("%s: expected count %v, got %v", tt.path, tt.count, body["count"])
+		}
+	}
+
+	var body struct {
+		Vehicles []map[string]interface{} `json:"vehicles"`
+	}
+	json.NewDecoder(get("/v1/reports/driving?date=2025-01-02&vehicle_cd=1").Body).Decode(&body)
+	if len(body.Vehicles) != 1 {
+		t.Fatalf("Expected 1 vehicle, got %v", body.Vehicles)
+	}
+	v := body.Vehicles[0]
+	if v["vehicle_name"] != "Truck" || v["overspeeds"] != float64(1) || v["overspeed_seconds"] != float64(120) || v["max_speed"] != float64(98) || v["harsh_brakings"] != float64(1) {
+		t.Errorf("Unexpected summary: %v", v)
+	}
+
+	w := get("/v1/reports/driving?date=2025-01-02&format=csv")
+	if lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n"); len(lines) != 3 {
+		t.Errorf("Expected a header and 2 rows, got %q", w.Body.String())
+	}
+}
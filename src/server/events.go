@@ -0,0 +1,76 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+// Events endpoint - lists detected vehicle change events in the order they
+// were recorded. Clients tail the stream by passing the last ID they saw as
+// after_id.
+func (s *HTTPServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := storage.EventFilter{Types: parseFields(query.Get("type"))}
+
+	var err error
+	if v := query.Get("vehicle_cd"); v != "" {
+		if filter.VehicleCD, err = strconv.ParseInt(v, 10, 64); err != nil {
+			s.sendError(w, fmt.Sprintf("invalid vehicle_cd: %q", v), http.StatusBadRequest)
+			return
+		}
+	}
+	if v := query.Get("after_id"); v != "" {
+		if filter.AfterID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			s.sendError(w, fmt.Sprintf("invalid after_id: %q", v), http.StatusBadRequest)
+			return
+		}
+	}
+	if filter.From, err = parseTimeParam(r, "from"); err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filter.To, err = parseTimeParam(r, "to"); err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit, err := parseLimitParam(r)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.Limit = defaultHistoryPageSize
+	if limit > 0 {
+		filter.Limit = min(limit, maxHistoryPageSize)
+	}
+
+	events, err := s.storage.ListEvents(filter)
+	if err != nil {
+		s.sendError(w, fmt.Sprintf("Failed to list events: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	lastID := filter.AfterID
+	for i := range events {
+		events[i].Time = events[i].Time.In(vehicle.Tokyo)
+		lastID = events[i].ID
+	}
+	if events == nil {
+		events = []storage.Event{}
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"events":  events,
+		"count":   len(events),
+		"last_id": lastID,
+	}, http.StatusOK)
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+)
+
+func TestHTTPServer_Events(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	base := time.Now().Add(-time.Hour)
+	if err := server.storage.SaveEvents([]storage.Event{
+		{Type: "moved", VehicleCD: 1, Time: base},
+		{Type: "driver_changed", VehicleCD: 1, Time: base.Add(time.Minute)},
+		{Type: "moved", VehicleCD: 2, Time: base.Add(2 * time.Minute)},
+	}); err != nil {
+		t.Fatalf("Failed to save events: %v", err)
+	}
+
+	tests := []struct {
+		query  string
+		status int
+		count  float64
+	}{
+		{"", http.StatusOK, 3},
+		{"?vehicle_cd=1", http.StatusOK, 2},
+		{"?type=moved", http.StatusOK, 2},
+		{"?type=moved,driver_changed&after_id=1", http.StatusOK, 2},
+		{"?limit=1", http.StatusOK, 1},
+		{"?vehicle_cd=x", http.StatusBadRequest, 0},
+		{"?from=yesterday", http.StatusBadRequest, 0},
+		{"?limit=abc", http.StatusBadRequest, 0},
+		{"?limit=-5", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/v1/events"+tt.query, nil)
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("Query %q: expected status %d, got %d", tt.query, tt.status, w.Code)
+			continue
+		}
+		var body map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&body)
+		if tt.status == http.StatusOK && body["count"] != tt.count {
+			t.Errorf("Query %q: expected %v events, got %v", tt.query, tt.count, body["count"])
+		}
+	}
+}
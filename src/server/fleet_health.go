@@ -0,0 +1,83 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/fleet"
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+// vehicleHealthView adds how long a vehicle has been in its status and since
+// its last contact, as of the request.
+type vehicleHealthView struct {
+	storage.VehicleHealth
+	DurationSeconds int64  `json:"duration_seconds"`
+	AgeSeconds      *int64 `json:"age_seconds,omitempty"`
+}
+
+// Fleet health endpoint - per-vehicle telematics data status (fresh, stale,
+// offline, gps_invalid) reclassified from the last contact as of the
+// request, with counts per status
+func (s *HTTPServer) handleFleetHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	// The stored status may be out of date, so filter by status after
+	// reclassifying rather than in the query
+	status := query.Get("status")
+	if status != "" && !slices.Contains(fleet.HealthStatuses, status) {
+		s.sendError(w, fmt.Sprintf("invalid status: %q", status), http.StatusBadRequest)
+		return
+	}
+	var filter storage.HealthFilter
+	if v := query.Get("branch_cd"); v != "" {
+		var err error
+		if filter.BranchCD, err = strconv.Atoi(v); err != nil {
+			s.sendError(w, fmt.Sprintf("invalid branch_cd: %q", v), http.StatusBadRequest)
+			return
+		}
+	}
+
+	health, err := s.storage.ListHealth(filter)
+	if err != nil {
+		s.sendError(w, fmt.Sprintf("Failed to list vehicle health: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	counts := make(map[string]int, len(fleet.HealthStatuses))
+	for _, status := range fleet.HealthStatuses {
+		counts[status] = 0
+	}
+	views := make([]vehicleHealthView, 0, len(health))
+	for _, h := range health {
+		h = fleet.CurrentHealth(h, now, s.config.HealthStaleAfter, s.config.HealthOfflineAfter)
+		if status != "" && h.Status != status {
+			continue
+		}
+		counts[h.Status]++
+		v := vehicleHealthView{VehicleHealth: h, DurationSeconds: int64(now.Sub(h.Since).Seconds())}
+		v.Since = h.Since.In(vehicle.Tokyo)
+		v.CheckedAt = h.CheckedAt.In(vehicle.Tokyo)
+		if h.LastContact != nil {
+			contact := h.LastContact.In(vehicle.Tokyo)
+			age := int64(now.Sub(contact).Seconds())
+			v.LastContact, v.AgeSeconds = &contact, &age
+		}
+		views = append(views, v)
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"vehicles": views,
+		"count":    len(views),
+		"counts":   counts,
+	}, http.StatusOK)
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+)
+
+func TestHTTPServer_FleetHealth(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	now := time.Now()
+	contact := now.Add(-time.Hour)
+	if err := server.storage.SaveHealth([]storage.VehicleHealth{
+		{VehicleCD: 1, BranchCD: 1, Status: "fresh", Since: now.Add(-2 * time.Hour), LastContact: &now, GPSValid: true, CheckedAt: now},
+		{VehicleCD: 2, BranchCD: 1, Status: "stale", Since: now.Add(-30 * time.Minute), LastContact: &contact, CheckedAt: now},
+		{VehicleCD: 3, BranchCD: 2, Status: "offline", Since: now, CheckedAt: now},
+	}); err != nil {
+		t.Fatalf("Failed to save health: %v", err)
+	}
+
+	tests := []struct {
+		query  string
+		status int
+		count  float64
+	}{
+		{"", http.StatusOK, 3},
+		{"?status=stale", http.StatusOK, 1},
+		{"?branch_cd=1", http.StatusOK, 2},
+		{"?status=broken", http.StatusBadRequest, 0},
+		{"?branch_cd=x", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/v1/fleet/health"+tt.query, nil)
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("Query %q: expected status %d, got %d", tt.query, tt.status, w.Code)
+			continue
+		}
+		var body map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&body)
+		if tt.status == http.StatusOK && body["count"] != tt.count {
+			t.Errorf("Query %q: expected %v vehicles, got %v", tt.query, tt.count, body["count"])
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/v1/fleet/health", nil)
+	w := httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+	var body struct {
+		Vehicles []map[string]interface{} `json:"vehicles"`
+		Counts   map[string]int           `json:"counts"`
+	}
+	json.NewDecoder(w.Body).Decode(&body)
+	if body.Counts["fresh"] != 1 || body.Counts["stale"] != 1 || body.Counts["offline"] != 1 || body.Counts["gps_invalid"] != 0 {
+		t.Errorf("Unexpected counts: %v", body.Counts)
+	}
+	stale := body.Vehicles[1]
+	if stale["status"] != "stale" || stale["duration_seconds"].(float64) < 1799 || stale["age_seconds"].(float64) < 3599 {
+		t.Errorf("Unexpected stale vehicle: %v", stale)
+	}
+	if _, ok := body.Vehicles[2]["age_seconds"]; ok {
+		t.Errorf("Expected no age for a vehicle never heard from, got %v", body.Vehicles[2])
+	}
+}
+
+func TestHTTPServer_FleetHealth_ReclassifiesOnRead(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	server.config.HealthStaleAfter = 30 * time.Minute
+	server.config.HealthOfflineAfter = 24 * time.Hour
+
+	// Stored fresh, but the vehicle has not reported for an hour since
+	now := time.Now()
+	contact := now.Add(-time.Hour)
+	if err := server.storage.SaveHealth([]storage.VehicleHealth{
+		{VehicleCD: 1, Status: "fresh", Since: contact, LastContact: &contact, GPSValid: true, CheckedAt: contact},
+	}); err != nil {
+		t.Fatalf("Failed to save health: %v", err)
+	}
+
+	for query, count := range map[string]float64{"?status=stale": 1, "?status=fresh": 0} {
+		req := httptest.NewRequest("GET", "/v1/fleet/health"+query, nil)
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+
+		var body map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&body)
+		if w.Code != http.StatusOK || body["count"] != count {
+			t.Errorf("Query %q: expected %v vehicles, got %d %v", query, count, w.Code, body["count"])
+		}
+	}
+}
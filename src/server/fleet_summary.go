@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/fleet"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+// Temporary struct definitions until protoc generates them
+type GetFleetSummaryRequest struct{}
+
+type GetFleetSummaryResponse struct {
+	GeneratedAt     *timestamppb.Timestamp
+	Total           int32
+	Branches        []*GroupCount
+	AllStates       []*GroupCount
+	OperationStates []*GroupCount
+	CurrentWorks    []*GroupCount
+	Freshness       []*GroupCount
+}
+
+type GroupCount struct {
+	Key   string
+	Name  string
+	Count int32
+}
+
+// Fleet summary endpoint - vehicle counts by branch, state and current work
+// as cached from the latest snapshot, and data freshness as of the request
+func (s *HTTPServer) handleFleetSummary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	summary, err := fleet.LoadSummary(s.storage, time.Now(), s.config.HealthStaleAfter, s.config.HealthOfflineAfter)
+	if err != nil {
+		s.sendError(w, fmt.Sprintf("Failed to load fleet summary: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if summary == nil {
+		s.sendError(w, "No snapshot has been summarized yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	summary.GeneratedAt = summary.GeneratedAt.In(vehicle.Tokyo)
+	s.sendJSON(w, struct {
+		*fleet.FleetSummary
+		AgeSeconds int64 `json:"age_seconds"`
+	}{summary, int64(time.Since(summary.GeneratedAt).Seconds())}, http.StatusOK)
+}
+
+// GetFleetSummary returns the cached vehicle counts of the latest snapshot,
+// with freshness classified as of the request
+func (s *GRPCServer) GetFleetSummary(ctx context.Context, req *GetFleetSummaryRequest) (*GetFleetSummaryResponse, error) {
+	log.Println("GetFleetSummary called")
+
+	summary, err := fleet.LoadSummary(s.storage, time.Now(), s.config.HealthStaleAfter, s.config.HealthOfflineAfter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fleet summary: %w", err)
+	}
+	if summary == nil {
+		return nil, fmt.Errorf("no snapshot has been summarized yet")
+	}
+
+	return &GetFleetSummaryResponse{
+		GeneratedAt:     timestamppb.New(summary.GeneratedAt),
+		Total:           int32(summary.Total),
+		Branches:        toPbGroupCounts(summary.Branches),
+		AllStates:       toPbGroupCounts(summary.AllStates),
+		OperationStates: toPbGroupCounts(summary.OperationStates),
+		CurrentWorks:    toPbGroupCounts(summary.CurrentWorks),
+		Freshness:       toPbGroupCounts(summary.Freshness),
+	}, nil
+}
+
+func toPbGroupCounts(groups []fleet.GroupCount) []*GroupCount {
+	pb := make([]*GroupCount, len(groups))
+	for i, g := range groups {
+		pb[i] = &GroupCount{Key: g.Key, Name: g.Name, Count: int32(g.Count)}
+	}
+	return pb
+}
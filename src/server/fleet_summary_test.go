@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/browser"
+	"github.com/yhonda-ohishi/browser_render_go/src/fleet"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+func TestHTTPServer_FleetSummary(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	get := func() (int, map[string]interface{}) {
+		req := httptest.NewRequest("GET", "/v1/fleet/summary", nil)
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		var body map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&body)
+		return w.Code, body
+	}
+
+	if code, _ := get(); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 before the first snapshot, got %d", code)
+	}
+
+	now := time.Now()
+	cache := fleet.NewSummaryCache(30*time.Minute, 24*time.Hour, server.storage)
+	if err := cache.Write(context.Background(), &browser.Snapshot{
+		BranchID:  browser.DefaultBranchID,
+		FetchedAt: now,
+		Vehicles: []browser.VehicleData{
+			{DataTime: &now, Telemetry: &vehicle.Telemetry{VehicleCD: 1, BranchCD: 1, BranchName: "本社", AllState: "運行中"}},
+			{DataTime: &now, Telemetry: &vehicle.Telemetry{VehicleCD: 2, BranchCD: 2, BranchName: "大阪", AllState: "運行中"}},
+		},
+	}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	code, body := get()
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if body["total"] != float64(2) || len(body["branches"].([]interface{})) != 2 {
+		t.Errorf("Unexpected summary: %v", body)
+	}
+	states := body["all_states"].([]interface{})
+	if len(states) != 1 || states[0].(map[string]interface{})["count"] != float64(2) {
+		t.Errorf("Unexpected state counts: %v", states)
+	}
+	if _, ok := body["age_seconds"]; !ok {
+		t.Error("Expected age_seconds in the response")
+	}
+
+	grpcServer := NewGRPCServer(server.config, server.storage, nil)
+	resp, err := grpcServer.GetFleetSummary(context.Background(), &GetFleetSummaryRequest{})
+	if err != nil {
+		t.Fatalf("GetFleetSummary failed: %v", err)
+	}
+	if resp.Total != 2 || len(resp.Branches) != 2 || resp.Branches[0].Name != "本社" || len(resp.Freshness) != len(fleet.HealthStatuses) {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+}
+
+func TestHTTPServer_FleetSummary_FreshnessOnRead(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	server.config.HealthStaleAfter = 30 * time.Minute
+	server.config.HealthOfflineAfter = 24 * time.Hour
+
+	// Fresh when cached two hours ago, stale by now
+	cachedAt := time.Now().Add(-2 * time.Hour)
+	cache := fleet.NewSummaryCache(30*time.Minute, 24*time.Hour, server.storage)
+	if err := cache.Write(context.Background(), &browser.Snapshot{
+		BranchID:  browser.DefaultBranchID,
+		FetchedAt: cachedAt,
+		Vehicles: []browser.VehicleData{
+			{DataTime: &cachedAt, Position: &vehicle.Position{Valid: true}, Telemetry: &vehicle.Telemetry{VehicleCD: 1}},
+		},
+	}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/v1/fleet/summary", nil)
+	w := httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+	var body struct {
+		Freshness []fleet.GroupCount `json:"freshness"`
+	}
+	json.NewDecoder(w.Body).Decode(&body)
+	for _, g := range body.Freshness {
+		if want := map[string]int{fleet.HealthStale: 1}[g.Key]; g.Count != want {
+			t.Errorf("Expected %d %s vehicles, got %d", want, g.Key, g.Count)
+		}
+	}
+	if len(body.Freshness) != len(fleet.HealthStatuses) {
+		t.Errorf("Unexpected freshness: %+v", body.Freshness)
+	}
+}
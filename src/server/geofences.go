@@ -0,0 +1,131 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/fleet"
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+)
+
+// maxGeofenceSize limits geofence request bodies
+const maxGeofenceSize = 1 << 20
+
+// Geofences endpoint - lists or creates geofences
+func (s *HTTPServer) handleGeofences(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		fences, err := s.storage.ListGeofences()
+		if err != nil {
+			s.sendError(w, fmt.Sprintf("Failed to list geofences: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if fences == nil {
+			fences = []storage.Geofence{}
+		}
+		s.sendJSON(w, map[string]interface{}{
+			"geofences": fences,
+			"count":     len(fences),
+		}, http.StatusOK)
+
+	case http.MethodPost:
+		g, ok := s.readGeofence(w, r)
+		if !ok {
+			return
+		}
+		if err := s.storage.CreateGeofence(g); err != nil {
+			s.sendError(w, fmt.Sprintf("Failed to create geofence: %v", err), http.StatusInternalServerError)
+			return
+		}
+		s.sendStoredGeofence(w, g.ID, http.StatusCreated)
+
+	default:
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Geofence endpoint - gets, replaces or deletes a single geofence
+func (s *HTTPServer) handleGeofence(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.URL.Path[len("/v1/geofences/"):], 10, 64)
+	if err != nil || id <= 0 {
+		s.sendError(w, "Invalid geofence ID", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.sendStoredGeofence(w, id, http.StatusOK)
+
+	case http.MethodPut:
+		g, ok := s.readGeofence(w, r)
+		if !ok {
+			return
+		}
+		g.ID = id
+		found, err := s.storage.UpdateGeofence(g)
+		if err != nil {
+			s.sendError(w, fmt.Sprintf("Failed to update geofence: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			s.sendError(w, "Geofence not found", http.StatusNotFound)
+			return
+		}
+		s.sendStoredGeofence(w, id, http.StatusOK)
+
+	case http.MethodDelete:
+		found, err := s.storage.DeleteGeofence(id)
+		if err != nil {
+			s.sendError(w, fmt.Sprintf("Failed to delete geofence: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			s.sendError(w, "Geofence not found", http.StatusNotFound)
+			return
+		}
+		s.sendJSON(w, map[string]interface{}{
+			"success": true,
+			"message": "Geofence deleted",
+		}, http.StatusOK)
+
+	default:
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// readGeofence decodes and validates a geofence request body, writing the
+// error response itself when it fails
+func (s *HTTPServer) readGeofence(w http.ResponseWriter, r *http.Request) (*storage.Geofence, bool) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxGeofenceSize))
+	if err != nil {
+		s.sendError(w, fmt.Sprintf("Failed to read request body: %v", err), http.StatusBadRequest)
+		return nil, false
+	}
+
+	var g storage.Geofence
+	if err := json.Unmarshal(body, &g); err != nil {
+		s.sendError(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return nil, false
+	}
+	if err := fleet.ValidateGeofence(&g); err != nil {
+		s.sendError(w, fmt.Sprintf("Invalid geofence: %v", err), http.StatusBadRequest)
+		return nil, false
+	}
+	return &g, true
+}
+
+func (s *HTTPServer) sendStoredGeofence(w http.ResponseWriter, id int64, status int) {
+	g, err := s.storage.GetGeofence(id)
+	if err != nil {
+		s.sendError(w, fmt.Sprintf("Failed to get geofence: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if g == nil {
+		s.sendError(w, "Geofence not found", http.StatusNotFound)
+		return
+	}
+	s.sendJSON(w, g, status)
+}
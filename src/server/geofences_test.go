@@ -0,0 +1,68 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPServer_Geofences(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	do := func(method, path, body string) (int, map[string]interface{}) {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		var resp map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w.Code, resp
+	}
+
+	code, created := do("POST", "/v1/geofences", `{"name":"Depot","category":"depot","shape":"circle","latitude":35.0,"longitude":139.0,"radius":200}`)
+	if code != http.StatusCreated || created["id"] == nil {
+		t.Fatalf("Expected status 201, got %d: %v", code, created)
+	}
+	path := fmt.Sprintf("/v1/geofences/%d", int64(created["id"].(float64)))
+
+	code, _ = do("PUT", path, `{"name":"Yard","shape":"polygon","polygon":[[35,139],[35,139.1],[35.1,139.1]]}`)
+	if code != http.StatusOK {
+		t.Fatalf("Expected status 200 for update, got %d", code)
+	}
+	_, got := do("GET", path, "")
+	if got["name"] != "Yard" || got["shape"] != "polygon" {
+		t.Errorf("Expected the updated geofence, got %v", got)
+	}
+
+	_, list := do("GET", "/v1/geofences", "")
+	if list["count"] != float64(1) {
+		t.Errorf("Expected 1 geofence, got %v", list["count"])
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"invalid shape", "POST", "/v1/geofences", `{"name":"X","shape":"square"}`, http.StatusBadRequest},
+		{"invalid json", "POST", "/v1/geofences", `{`, http.StatusBadRequest},
+		{"invalid id", "GET", "/v1/geofences/abc", "", http.StatusBadRequest},
+		{"unknown", "GET", "/v1/geofences/999", "", http.StatusNotFound},
+		{"update unknown", "PUT", "/v1/geofences/999", `{"name":"X","shape":"circle","latitude":35,"longitude":139,"radius":1}`, http.StatusNotFound},
+		{"delete", "DELETE", path, "", http.StatusOK},
+		{"delete again", "DELETE", path, "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, body := do(tt.method, tt.path, tt.body); code != tt.status {
+				t.Errorf("Expected status %d, got %d: %v", tt.status, code, body)
+			}
+		})
+	}
+}
@@ -2,12 +2,15 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/yhonda-ohishi/browser_render_go/src/browser"
 	"github.com/yhonda-ohishi/browser_render_go/src/config"
@@ -32,10 +35,19 @@ type GetVehicleDataResponse struct {
 }
 
 type VehicleData struct {
+	VehicleId   int64
 	VehicleCd   string
 	VehicleName string
 	Status      string
 	Metadata    map[string]string
+	Telemetry   *VehicleTelemetry
+	Plate       *Plate
+	Position    *Position
+	Raw         *structpb.Struct
+
+	DataTime      *timestamppb.Timestamp
+	ComuTime      *timestamppb.Timestamp
+	StartWorkTime *timestamppb.Timestamp
 }
 
 type CheckSessionRequest struct {
@@ -56,6 +68,35 @@ type ClearSessionResponse struct {
 	Message string
 }
 
+type ListBranchesRequest struct {
+	SessionId string
+	Refresh   bool
+}
+
+type ListBranchesResponse struct {
+	Branches []*Branch
+}
+
+type Branch struct {
+	BranchId   string
+	BranchCd   int32
+	BranchName string
+}
+
+type ListFiltersRequest struct {
+	SessionId string
+	Refresh   bool
+}
+
+type ListFiltersResponse struct {
+	Filters []*Filter
+}
+
+type Filter struct {
+	FilterId   string
+	FilterName string
+}
+
 type HealthCheckRequest struct{}
 
 type HealthCheckResponse struct {
@@ -97,9 +138,14 @@ func (s *GRPCServer) GetVehicleData(ctx context.Context, req *GetVehicleDataRequ
 	)
 	if err != nil {
 		log.Printf("Error getting vehicle data: %v", err)
+		statusCode := int32(500)
+		var suspect *browser.SuspectScrapeError
+		if errors.As(err, &suspect) {
+			statusCode = 409
+		}
 		return &GetVehicleDataResponse{
 			Status:     err.Error(),
-			StatusCode: 500,
+			StatusCode: statusCode,
 			Data:       []*VehicleData{},
 		}, nil
 	}
@@ -107,12 +153,7 @@ func (s *GRPCServer) GetVehicleData(ctx context.Context, req *GetVehicleDataRequ
 	// Convert to protobuf format
 	pbVehicleData := make([]*VehicleData, len(vehicleData))
 	for i, v := range vehicleData {
-		pbVehicleData[i] = &VehicleData{
-			VehicleCd:   v.VehicleCD,
-			VehicleName: v.VehicleName,
-			Status:      v.Status,
-			Metadata:    v.Metadata,
-		}
+		pbVehicleData[i] = toPbVehicleData(v)
 	}
 
 	return &GetVehicleDataResponse{
@@ -166,6 +207,47 @@ func (s *GRPCServer) ClearSession(ctx context.Context, req *ClearSessionRequest)
 	}, nil
 }
 
+// ListBranches returns the branches available in the portal
+func (s *GRPCServer) ListBranches(ctx context.Context, req *ListBranchesRequest) (*ListBranchesResponse, error) {
+	log.Printf("ListBranches called with refresh=%v", req.Refresh)
+
+	branches, err := s.renderer.GetBranches(ctx, req.SessionId, req.Refresh)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get branches: %w", err)
+	}
+
+	pbBranches := make([]*Branch, len(branches))
+	for i, b := range branches {
+		pbBranches[i] = &Branch{
+			BranchId:   b.BranchID,
+			BranchCd:   int32(b.BranchCD),
+			BranchName: b.BranchName,
+		}
+	}
+
+	return &ListBranchesResponse{Branches: pbBranches}, nil
+}
+
+// ListFilters returns the vehicle list filters available in the portal
+func (s *GRPCServer) ListFilters(ctx context.Context, req *ListFiltersRequest) (*ListFiltersResponse, error) {
+	log.Printf("ListFilters called with refresh=%v", req.Refresh)
+
+	filters, err := s.renderer.GetFilters(ctx, req.SessionId, req.Refresh)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get filters: %w", err)
+	}
+
+	pbFilters := make([]*Filter, len(filters))
+	for i, f := range filters {
+		pbFilters[i] = &Filter{
+			FilterId:   f.FilterID,
+			FilterName: f.FilterName,
+		}
+	}
+
+	return &ListFiltersResponse{Filters: pbFilters}, nil
+}
+
 // HealthCheck returns the service health status
 func (s *GRPCServer) HealthCheck(ctx context.Context, req *HealthCheckRequest) (*HealthCheckResponse, error) {
 	uptime := int64(time.Since(s.startTime).Seconds())
@@ -0,0 +1,411 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+// Temporary struct definitions until protoc generates them
+type GetVehicleHistoryRequest struct {
+	VehicleCd int64
+	From      *timestamppb.Timestamp
+	To        *timestamppb.Timestamp
+	PageSize  int32
+	PageToken string
+	Fields    []string
+}
+
+type GetVehicleHistoryResponse struct {
+	Points        []*TelemetryPoint
+	NextPageToken string
+}
+
+type GetFleetAtRequest struct {
+	Time      *timestamppb.Timestamp
+	MaxAge    int64 // seconds, 0 for no limit
+	PageSize  int32
+	PageToken string
+	Fields    []string
+}
+
+type GetFleetAtResponse struct {
+	Time          *timestamppb.Timestamp
+	Points        []*TelemetryPoint
+	NextPageToken string
+}
+
+type TelemetryPoint struct {
+	VehicleCd    int64
+	VehicleId    int64
+	VehicleName  string
+	BranchCd     int32
+	DriverCd     int32
+	Speed        float64
+	DataDateTime string
+	DataTime     *timestamppb.Timestamp
+	FetchedAt    *timestamppb.Timestamp
+	Latitude     float64
+	Longitude    float64
+	HasPosition  bool
+	GpsValid     bool
+	Raw          *structpb.Struct
+}
+
+const (
+	defaultHistoryPageSize = 100
+	maxHistoryPageSize     = 1000
+)
+
+// historyQuery is the parsed paging and projection parameters shared by the
+// history endpoints.
+type historyQuery struct {
+	pageSize  int
+	pageToken int64
+	fields    []string
+}
+
+func newHistoryQuery(pageSize int, pageToken string, fields []string) (historyQuery, error) {
+	q := historyQuery{pageSize: pageSize, fields: fields}
+	if q.pageSize <= 0 {
+		q.pageSize = defaultHistoryPageSize
+	}
+	if q.pageSize > maxHistoryPageSize {
+		q.pageSize = maxHistoryPageSize
+	}
+	if pageToken != "" {
+		token, err := strconv.ParseInt(pageToken, 10, 64)
+		if err != nil {
+			return q, fmt.Errorf("invalid page_token %q", pageToken)
+		}
+		q.pageToken = token
+	}
+	return q, nil
+}
+
+// vehicleHistory returns one page of a vehicle's records in [from, to). The
+// page token is the unix time to resume from.
+func vehicleHistory(store *storage.Storage, vehicleCD int64, from, to time.Time, q historyQuery) ([]storage.TelemetryRecord, string, error) {
+	if q.pageToken != 0 {
+		from = time.Unix(q.pageToken, 0)
+	}
+	records, err := store.ListTelemetry(vehicleCD, from, to, q.pageSize+1)
+	if err != nil {
+		return nil, "", err
+	}
+	next := ""
+	if len(records) > q.pageSize {
+		records = records[:q.pageSize]
+		next = strconv.FormatInt(records[len(records)-1].DataTime.Unix()+1, 10)
+	}
+	return records, next, nil
+}
+
+// fleetAt returns one page of the latest record per vehicle as of at. The
+// page token is the last VehicleCD returned.
+func fleetAt(store *storage.Storage, at time.Time, maxAge time.Duration, q historyQuery) ([]storage.TelemetryRecord, string, error) {
+	var since time.Time
+	if maxAge > 0 {
+		since = at.Add(-maxAge)
+	}
+	records, err := store.ListFleetAt(at, since, q.pageToken, q.pageSize+1)
+	if err != nil {
+		return nil, "", err
+	}
+	next := ""
+	if len(records) > q.pageSize {
+		records = records[:q.pageSize]
+		next = strconv.FormatInt(records[len(records)-1].VehicleCD, 10)
+	}
+	return records, next, nil
+}
+
+// projectTelemetry returns the record as a JSON object. When fields is set,
+// only those keys are kept; keys that are not stored columns are looked up in
+// the raw portal record, so e.g. "GPSDirection" can be requested as well.
+func projectTelemetry(r storage.TelemetryRecord, fields []string) map[string]interface{} {
+	r.DataTime = r.DataTime.In(vehicle.Tokyo)
+
+	full := make(map[string]interface{})
+	data, _ := json.Marshal(r)
+	json.Unmarshal(data, &full)
+	if len(fields) == 0 {
+		return full
+	}
+
+	var raw map[string]interface{}
+	projected := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		if v, ok := full[field]; ok {
+			projected[field] = v
+			continue
+		}
+		if raw == nil {
+			raw = rawRecord(r)
+		}
+		if v, ok := raw[field]; ok {
+			projected[field] = v
+		}
+	}
+	return projected
+}
+
+func rawRecord(r storage.TelemetryRecord) map[string]interface{} {
+	raw := make(map[string]interface{})
+	if r.Record != "" {
+		if err := json.Unmarshal([]byte(r.Record), &raw); err != nil {
+			log.Printf("Warning: invalid stored record for vehicle %d: %v", r.VehicleCD, err)
+		}
+	}
+	return raw
+}
+
+// parseFields splits a comma-separated fields parameter.
+func parseFields(s string) []string {
+	var fields []string
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// parseTimeParam parses an optional time query parameter. Portal style
+// values are read as Asia/Tokyo.
+func parseTimeParam(r *http.Request, name string) (time.Time, error) {
+	v := r.URL.Query().Get(name)
+	t, err := vehicle.ParseTime(v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: %q", name, v)
+	}
+	return t, nil
+}
+
+// Vehicle endpoint - serves /v1/vehicles/{cd}/history and /v1/vehicles/{cd}/trips
+func (s *HTTPServer) handleVehicles(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path[len("/v1/vehicles/"):]
+	cdStr, action, _ := strings.Cut(path, "/")
+
+	vehicleCD, err := strconv.ParseInt(cdStr, 10, 64)
+	if err != nil || vehicleCD <= 0 {
+		s.sendError(w, "Invalid vehicle code", http.StatusBadRequest)
+		return
+	}
+
+	switch action {
+	case "history":
+		s.handleVehicleHistory(w, r, vehicleCD)
+	case "trips":
+		s.handleVehicleTrips(w, r, vehicleCD)
+	default:
+		s.notFound(w, r)
+	}
+}
+
+// handleVehicleHistory returns a vehicle's stored telemetry, oldest first
+func (s *HTTPServer) handleVehicleHistory(w http.ResponseWriter, r *http.Request, vehicleCD int64) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	from, err := parseTimeParam(r, "from")
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	to, err := parseTimeParam(r, "to")
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	limit, err := parseLimitParam(r)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q, err := newHistoryQuery(limit, query.Get("page_token"), parseFields(query.Get("fields")))
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	records, next, err := vehicleHistory(s.storage, vehicleCD, from, to, q)
+	if err != nil {
+		s.sendError(w, fmt.Sprintf("Failed to query history: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	points := make([]map[string]interface{}, len(records))
+	for i, rec := range records {
+		points[i] = projectTelemetry(rec, q.fields)
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"vehicle_cd":      vehicleCD,
+		"points":          points,
+		"count":           len(points),
+		"next_page_token": next,
+	}, http.StatusOK)
+}
+
+// Fleet state endpoint - latest stored record of every vehicle as of a time
+func (s *HTTPServer) handleFleetAt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	at, err := parseTimeParam(r, "time")
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	query := r.URL.Query()
+	var maxAge time.Duration
+	if v := query.Get("max_age"); v != "" {
+		if maxAge, err = time.ParseDuration(v); err != nil || maxAge < 0 {
+			s.sendError(w, fmt.Sprintf("invalid max_age: %q", v), http.StatusBadRequest)
+			return
+		}
+	}
+
+	limit, err := parseLimitParam(r)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q, err := newHistoryQuery(limit, query.Get("page_token"), parseFields(query.Get("fields")))
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	records, next, err := fleetAt(s.storage, at, maxAge, q)
+	if err != nil {
+		s.sendError(w, fmt.Sprintf("Failed to query fleet state: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	vehicles := make([]map[string]interface{}, len(records))
+	for i, rec := range records {
+		vehicles[i] = projectTelemetry(rec, q.fields)
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"time":            at.In(vehicle.Tokyo),
+		"vehicles":        vehicles,
+		"count":           len(vehicles),
+		"next_page_token": next,
+	}, http.StatusOK)
+}
+
+// GetVehicleHistory returns a vehicle's stored telemetry, oldest first
+func (s *GRPCServer) GetVehicleHistory(ctx context.Context, req *GetVehicleHistoryRequest) (*GetVehicleHistoryResponse, error) {
+	log.Printf("GetVehicleHistory called with vehicle_cd=%d", req.VehicleCd)
+
+	if req.VehicleCd <= 0 {
+		return nil, fmt.Errorf("vehicle_cd is required")
+	}
+	q, err := newHistoryQuery(int(req.PageSize), req.PageToken, req.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	records, next, err := vehicleHistory(s.storage, req.VehicleCd, fromPbTimestamp(req.From), fromPbTimestamp(req.To), q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+
+	return &GetVehicleHistoryResponse{
+		Points:        toPbTelemetryPoints(records, q.fields),
+		NextPageToken: next,
+	}, nil
+}
+
+// GetFleetAt returns the latest stored record of every vehicle as of a time
+func (s *GRPCServer) GetFleetAt(ctx context.Context, req *GetFleetAtRequest) (*GetFleetAtResponse, error) {
+	at := fromPbTimestamp(req.Time)
+	if at.IsZero() {
+		at = time.Now()
+	}
+	log.Printf("GetFleetAt called with time=%s", at.Format(time.RFC3339))
+
+	q, err := newHistoryQuery(int(req.PageSize), req.PageToken, req.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	records, next, err := fleetAt(s.storage, at, time.Duration(req.MaxAge)*time.Second, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query fleet state: %w", err)
+	}
+
+	return &GetFleetAtResponse{
+		Time:          timestamppb.New(at),
+		Points:        toPbTelemetryPoints(records, q.fields),
+		NextPageToken: next,
+	}, nil
+}
+
+func fromPbTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+// toPbTelemetryPoints converts stored records. The typed fields are always
+// set; fields limits the raw record to the requested keys.
+func toPbTelemetryPoints(records []storage.TelemetryRecord, fields []string) []*TelemetryPoint {
+	points := make([]*TelemetryPoint, len(records))
+	for i, r := range records {
+		raw := rawRecord(r)
+		if len(fields) > 0 {
+			projected := make(map[string]interface{}, len(fields))
+			for _, field := range fields {
+				if v, ok := raw[field]; ok {
+					projected[field] = v
+				}
+			}
+			raw = projected
+		}
+
+		p := &TelemetryPoint{
+			VehicleCd:    r.VehicleCD,
+			VehicleId:    r.VehicleID,
+			VehicleName:  r.VehicleName,
+			BranchCd:     int32(r.BranchCD),
+			DriverCd:     int32(r.DriverCD),
+			Speed:        r.Speed,
+			DataDateTime: r.DataDateTime,
+			DataTime:     timestamppb.New(r.DataTime),
+			FetchedAt:    timestamppb.New(r.FetchedAt),
+			GpsValid:     r.GPSValid,
+			Raw:          toPbStruct(raw),
+		}
+		if r.Latitude != nil && r.Longitude != nil {
+			p.Latitude, p.Longitude, p.HasPosition = *r.Latitude, *r.Longitude, true
+		}
+		points[i] = p
+	}
+	return points
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+func saveTestTelemetry(t *testing.T, store *storage.Storage) time.Time {
+	t.Helper()
+	// 2025/01/02 14:00 in Tokyo
+	base := time.Date(2025, 1, 2, 14, 0, 0, 0, vehicle.Tokyo)
+	var records []storage.TelemetryRecord
+	for i := 0; i < 5; i++ {
+		records = append(records, storage.TelemetryRecord{
+			VehicleCD: 1, DataTime: base.Add(time.Duration(i) * 5 * time.Minute), VehicleName: "Truck",
+			Speed: float64(i * 10), Record: `{"VehicleCD":1,"GPSDirection":90}`,
+		})
+	}
+	records = append(records, storage.TelemetryRecord{VehicleCD: 2, DataTime: base.Add(time.Minute), VehicleName: "Van", Record: `{}`})
+	if err := store.SaveTelemetry(records); err != nil {
+		t.Fatalf("Failed to save telemetry: %v", err)
+	}
+	return base
+}
+
+func TestHTTPServer_VehicleHistory(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	saveTestTelemetry(t, server.storage)
+
+	get := func(path string) (int, map[string]interface{}) {
+		req := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		var body map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&body)
+		return w.Code, body
+	}
+
+	code, body := get("/v1/vehicles/1/history?from=2025/01/02%2014:05&to=2025/01/02%2014:20")
+	if code != http.StatusOK || body["count"] != float64(3) {
+		t.Fatalf("Expected 3 points, got %d: %v", code, body)
+	}
+
+	// Paging walks through every record exactly once
+	seen := 0
+	path := "/v1/vehicles/1/history?limit=2"
+	for page := 0; page < 5; page++ {
+		_, body = get(path)
+		seen += int(body["count"].(float64))
+		token, _ := body["next_page_token"].(string)
+		if token == "" {
+			break
+		}
+		path = "/v1/vehicles/1/history?limit=2&page_token=" + token
+	}
+	if seen != 5 {
+		t.Errorf("Expected 5 points across pages, got %d", seen)
+	}
+
+	// Projection keeps requested columns and raw portal fields only
+	_, body = get("/v1/vehicles/1/history?limit=1&fields=Speed,GPSDirection")
+	point := body["points"].([]interface{})[0].(map[string]interface{})
+	if len(point) != 2 || point["GPSDirection"] != float64(90) {
+		t.Errorf("Unexpected projected point: %v", point)
+	}
+
+	for _, path := range []string{
+		"/v1/vehicles/abc/history",
+		"/v1/vehicles/1/history?from=yesterday",
+		"/v1/vehicles/1/history?page_token=x",
+		"/v1/vehicles/1/history?limit=ten",
+		"/v1/vehicles/1/history?limit=-1",
+	} {
+		if code, _ := get(path); code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", path, code)
+		}
+	}
+}
+
+func TestHTTPServer_FleetAt(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	saveTestTelemetry(t, server.storage)
+
+	tests := []struct {
+		query string
+		count float64
+		speed float64
+	}{
+		{"?time=2025/01/02%2014:05", 2, 10},
+		{"?time=2025/01/02%2014:00", 1, 0},
+		{"?time=2025/01/02%2014:12&max_age=5m", 1, 20},
+		{"?time=2025/01/02%2013:00", 0, 0},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/v1/fleet/at"+tt.query, nil)
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+
+		var body map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&body)
+		if w.Code != http.StatusOK || body["count"] != tt.count {
+			t.Errorf("Query %q: expected %v vehicles, got %d: %v", tt.query, tt.count, w.Code, body)
+			continue
+		}
+		if tt.count > 0 {
+			first := body["vehicles"].([]interface{})[0].(map[string]interface{})
+			if first["Speed"] != tt.speed {
+				t.Errorf("Query %q: expected speed %v, got %v", tt.query, tt.speed, first["Speed"])
+			}
+		}
+	}
+
+	for _, query := range []string{"?time=soon", "?max_age=old", "?limit=1x"} {
+		req := httptest.NewRequest("GET", "/v1/fleet/at"+query, nil)
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Query %q: expected status 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestGRPCServer_GetVehicleHistory(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	saveTestTelemetry(t, server.storage)
+
+	grpcServer := NewGRPCServer(server.config, server.storage, nil)
+	resp, err := grpcServer.GetVehicleHistory(context.Background(), &GetVehicleHistoryRequest{VehicleCd: 1, PageSize: 3, Fields: []string{"GPSDirection"}})
+	if err != nil {
+		t.Fatalf("GetVehicleHistory failed: %v", err)
+	}
+	if len(resp.Points) != 3 || resp.NextPageToken == "" {
+		t.Fatalf("Expected a full page with a next token, got %d points and %q", len(resp.Points), resp.NextPageToken)
+	}
+	if raw := resp.Points[0].Raw.AsMap(); len(raw) != 1 || raw["GPSDirection"] != float64(90) {
+		t.Errorf("Expected projected raw record, got %v", raw)
+	}
+
+	fleet, err := grpcServer.GetFleetAt(context.Background(), &GetFleetAtRequest{})
+	if err != nil || len(fleet.Points) != 2 {
+		t.Errorf("Expected 2 vehicles in current fleet state, got %v (%v)", fleet, err)
+	}
+}
@@ -1,10 +1,13 @@
 package server
 
 import (
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/yhonda-ohishi/browser_render_go/src/browser"
@@ -18,6 +21,7 @@ type HTTPServer struct {
 	config     *config.Config
 	storage    *storage.Storage
 	renderer   *browser.Renderer
+	scrapes    scrapeReviewer
 	jobManager *jobs.Manager
 	startTime  time.Time
 	mux        *http.ServeMux
@@ -29,6 +33,7 @@ func NewHTTPServer(cfg *config.Config, store *storage.Storage, renderer *browser
 		config:     cfg,
 		storage:    store,
 		renderer:   renderer,
+		scrapes:    renderer,
 		jobManager: jobs.NewManager(renderer),
 		startTime:  time.Now(),
 		mux:        http.NewServeMux(),
@@ -40,10 +45,38 @@ func NewHTTPServer(cfg *config.Config, store *storage.Storage, renderer *browser
 func (s *HTTPServer) setupRoutes() {
 	// API endpoints
 	s.mux.HandleFunc("/v1/vehicle/data", s.handleVehicleData)
+	s.mux.HandleFunc("/v1/vehicle/stream", s.handleVehicleStream)
 	s.mux.HandleFunc("/v1/job/", s.handleJobStatus)
 	s.mux.HandleFunc("/v1/jobs", s.handleJobsList)
+	s.mux.HandleFunc("/v1/scrapes", s.handleScrapes)
+	s.mux.HandleFunc("/v1/scrapes/confirm", s.handleScrapeConfirm)
 	s.mux.HandleFunc("/v1/session/check", s.handleSessionCheck)
 	s.mux.HandleFunc("/v1/session/clear", s.handleSessionClear)
+	s.mux.HandleFunc("/v1/branches", s.handleBranches)
+	s.mux.HandleFunc("/v1/filters", s.handleFilters)
+	s.mux.HandleFunc("/v1/render/screenshot", s.handleRenderScreenshot)
+	s.mux.HandleFunc("/v1/render/pdf", s.handleRenderPDF)
+	s.mux.HandleFunc("/v1/renders", s.handleRendersList)
+	s.mux.HandleFunc("/v1/renders/", s.handleRender)
+	s.mux.HandleFunc("/v1/downloads", s.handleDownloads)
+	s.mux.HandleFunc("/v1/downloads/", s.handleDownload)
+	s.mux.HandleFunc("/v1/recipes", s.handleRecipes)
+	s.mux.HandleFunc("/v1/recipes/", s.handleRecipe)
+	s.mux.HandleFunc("/v1/admin/vehicles", s.handleRegistryVehicles)
+	s.mux.HandleFunc("/v1/admin/vehicles/", s.handleRegistryVehicle)
+	s.mux.HandleFunc("/v1/vehicles", s.handleVehicleSearch)
+	s.mux.HandleFunc("/v1/vehicles/", s.handleVehicles)
+	s.mux.HandleFunc("/v1/fleet/at", s.handleFleetAt)
+	s.mux.HandleFunc("/v1/fleet/health", s.handleFleetHealth)
+	s.mux.HandleFunc("/v1/fleet/nearest", s.handleNearestVehicles)
+	s.mux.HandleFunc("/v1/fleet/summary", s.handleFleetSummary)
+	s.mux.HandleFunc("/v1/events", s.handleEvents)
+	s.mux.HandleFunc("/v1/temperature/excursions", s.handleTemperatureExcursions)
+	s.mux.HandleFunc("/v1/geofences", s.handleGeofences)
+	s.mux.HandleFunc("/v1/geofences/", s.handleGeofence)
+	s.mux.HandleFunc("/v1/reports/trips", s.handleTripReport)
+	s.mux.HandleFunc("/v1/reports/drivers", s.handleDriverReport)
+	s.mux.HandleFunc("/v1/reports/driving", s.handleDrivingReport)
 
 	// Health and metrics
 	s.mux.HandleFunc("/health", s.handleHealth)
@@ -78,8 +111,9 @@ func (s *HTTPServer) handleVehicleData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create a new job
-	jobID := s.jobManager.CreateJob()
+	// Create a new job for the requested branch and filter (defaults if empty)
+	query := r.URL.Query()
+	jobID := s.jobManager.CreateJob(query.Get("branch_id"), query.Get("filter_id"))
 	log.Printf("Created new job: %s", jobID)
 
 	// Return job ID immediately
@@ -181,6 +215,48 @@ func (s *HTTPServer) handleSessionClear(w http.ResponseWriter, r *http.Request)
 	}, http.StatusOK)
 }
 
+// Branch list endpoint
+func (s *HTTPServer) handleBranches(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	refresh := query.Get("refresh") == "true"
+	branches, err := s.renderer.GetBranches(r.Context(), query.Get("session_id"), refresh)
+	if err != nil {
+		s.sendError(w, fmt.Sprintf("Failed to get branches: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"branches": branches,
+		"count":    len(branches),
+	}, http.StatusOK)
+}
+
+// Filter list endpoint
+func (s *HTTPServer) handleFilters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	refresh := query.Get("refresh") == "true"
+	filters, err := s.renderer.GetFilters(r.Context(), query.Get("session_id"), refresh)
+	if err != nil {
+		s.sendError(w, fmt.Sprintf("Failed to get filters: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"filters": filters,
+		"count":   len(filters),
+	}, http.StatusOK)
+}
+
 // Health check endpoint
 func (s *HTTPServer) handleHealth(w http.ResponseWriter, r *http.Request) {
 	uptime := time.Since(s.startTime).Seconds()
@@ -216,12 +292,50 @@ func (s *HTTPServer) sendJSON(w http.ResponseWriter, data interface{}, status in
 	}
 }
 
+func (s *HTTPServer) sendBinary(w http.ResponseWriter, contentType, filename string, data []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	if filename != "" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(data); err != nil {
+		log.Printf("Error writing binary response: %v", err)
+	}
+}
+
+// sendCSV writes rows as a CSV attachment. The UTF-8 BOM lets Excel open
+// Japanese text without garbling it.
+func (s *HTTPServer) sendCSV(w http.ResponseWriter, filename string, rows [][]string) {
+	var buf bytes.Buffer
+	buf.WriteString("\ufeff")
+	cw := csv.NewWriter(&buf)
+	if err := cw.WriteAll(rows); err != nil {
+		s.sendError(w, fmt.Sprintf("Failed to write CSV: %v", err), http.StatusInternalServerError)
+		return
+	}
+	s.sendBinary(w, "text/csv; charset=utf-8", filename, buf.Bytes())
+}
+
 func (s *HTTPServer) sendError(w http.ResponseWriter, message string, status int) {
 	s.sendJSON(w, map[string]interface{}{
 		"error": message,
 	}, status)
 }
 
+// parseLimitParam parses the optional limit query parameter; 0 when unset.
+func parseLimitParam(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(v)
+	if err != nil || limit <= 0 {
+		return 0, fmt.Errorf("invalid limit: %q", v)
+	}
+	return limit, nil
+}
+
 // Start starts the HTTP server
 func (s *HTTPServer) Start(address string) error {
 	server := &http.Server{
@@ -0,0 +1,230 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/fleet"
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+// Temporary struct definitions until protoc generates them
+type GetNearestVehiclesRequest struct {
+	Latitude     float64
+	Longitude    float64
+	BranchCd     int32
+	States       []string
+	HasDriver    *bool
+	Refrigerated *bool
+	MaxDistance  float64 // meters, 0 for no limit
+	MaxAge       int64   // seconds, 0 for no limit
+	Limit        int32
+	AverageSpeed float64 // km/h, 0 uses the configured speed
+}
+
+type GetNearestVehiclesResponse struct {
+	AverageSpeed float64
+	Vehicles     []*NearbyVehicle
+}
+
+type NearbyVehicle struct {
+	VehicleCd    int64
+	VehicleId    int64
+	VehicleName  string
+	BranchCd     int32
+	BranchName   string
+	DriverCd     int32
+	DriverName   string
+	AllState     string
+	Refrigerated bool
+	Latitude     float64
+	Longitude    float64
+	DataTime     *timestamppb.Timestamp
+	DistanceM    float64
+	EtaSeconds   int64
+}
+
+const defaultNearestLimit = 10
+
+func checkCoordinate(lat, lon float64) error {
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return fmt.Errorf("invalid coordinate %v,%v", lat, lon)
+	}
+	return nil
+}
+
+// nearestVehicles ranks the latest stored record of every vehicle seen within
+// maxAge (0 for no limit) by distance from the queried point.
+func nearestVehicles(store *storage.Storage, q fleet.NearestQuery, maxAge time.Duration) ([]fleet.NearbyVehicle, error) {
+	if q.Limit <= 0 {
+		q.Limit = defaultNearestLimit
+	}
+	q.Limit = min(q.Limit, maxHistoryPageSize)
+
+	now := time.Now()
+	var since time.Time
+	if maxAge > 0 {
+		since = now.Add(-maxAge)
+	}
+	records, err := store.ListFleetAt(now, since, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return fleet.NearestVehicles(records, q), nil
+}
+
+// Nearest vehicles endpoint - vehicles closest to lat/lon by great-circle
+// distance from their latest stored position, with a straight-line ETA
+func (s *HTTPServer) handleNearestVehicles(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	q := fleet.NearestQuery{AverageSpeed: s.config.NearestAverageSpeed}
+	floats := []struct {
+		name     string
+		dst      *float64
+		required bool
+	}{
+		{"lat", &q.Latitude, true},
+		{"lon", &q.Longitude, true},
+		{"max_distance", &q.MaxDistance, false},
+		{"speed", &q.AverageSpeed, false},
+	}
+	for _, f := range floats {
+		v := query.Get(f.name)
+		if v == "" {
+			if f.required {
+				s.sendError(w, fmt.Sprintf("%s is required", f.name), http.StatusBadRequest)
+				return
+			}
+			continue
+		}
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil || (parsed < 0 && !f.required) {
+			s.sendError(w, fmt.Sprintf("invalid %s: %q", f.name, v), http.StatusBadRequest)
+			return
+		}
+		*f.dst = parsed
+	}
+
+	var err error
+	if v := query.Get("branch_cd"); v != "" {
+		if q.BranchCD, err = strconv.Atoi(v); err != nil {
+			s.sendError(w, fmt.Sprintf("invalid branch_cd: %q", v), http.StatusBadRequest)
+			return
+		}
+	}
+	q.States = parseFields(query.Get("state"))
+	for _, b := range []struct {
+		name string
+		dst  **bool
+	}{
+		{"has_driver", &q.HasDriver},
+		{"refrigerated", &q.Refrigerated},
+	} {
+		v := query.Get(b.name)
+		if v == "" {
+			continue
+		}
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			s.sendError(w, fmt.Sprintf("invalid %s: %q", b.name, v), http.StatusBadRequest)
+			return
+		}
+		*b.dst = &parsed
+	}
+	var maxAge time.Duration
+	if v := query.Get("max_age"); v != "" {
+		if maxAge, err = time.ParseDuration(v); err != nil || maxAge < 0 {
+			s.sendError(w, fmt.Sprintf("invalid max_age: %q", v), http.StatusBadRequest)
+			return
+		}
+	}
+	if q.Limit, err = parseLimitParam(r); err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := checkCoordinate(q.Latitude, q.Longitude); err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	nearby, err := nearestVehicles(s.storage, q, maxAge)
+	if err != nil {
+		s.sendError(w, fmt.Sprintf("Failed to query nearest vehicles: %v", err), http.StatusInternalServerError)
+		return
+	}
+	for i := range nearby {
+		nearby[i].DataTime = nearby[i].DataTime.In(vehicle.Tokyo)
+	}
+	if nearby == nil {
+		nearby = []fleet.NearbyVehicle{}
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"latitude":      q.Latitude,
+		"longitude":     q.Longitude,
+		"average_speed": q.AverageSpeed,
+		"vehicles":      nearby,
+		"count":         len(nearby),
+	}, http.StatusOK)
+}
+
+// GetNearestVehicles returns the vehicles closest to a point
+func (s *GRPCServer) GetNearestVehicles(ctx context.Context, req *GetNearestVehiclesRequest) (*GetNearestVehiclesResponse, error) {
+	log.Printf("GetNearestVehicles called with lat=%v, lon=%v", req.Latitude, req.Longitude)
+
+	q := fleet.NearestQuery{
+		Latitude:     req.Latitude,
+		Longitude:    req.Longitude,
+		BranchCD:     int(req.BranchCd),
+		States:       req.States,
+		HasDriver:    req.HasDriver,
+		Refrigerated: req.Refrigerated,
+		MaxDistance:  req.MaxDistance,
+		Limit:        int(req.Limit),
+		AverageSpeed: req.AverageSpeed,
+	}
+	if q.AverageSpeed <= 0 {
+		q.AverageSpeed = s.config.NearestAverageSpeed
+	}
+	if err := checkCoordinate(q.Latitude, q.Longitude); err != nil {
+		return nil, err
+	}
+
+	nearby, err := nearestVehicles(s.storage, q, time.Duration(req.MaxAge)*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query nearest vehicles: %w", err)
+	}
+
+	vehicles := make([]*NearbyVehicle, len(nearby))
+	for i, v := range nearby {
+		vehicles[i] = &NearbyVehicle{
+			VehicleCd:    v.VehicleCD,
+			VehicleId:    v.VehicleID,
+			VehicleName:  v.VehicleName,
+			BranchCd:     int32(v.BranchCD),
+			BranchName:   v.BranchName,
+			DriverCd:     int32(v.DriverCD),
+			DriverName:   v.DriverName,
+			AllState:     v.AllState,
+			Refrigerated: v.Refrigerated,
+			Latitude:     v.Latitude,
+			Longitude:    v.Longitude,
+			DataTime:     timestamppb.New(v.DataTime),
+			DistanceM:    v.DistanceM,
+			EtaSeconds:   v.ETASeconds,
+		}
+	}
+	return &GetNearestVehiclesResponse{AverageSpeed: q.AverageSpeed, Vehicles: vehicles}, nil
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+)
+
+func saveNearbyTelemetry(t *testing.T, store *storage.Storage) {
+	t.Helper()
+	now := time.Now().Truncate(time.Second)
+	point := func(cd int64, lat float64, driverCD int, at time.Time, record string) storage.TelemetryRecord {
+		lon := 139.0
+		return storage.TelemetryRecord{
+			VehicleCD: cd, DataTime: at, VehicleName: "Truck", BranchCD: 1, DriverCD: driverCD,
+			Latitude: &lat, Longitude: &lon, GPSValid: true, Record: record,
+		}
+	}
+	if err := store.SaveTelemetry([]storage.TelemetryRecord{
+		point(1, 35.10, 10, now.Add(-time.Hour), `{"AllState":"空車"}`),                                    // ~11 km
+		point(1, 35.20, 10, now.Add(-2*time.Hour), `{"AllState":"実車"}`),                                  // older position
+		point(2, 35.01, 0, now.Add(-time.Minute), `{"AllState":"空車","Temp1":"-18","SettingTemp":"-20"}`), // ~1.1 km
+		point(3, 35.05, 11, now.Add(-48*time.Hour), `{"AllState":"実車"}`),                                 // ~5.6 km, old
+	}); err != nil {
+		t.Fatalf("Failed to save telemetry: %v", err)
+	}
+}
+
+func TestHTTPServer_NearestVehicles(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	saveNearbyTelemetry(t, server.storage)
+
+	tests := []struct {
+		query  string
+		status int
+		want   []float64
+	}{
+		{"?lat=35&lon=139", http.StatusOK, []float64{2, 3, 1}},
+		{"?lat=35&lon=139&limit=1", http.StatusOK, []float64{2}},
+		{"?lat=35&lon=139&max_age=24h", http.StatusOK, []float64{2, 1}},
+		{"?lat=35&lon=139&max_distance=6000", http.StatusOK, []float64{2, 3}},
+		{"?lat=35&lon=139&has_driver=true", http.StatusOK, []float64{3, 1}},
+		{"?lat=35&lon=139&refrigerated=true", http.StatusOK, []float64{2}},
+		{"?lat=35&lon=139&state=空車", http.StatusOK, []float64{2, 1}},
+		{"?lat=35&lon=139&branch_cd=2", http.StatusOK, nil},
+		{"?lon=139", http.StatusBadRequest, nil},
+		{"?lat=95&lon=139", http.StatusBadRequest, nil},
+		{"?lat=35&lon=139&has_driver=maybe", http.StatusBadRequest, nil},
+		{"?lat=35&lon=139&speed=-1", http.StatusBadRequest, nil},
+		{"?lat=35&lon=139&limit=ten", http.StatusBadRequest, nil},
+		{"?lat=35&lon=139&limit=-1", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/v1/fleet/nearest"+tt.query, nil)
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("Query %q: expected status %d, got %d", tt.query, tt.status, w.Code)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		var body struct {
+			Vehicles []map[string]interface{} `json:"vehicles"`
+		}
+		json.NewDecoder(w.Body).Decode(&body)
+		var got []float64
+		for _, v := range body.Vehicles {
+			got = append(got, v["vehicle_cd"].(float64))
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("Query %q: expected vehicles %v, got %v", tt.query, tt.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Query %q: expected vehicles %v, got %v", tt.query, tt.want, got)
+				break
+			}
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/v1/fleet/nearest?lat=35&lon=139&speed=40&limit=1", nil)
+	w := httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+	var body struct {
+		Vehicles []map[string]interface{} `json:"vehicles"`
+	}
+	json.NewDecoder(w.Body).Decode(&body)
+	// 1.1 km at 40 km/h is about 100 seconds
+	if len(body.Vehicles) != 1 || body.Vehicles[0]["eta_seconds"] != float64(100) {
+		t.Errorf("Expected an ETA of 100 seconds, got %v", body.Vehicles)
+	}
+}
+
+func TestGRPCServer_GetNearestVehicles(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	saveNearbyTelemetry(t, server.storage)
+	server.config.NearestAverageSpeed = 40
+
+	grpcServer := NewGRPCServer(server.config, server.storage, nil)
+	refrigerated := true
+	resp, err := grpcServer.GetNearestVehicles(context.Background(), &GetNearestVehiclesRequest{
+		Latitude: 35, Longitude: 139, Refrigerated: &refrigerated,
+	})
+	if err != nil {
+		t.Fatalf("GetNearestVehicles failed: %v", err)
+	}
+	if resp.AverageSpeed != 40 || len(resp.Vehicles) != 1 || resp.Vehicles[0].VehicleCd != 2 || resp.Vehicles[0].EtaSeconds != 100 {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+
+	if _, err := grpcServer.GetNearestVehicles(context.Background(), &GetNearestVehiclesRequest{Latitude: 200}); err == nil {
+		t.Error("Expected an error for an invalid coordinate")
+	}
+}
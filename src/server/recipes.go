@@ -0,0 +1,214 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/browser"
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+)
+
+// maxRecipeSize limits recipe request bodies
+const maxRecipeSize = 1 << 20
+
+type recipeView struct {
+	*browser.Recipe
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type runRecipeRequest struct {
+	SessionID string            `json:"session_id"`
+	Vars      map[string]string `json:"vars"`
+}
+
+// Recipes endpoint - lists or creates extraction recipes (JSON or YAML body)
+func (s *HTTPServer) handleRecipes(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		stored, err := s.storage.ListRecipes()
+		if err != nil {
+			s.sendError(w, fmt.Sprintf("Failed to list recipes: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		recipes := make([]recipeView, 0, len(stored))
+		for i := range stored {
+			view, err := toRecipeView(&stored[i])
+			if err != nil {
+				log.Printf("Skipping invalid stored recipe %s: %v", stored[i].Name, err)
+				continue
+			}
+			recipes = append(recipes, *view)
+		}
+
+		s.sendJSON(w, map[string]interface{}{
+			"recipes": recipes,
+			"count":   len(recipes),
+		}, http.StatusOK)
+
+	case http.MethodPost:
+		recipe, ok := s.readRecipe(w, r)
+		if !ok {
+			return
+		}
+		if err := s.saveRecipe(recipe); err != nil {
+			s.sendError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.sendJSON(w, recipe, http.StatusCreated)
+
+	default:
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Recipe endpoint - gets, replaces, deletes or runs a single recipe
+func (s *HTTPServer) handleRecipe(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path[len("/v1/recipes/"):]
+	name, action, _ := strings.Cut(path, "/")
+	if name == "" {
+		s.sendError(w, "Recipe name is required", http.StatusBadRequest)
+		return
+	}
+
+	if action == "run" {
+		s.handleRecipeRun(w, r, name)
+		return
+	}
+	if action != "" {
+		s.notFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		stored, err := s.storage.GetRecipe(name)
+		if err != nil {
+			s.sendError(w, fmt.Sprintf("Failed to get recipe: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if stored == nil {
+			s.sendError(w, "Recipe not found", http.StatusNotFound)
+			return
+		}
+		view, err := toRecipeView(stored)
+		if err != nil {
+			s.sendError(w, fmt.Sprintf("Stored recipe is invalid: %v", err), http.StatusInternalServerError)
+			return
+		}
+		s.sendJSON(w, view, http.StatusOK)
+
+	case http.MethodPut:
+		recipe, ok := s.readRecipe(w, r)
+		if !ok {
+			return
+		}
+		if recipe.Name != name {
+			s.sendError(w, "Recipe name does not match URL", http.StatusBadRequest)
+			return
+		}
+		if err := s.saveRecipe(recipe); err != nil {
+			s.sendError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.sendJSON(w, recipe, http.StatusOK)
+
+	case http.MethodDelete:
+		if err := s.storage.DeleteRecipe(name); err != nil {
+			s.sendError(w, fmt.Sprintf("Failed to delete recipe: %v", err), http.StatusInternalServerError)
+			return
+		}
+		s.sendJSON(w, map[string]interface{}{
+			"success": true,
+			"message": "Recipe deleted successfully",
+		}, http.StatusOK)
+
+	default:
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *HTTPServer) handleRecipeRun(w http.ResponseWriter, r *http.Request, name string) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req runRecipeRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			s.sendError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	stored, err := s.storage.GetRecipe(name)
+	if err != nil {
+		s.sendError(w, fmt.Sprintf("Failed to get recipe: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if stored == nil {
+		s.sendError(w, "Recipe not found", http.StatusNotFound)
+		return
+	}
+	recipe, err := browser.ParseRecipe([]byte(stored.Definition))
+	if err != nil {
+		s.sendError(w, fmt.Sprintf("Stored recipe is invalid: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	jobID := s.jobManager.CreateRecipeJob(recipe, req.SessionID, req.Vars)
+	log.Printf("Created recipe job %s for recipe %s", jobID, name)
+
+	s.sendJSON(w, map[string]interface{}{
+		"job_id":  jobID,
+		"status":  "pending",
+		"message": "Recipe job created successfully. Use /v1/job/{id} to check status.",
+	}, http.StatusAccepted)
+}
+
+// readRecipe parses and validates a recipe from the request body.
+func (s *HTTPServer) readRecipe(w http.ResponseWriter, r *http.Request) (*browser.Recipe, bool) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRecipeSize))
+	if err != nil {
+		s.sendError(w, "Failed to read request body", http.StatusBadRequest)
+		return nil, false
+	}
+
+	recipe, err := browser.ParseRecipe(body)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return recipe, true
+}
+
+// saveRecipe stores the recipe normalized to JSON.
+func (s *HTTPServer) saveRecipe(recipe *browser.Recipe) error {
+	definition, err := json.Marshal(recipe)
+	if err != nil {
+		return fmt.Errorf("failed to encode recipe: %w", err)
+	}
+	if err := s.storage.SaveRecipe(recipe.Name, string(definition)); err != nil {
+		return fmt.Errorf("failed to save recipe: %w", err)
+	}
+	return nil
+}
+
+func toRecipeView(stored *storage.Recipe) (*recipeView, error) {
+	recipe, err := browser.ParseRecipe([]byte(stored.Definition))
+	if err != nil {
+		return nil, err
+	}
+	return &recipeView{
+		Recipe:    recipe,
+		CreatedAt: stored.CreatedAt,
+		UpdatedAt: stored.UpdatedAt,
+	}, nil
+}
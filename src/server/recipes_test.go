@@ -0,0 +1,75 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPServer_Recipes(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	yamlRecipe := "name: page_title\nfields:\n  - name: title\n    selector: title\n"
+
+	req := httptest.NewRequest("POST", "/v1/recipes", bytes.NewBufferString(yamlRecipe))
+	w := httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/v1/recipes/page_title", nil)
+	w = httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var body map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&body)
+	if body["name"] != "page_title" || body["created_at"] == nil {
+		t.Errorf("Unexpected recipe response: %v", body)
+	}
+
+	req = httptest.NewRequest("GET", "/v1/recipes", nil)
+	w = httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+	json.NewDecoder(w.Body).Decode(&body)
+	if body["count"] != float64(1) {
+		t.Errorf("Expected 1 recipe, got %v", body["count"])
+	}
+}
+
+func TestHTTPServer_RecipeErrors(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	tests := []struct {
+		name       string
+		method     string
+		endpoint   string
+		body       string
+		wantStatus int
+	}{
+		{"Invalid recipe", "POST", "/v1/recipes", `{"name": "x"}`, http.StatusBadRequest},
+		{"Name mismatch", "PUT", "/v1/recipes/other", `{"name": "x", "fields": [{"name": "t", "js": "1"}]}`, http.StatusBadRequest},
+		{"Unknown recipe", "GET", "/v1/recipes/missing", "", http.StatusNotFound},
+		{"Run unknown recipe", "POST", "/v1/recipes/missing/run", "", http.StatusNotFound},
+		{"Run wrong method", "GET", "/v1/recipes/missing/run", "", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.endpoint, bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			server.mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
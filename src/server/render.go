@@ -0,0 +1,269 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/browser"
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+)
+
+// Temporary struct definitions until protoc generates them
+type RenderScreenshotRequest struct {
+	Url          string
+	SessionId    string
+	Width        int32
+	Height       int32
+	FullPage     bool
+	WaitSelector string
+	Format       string
+	Quality      int32
+	Store        bool
+}
+
+type RenderPDFRequest struct {
+	Url             string
+	SessionId       string
+	Width           int32
+	Height          int32
+	WaitSelector    string
+	PaperSize       string
+	Landscape       bool
+	PrintBackground bool
+	Store           bool
+}
+
+type RenderResponse struct {
+	ContentType string
+	Data        []byte // empty when the result was stored
+	RenderId    string // set when the result was stored
+	Size        int64
+}
+
+type screenshotHTTPRequest struct {
+	browser.ScreenshotOptions
+	Store bool `json:"store"`
+}
+
+type pdfHTTPRequest struct {
+	browser.PDFOptions
+	Store bool `json:"store"`
+}
+
+// RenderScreenshot captures a screenshot of an arbitrary URL
+func (s *GRPCServer) RenderScreenshot(ctx context.Context, req *RenderScreenshotRequest) (*RenderResponse, error) {
+	log.Printf("RenderScreenshot called with url=%s", req.Url)
+
+	data, contentType, err := s.renderer.RenderScreenshot(ctx, browser.ScreenshotOptions{
+		URL:          req.Url,
+		SessionID:    req.SessionId,
+		Width:        int(req.Width),
+		Height:       int(req.Height),
+		FullPage:     req.FullPage,
+		WaitSelector: req.WaitSelector,
+		Format:       req.Format,
+		Quality:      int(req.Quality),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render screenshot: %w", err)
+	}
+
+	return renderResult(s.storage, "screenshot", req.Url, contentType, data, req.Store)
+}
+
+// RenderPDF prints an arbitrary URL to PDF
+func (s *GRPCServer) RenderPDF(ctx context.Context, req *RenderPDFRequest) (*RenderResponse, error) {
+	log.Printf("RenderPDF called with url=%s", req.Url)
+
+	data, err := s.renderer.RenderPDF(ctx, browser.PDFOptions{
+		URL:             req.Url,
+		SessionID:       req.SessionId,
+		Width:           int(req.Width),
+		Height:          int(req.Height),
+		WaitSelector:    req.WaitSelector,
+		PaperSize:       req.PaperSize,
+		Landscape:       req.Landscape,
+		PrintBackground: req.PrintBackground,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render PDF: %w", err)
+	}
+
+	return renderResult(s.storage, "pdf", req.Url, "application/pdf", data, req.Store)
+}
+
+// renderResult either returns the data inline or stores it and returns its ID.
+func renderResult(store *storage.Storage, kind, url, contentType string, data []byte, persist bool) (*RenderResponse, error) {
+	resp := &RenderResponse{
+		ContentType: contentType,
+		Size:        int64(len(data)),
+	}
+	if !persist {
+		resp.Data = data
+		return resp, nil
+	}
+
+	render, err := saveRender(store, kind, url, contentType, data)
+	if err != nil {
+		return nil, err
+	}
+	resp.RenderId = render.ID
+	return resp, nil
+}
+
+func saveRender(store *storage.Storage, kind, url, contentType string, data []byte) (*storage.Render, error) {
+	render := &storage.Render{
+		ID:          uuid.New().String(),
+		Kind:        kind,
+		URL:         url,
+		ContentType: contentType,
+		Size:        len(data),
+		CreatedAt:   time.Now(),
+		Data:        data,
+	}
+	if err := store.SaveRender(render); err != nil {
+		return nil, fmt.Errorf("failed to store render: %w", err)
+	}
+	return render, nil
+}
+
+// Screenshot endpoint
+func (s *HTTPServer) handleRenderScreenshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req screenshotHTTPRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.URL == "" {
+		s.sendError(w, "url is required", http.StatusBadRequest)
+		return
+	}
+
+	data, contentType, err := s.renderer.RenderScreenshot(r.Context(), req.ScreenshotOptions)
+	if err != nil {
+		s.sendError(w, fmt.Sprintf("Failed to render screenshot: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	s.sendRender(w, "screenshot", req.URL, contentType, data, req.Store)
+}
+
+// PDF endpoint
+func (s *HTTPServer) handleRenderPDF(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req pdfHTTPRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.URL == "" {
+		s.sendError(w, "url is required", http.StatusBadRequest)
+		return
+	}
+
+	data, err := s.renderer.RenderPDF(r.Context(), req.PDFOptions)
+	if err != nil {
+		s.sendError(w, fmt.Sprintf("Failed to render PDF: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	s.sendRender(w, "pdf", req.URL, "application/pdf", data, req.Store)
+}
+
+// Stored renders list endpoint
+func (s *HTTPServer) handleRendersList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit, err := parseLimitParam(r)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if limit == 0 {
+		limit = 100
+	}
+
+	renders, err := s.storage.ListRenders(limit)
+	if err != nil {
+		s.sendError(w, fmt.Sprintf("Failed to list renders: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"renders": renders,
+		"count":   len(renders),
+	}, http.StatusOK)
+}
+
+// Stored render endpoint - returns or deletes a single render
+func (s *HTTPServer) handleRender(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Path[len("/v1/renders/"):]
+	if id == "" {
+		s.sendError(w, "Render ID is required", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		render, err := s.storage.GetRender(id)
+		if err != nil {
+			s.sendError(w, fmt.Sprintf("Failed to get render: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if render == nil {
+			s.sendError(w, "Render not found", http.StatusNotFound)
+			return
+		}
+		s.sendBinary(w, render.ContentType, "", render.Data)
+
+	case http.MethodDelete:
+		if err := s.storage.DeleteRender(id); err != nil {
+			s.sendError(w, fmt.Sprintf("Failed to delete render: %v", err), http.StatusInternalServerError)
+			return
+		}
+		s.sendJSON(w, map[string]interface{}{
+			"success": true,
+			"message": "Render deleted successfully",
+		}, http.StatusOK)
+
+	default:
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// sendRender writes the rendered bytes, or stores them and returns their location.
+func (s *HTTPServer) sendRender(w http.ResponseWriter, kind, url, contentType string, data []byte, persist bool) {
+	if !persist {
+		s.sendBinary(w, contentType, "", data)
+		return
+	}
+
+	render, err := saveRender(s.storage, kind, url, contentType, data)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"render":       render,
+		"download_url": "/v1/renders/" + render.ID,
+	}, http.StatusCreated)
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+)
+
+func TestHTTPServer_RenderValidation(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	tests := []struct {
+		name       string
+		method     string
+		endpoint   string
+		body       string
+		wantStatus int
+	}{
+		{"Screenshot wrong method", "GET", "/v1/render/screenshot", "", http.StatusMethodNotAllowed},
+		{"Screenshot invalid JSON", "POST", "/v1/render/screenshot", "invalid", http.StatusBadRequest},
+		{"Screenshot missing url", "POST", "/v1/render/screenshot", `{"full_page":true}`, http.StatusBadRequest},
+		{"PDF missing url", "POST", "/v1/render/pdf", `{"paper_size":"A4"}`, http.StatusBadRequest},
+		{"Unknown render", "GET", "/v1/renders/missing", "", http.StatusNotFound},
+		{"Renders invalid limit", "GET", "/v1/renders?limit=abc", "", http.StatusBadRequest},
+		{"Renders negative limit", "GET", "/v1/renders?limit=-5", "", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.endpoint, bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			server.mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestHTTPServer_StoredRender(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	err := server.storage.SaveRender(&storage.Render{
+		ID:          "stored",
+		Kind:        "screenshot",
+		URL:         "https://example.com",
+		ContentType: "image/png",
+		CreatedAt:   time.Now(),
+		Data:        []byte("png-bytes"),
+	})
+	if err != nil {
+		t.Fatalf("Failed to save render: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/v1/renders/stored", nil)
+	w := httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "image/png" {
+		t.Errorf("Expected Content-Type image/png, got %s", ct)
+	}
+	if w.Body.String() != "png-bytes" {
+		t.Errorf("Unexpected body: %q", w.Body.String())
+	}
+}
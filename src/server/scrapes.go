@@ -0,0 +1,50 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/browser"
+)
+
+// scrapeReviewer is the part of the renderer that holds suspect scrapes
+type scrapeReviewer interface {
+	PendingScrapes() []browser.PendingScrape
+	ConfirmScrape(branchID, filterID string) (*browser.PendingScrape, error)
+}
+
+// Suspect scrapes endpoint - full scrapes whose vehicle count did not match
+// the baseline and that are held back from the sinks
+func (s *HTTPServer) handleScrapes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	pending := s.scrapes.PendingScrapes()
+	s.sendJSON(w, map[string]interface{}{
+		"pending": pending,
+		"count":   len(pending),
+	}, http.StatusOK)
+}
+
+// Scrape confirmation endpoint - publishes the suspect scrape held for
+// branch_id and filter_id (defaults if empty) and makes its count the new
+// baseline
+func (s *HTTPServer) handleScrapeConfirm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	confirmed, err := s.scrapes.ConfirmScrape(query.Get("branch_id"), query.Get("filter_id"))
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"success":   true,
+		"confirmed": confirmed,
+	}, http.StatusOK)
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/browser"
+)
+
+// fakeScrapes stands in for the renderer, which needs a browser
+type fakeScrapes struct {
+	pending []browser.PendingScrape
+}
+
+func (f *fakeScrapes) PendingScrapes() []browser.PendingScrape {
+	return f.pending
+}
+
+func (f *fakeScrapes) ConfirmScrape(branchID, filterID string) (*browser.PendingScrape, error) {
+	if branchID == "" {
+		branchID = browser.DefaultBranchID
+	}
+	if filterID == "" {
+		filterID = browser.DefaultFilterID
+	}
+	for i, p := range f.pending {
+		if p.BranchID == branchID && p.FilterID == filterID {
+			f.pending = append(f.pending[:i], f.pending[i+1:]...)
+			return &p, nil
+		}
+	}
+	return nil, errors.New("no suspect scrape pending")
+}
+
+func TestHTTPServer_Scrapes(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	server.scrapes = &fakeScrapes{pending: []browser.PendingScrape{{
+		BranchID: browser.DefaultBranchID, FilterID: browser.DefaultFilterID,
+		Count: 50, Baseline: 193, Reason: "count dropped", FetchedAt: time.Now(),
+	}}}
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("GET", "/v1/scrapes")
+	var list struct {
+		Pending []browser.PendingScrape `json:"pending"`
+		Count   int                     `json:"count"`
+	}
+	json.NewDecoder(w.Body).Decode(&list)
+	if w.Code != http.StatusOK || list.Count != 1 || list.Pending[0].Count != 50 || list.Pending[0].Baseline != 193 {
+		t.Errorf("Unexpected pending scrapes: %d %+v", w.Code, list)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{"POST", "/v1/scrapes", http.StatusMethodNotAllowed},
+		{"GET", "/v1/scrapes/confirm", http.StatusMethodNotAllowed},
+		{"POST", "/v1/scrapes/confirm?branch_id=other", http.StatusNotFound},
+		{"POST", "/v1/scrapes/confirm", http.StatusOK},
+		{"POST", "/v1/scrapes/confirm", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if w := do(tt.method, tt.path); w.Code != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.path, tt.status, w.Code)
+		}
+	}
+
+	w = do("GET", "/v1/scrapes")
+	json.NewDecoder(w.Body).Decode(&list)
+	if list.Count != 0 {
+		t.Errorf("Expected no pending scrapes after confirming, got %d", list.Count)
+	}
+}
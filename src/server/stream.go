@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/browser"
+)
+
+// Temporary struct definitions until protoc generates them
+type StreamVehicleDataRequest struct{}
+
+type VehicleUpdate struct {
+	BranchId  string
+	FilterId  string
+	FetchedAt int64
+	Data      []*VehicleData
+}
+
+// BrowserRenderService_StreamVehicleDataServer is the server side of the
+// StreamVehicleData stream.
+type BrowserRenderService_StreamVehicleDataServer interface {
+	Send(*VehicleUpdate) error
+	Context() context.Context
+}
+
+const streamBuffer = 16
+
+// StreamVehicleData sends every polled set of changed vehicles until the
+// client disconnects.
+func (s *GRPCServer) StreamVehicleData(req *StreamVehicleDataRequest, stream BrowserRenderService_StreamVehicleDataServer) error {
+	log.Println("StreamVehicleData called")
+
+	updates, unsubscribe := s.renderer.SubscribeVehicleUpdates(streamBuffer)
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case snapshot, ok := <-updates:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(toVehicleUpdate(snapshot)); err != nil {
+				return fmt.Errorf("failed to send vehicle update: %w", err)
+			}
+		}
+	}
+}
+
+func toVehicleUpdate(snapshot *browser.Snapshot) *VehicleUpdate {
+	data := make([]*VehicleData, len(snapshot.Vehicles))
+	for i, v := range snapshot.Vehicles {
+		data[i] = toPbVehicleData(v)
+	}
+	return &VehicleUpdate{
+		BranchId:  snapshot.BranchID,
+		FilterId:  snapshot.FilterID,
+		FetchedAt: snapshot.FetchedAt.Unix(),
+		Data:      data,
+	}
+}
+
+// handleVehicleStream streams polled vehicle updates as server-sent events.
+// Each event carries the vehicles whose DataDateTime changed.
+func (s *HTTPServer) handleVehicleStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.config.PollInterval <= 0 {
+		s.sendError(w, "Vehicle polling is disabled (set POLL_INTERVAL)", http.StatusServiceUnavailable)
+		return
+	}
+
+	// The stream outlives the server's write timeout
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("Failed to clear write deadline for vehicle stream: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		log.Printf("Vehicle stream does not support flushing: %v", err)
+		return
+	}
+
+	updates, unsubscribe := s.renderer.SubscribeVehicleUpdates(streamBuffer)
+	defer unsubscribe()
+
+	keepAlive := time.NewTicker(30 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case snapshot, ok := <-updates:
+			if !ok {
+				return
+			}
+			payload, err := json.Marshal(map[string]interface{}{
+				"branch_id":  snapshot.BranchID,
+				"filter_id":  snapshot.FilterID,
+				"fetched_at": snapshot.FetchedAt,
+				"data":       snapshot.Vehicles,
+			})
+			if err != nil {
+				log.Printf("Failed to encode vehicle update: %v", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: vehicles\ndata: %s\n\n", payload); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
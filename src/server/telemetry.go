@@ -0,0 +1,234 @@
+package server
+
+import (
+	"log"
+	"time"
+
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/browser"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+// Temporary struct definitions until protoc generates them
+type VehicleTelemetry struct {
+	Type string
+
+	VehicleCd                   int64
+	VehicleName                 string
+	BranchCd                    int32
+	BranchName                  string
+	DataFilterType              int32
+	DispFlag                    int32
+	VehicleIconColor            string
+	VehicleIconLabelForDatetime string
+	VehicleIconLabelForDriver   string
+	VehicleIconLabelForVehicle  string
+
+	DataDateTime      string
+	ComuDateTime      string
+	StartWorkDateTime string
+
+	DriverCd    int32
+	DriverName  string
+	SubDriverCd int32
+
+	CurrentWorkCd          int32
+	CurrentWorkName        string
+	OperationState         int32
+	AllState               string
+	AllStateEx             string
+	AllStateFontColor      string
+	AllStateFontColorIndex int32
+	AllStateRyoutColor     string
+	State                  string
+	State1                 string
+	State2                 string
+	State3                 string
+	StateFlag              string
+	EventVal               string
+
+	ReciveEventType     int32
+	RecivePacketType    int32
+	ReciveTypeColorName string
+	ReciveTypeName      string
+	ReciveWorkCd        int32
+
+	GpsLatitude     int64
+	GpsLongitude    int64
+	GpsLatiAndLong  string
+	GpsDirection    int32
+	GpsEnable       int32
+	GpsSatelliteNum int32
+	AddressDispC    string
+	AddressDispP    string
+	Speed           float64
+	Revo            int32
+	OdoMeter        string
+
+	Temp1        string
+	Temp2        string
+	Temp3        string
+	Temp4        string
+	SettingTemp  string
+	SettingTemp1 string
+	SettingTemp3 string
+	SettingTemp4 string
+	TempState    int32
+}
+
+type Plate struct {
+	Region string
+	Class  string
+	Kana   string
+	Serial string
+	Text   string
+}
+
+type Position struct {
+	Latitude   float64
+	Longitude  float64
+	Direction  int32
+	Satellites int32
+	Valid      bool
+	Quality    string
+}
+
+// toPbVehicleData converts a vehicle to its protobuf form.
+func toPbVehicleData(v browser.VehicleData) *VehicleData {
+	return &VehicleData{
+		VehicleId:   v.VehicleID,
+		VehicleCd:   v.VehicleCD,
+		VehicleName: v.VehicleName,
+		Status:      v.Status,
+		Metadata:    v.Metadata,
+		Telemetry:   toPbTelemetry(v.Telemetry),
+		Plate:       toPbPlate(v.Plate),
+		Position:    toPbPosition(v.Position),
+		Raw:         toPbStruct(v.Raw),
+
+		DataTime:      toPbTimestamp(v.DataTime),
+		ComuTime:      toPbTimestamp(v.ComuTime),
+		StartWorkTime: toPbTimestamp(v.StartWorkTime),
+	}
+}
+
+func toPbPlate(p *vehicle.Plate) *Plate {
+	if p == nil {
+		return nil
+	}
+	return &Plate{
+		Region: p.Region,
+		Class:  p.Class,
+		Kana:   p.Kana,
+		Serial: p.Serial,
+		Text:   p.String(),
+	}
+}
+
+func toPbPosition(p *vehicle.Position) *Position {
+	if p == nil {
+		return nil
+	}
+	return &Position{
+		Latitude:   p.Latitude,
+		Longitude:  p.Longitude,
+		Direction:  int32(p.Direction),
+		Satellites: int32(p.Satellites),
+		Valid:      p.Valid,
+		Quality:    p.Quality,
+	}
+}
+
+func toPbTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+// toPbStruct converts a raw record to a protobuf Struct, keeping numbers,
+// booleans, nulls and nested values.
+func toPbStruct(raw map[string]interface{}) *structpb.Struct {
+	if raw == nil {
+		return nil
+	}
+	s, err := structpb.NewStruct(raw)
+	if err != nil {
+		log.Printf("Warning: failed to convert raw record: %v", err)
+		return nil
+	}
+	return s
+}
+
+func toPbTelemetry(t *vehicle.Telemetry) *VehicleTelemetry {
+	if t == nil {
+		return nil
+	}
+	return &VehicleTelemetry{
+		Type: t.Type,
+
+		VehicleCd:                   t.VehicleCD,
+		VehicleName:                 t.VehicleName,
+		BranchCd:                    int32(t.BranchCD),
+		BranchName:                  t.BranchName,
+		DataFilterType:              int32(t.DataFilterType),
+		DispFlag:                    int32(t.DispFlag),
+		VehicleIconColor:            t.VehicleIconColor,
+		VehicleIconLabelForDatetime: t.VehicleIconLabelForDatetime,
+		VehicleIconLabelForDriver:   t.VehicleIconLabelForDriver,
+		VehicleIconLabelForVehicle:  t.VehicleIconLabelForVehicle,
+
+		DataDateTime:      t.DataDateTime,
+		ComuDateTime:      t.ComuDateTime,
+		StartWorkDateTime: t.StartWorkDateTime,
+
+		DriverCd:    int32(t.DriverCD),
+		DriverName:  t.DriverName,
+		SubDriverCd: int32(t.SubDriverCD),
+
+		CurrentWorkCd:          int32(t.CurrentWorkCD),
+		CurrentWorkName:        t.CurrentWorkName,
+		OperationState:         int32(t.OperationState),
+		AllState:               t.AllState,
+		AllStateEx:             t.AllStateEx,
+		AllStateFontColor:      t.AllStateFontColor,
+		AllStateFontColorIndex: int32(t.AllStateFontColorIndex),
+		AllStateRyoutColor:     t.AllStateRyoutColor,
+		State:                  t.State,
+		State1:                 t.State1,
+		State2:                 t.State2,
+		State3:                 t.State3,
+		StateFlag:              t.StateFlag,
+		EventVal:               t.EventVal,
+
+		ReciveEventType:     int32(t.ReciveEventType),
+		RecivePacketType:    int32(t.RecivePacketType),
+		ReciveTypeColorName: t.ReciveTypeColorName,
+		ReciveTypeName:      t.ReciveTypeName,
+		ReciveWorkCd:        int32(t.ReciveWorkCD),
+
+		GpsLatitude:     t.GPSLatitude,
+		GpsLongitude:    t.GPSLongitude,
+		GpsLatiAndLong:  t.GPSLatiAndLong,
+		GpsDirection:    int32(t.GPSDirection),
+		GpsEnable:       int32(t.GPSEnable),
+		GpsSatelliteNum: int32(t.GPSSatelliteNum),
+		AddressDispC:    t.AddressDispC,
+		AddressDispP:    t.AddressDispP,
+		Speed:           t.Speed,
+		Revo:            int32(t.Revo),
+		OdoMeter:        t.ODOMeter,
+
+		Temp1:        t.Temp1,
+		Temp2:        t.Temp2,
+		Temp3:        t.Temp3,
+		Temp4:        t.Temp4,
+		SettingTemp:  t.SettingTemp,
+		SettingTemp1: t.SettingTemp1,
+		SettingTemp3: t.SettingTemp3,
+		SettingTemp4: t.SettingTemp4,
+		TempState:    int32(t.TempState),
+	}
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/browser"
+)
+
+func TestToPbVehicleData_Raw(t *testing.T) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(`{"VehicleCD":12,"Speed":42.5,"DriverName":null,"Nested":{"a":[1,"b"]}}`), &raw); err != nil {
+		t.Fatalf("Failed to decode fixture: %v", err)
+	}
+
+	pb := toPbVehicleData(browser.VehicleData{VehicleCD: "12", Raw: raw})
+	if pb.Raw == nil {
+		t.Fatal("Expected raw record to be converted")
+	}
+
+	fields := pb.Raw.GetFields()
+	if fields["Speed"].GetNumberValue() != 42.5 {
+		t.Errorf("Expected Speed to be a number, got %v", fields["Speed"])
+	}
+	if _, ok := fields["DriverName"].GetKind().(*structpb.Value_NullValue); !ok {
+		t.Errorf("Expected DriverName to be null, got %v", fields["DriverName"])
+	}
+	list := fields["Nested"].GetStructValue().GetFields()["a"].GetListValue().GetValues()
+	if len(list) != 2 || list[1].GetStringValue() != "b" {
+		t.Errorf("Expected nested list to be preserved, got %v", list)
+	}
+
+	if toPbVehicleData(browser.VehicleData{}).Raw != nil {
+		t.Error("Expected nil raw for vehicles without a record")
+	}
+}
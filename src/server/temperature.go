@@ -0,0 +1,75 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+// Temperature excursions endpoint - lists periods in which a refrigerated
+// compartment was out of range, newest first
+func (s *HTTPServer) handleTemperatureExcursions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := storage.ExcursionFilter{Limit: defaultHistoryPageSize}
+
+	var err error
+	if v := query.Get("open"); v != "" {
+		if filter.OpenOnly, err = strconv.ParseBool(v); err != nil {
+			s.sendError(w, fmt.Sprintf("invalid open: %q", v), http.StatusBadRequest)
+			return
+		}
+	}
+	if v := query.Get("vehicle_cd"); v != "" {
+		if filter.VehicleCD, err = strconv.ParseInt(v, 10, 64); err != nil {
+			s.sendError(w, fmt.Sprintf("invalid vehicle_cd: %q", v), http.StatusBadRequest)
+			return
+		}
+	}
+	if filter.From, err = parseTimeParam(r, "from"); err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filter.To, err = parseTimeParam(r, "to"); err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, err := parseLimitParam(r)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if limit > 0 {
+		filter.Limit = min(limit, maxHistoryPageSize)
+	}
+
+	excursions, err := s.storage.ListExcursions(filter)
+	if err != nil {
+		s.sendError(w, fmt.Sprintf("Failed to list excursions: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	for i := range excursions {
+		e := &excursions[i]
+		e.StartedAt = e.StartedAt.In(vehicle.Tokyo)
+		if e.EndedAt != nil {
+			ended := e.EndedAt.In(vehicle.Tokyo)
+			e.EndedAt = &ended
+		}
+	}
+	if excursions == nil {
+		excursions = []storage.TemperatureExcursion{}
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"excursions": excursions,
+		"count":      len(excursions),
+	}, http.StatusOK)
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+)
+
+func TestHTTPServer_TemperatureExcursions(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	base := time.Now().Add(-2 * time.Hour)
+	ended := base.Add(time.Hour)
+	for _, e := range []*storage.TemperatureExcursion{
+		{VehicleCD: 1, Compartment: 1, SetPoint: -20, Tolerance: 3, StartedAt: base, EndedAt: &ended},
+		{VehicleCD: 2, Compartment: 1, SetPoint: 5, Tolerance: 3, StartedAt: base},
+	} {
+		if err := server.storage.SaveExcursion(e); err != nil {
+			t.Fatalf("Failed to save excursion: %v", err)
+		}
+	}
+
+	tests := []struct {
+		query  string
+		status int
+		count  float64
+	}{
+		{"", http.StatusOK, 2},
+		{"?open=true", http.StatusOK, 1},
+		{"?vehicle_cd=1", http.StatusOK, 1},
+		{"?limit=1", http.StatusOK, 1},
+		{"?vehicle_cd=x", http.StatusBadRequest, 0},
+		{"?open=maybe", http.StatusBadRequest, 0},
+		{"?limit=abc", http.StatusBadRequest, 0},
+		{"?limit=-5", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/v1/temperature/excursions"+tt.query, nil)
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("Query %q: expected status %d, got %d", tt.query, tt.status, w.Code)
+			continue
+		}
+		var body map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&body)
+		if tt.status == http.StatusOK && body["count"] != tt.count {
+			t.Errorf("Query %q: expected %v excursions, got %v", tt.query, tt.count, body["count"])
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/fleet"
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+const csvTimeLayout = "2006/01/02 15:04:05"
+
+// parseDayRange reads either a date parameter (YYYY-MM-DD or YYYY/MM/DD, a
+// whole day in Asia/Tokyo) or from and to. Without any of them it returns
+// today in Asia/Tokyo when defaultToday is set.
+func parseDayRange(r *http.Request, defaultToday bool) (time.Time, time.Time, error) {
+	query := r.URL.Query()
+	date := query.Get("date")
+	if date == "" && query.Get("from") == "" && query.Get("to") == "" && defaultToday {
+		date = time.Now().In(vehicle.Tokyo).Format("2006-01-02")
+	}
+
+	if date != "" {
+		for _, layout := range []string{"2006-01-02", "2006/01/02", "2006/1/2"} {
+			if day, err := time.ParseInLocation(layout, date, vehicle.Tokyo); err == nil {
+				return day, day.AddDate(0, 0, 1), nil
+			}
+		}
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid date: %q", date)
+	}
+
+	from, err := parseTimeParam(r, "from")
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	to, err := parseTimeParam(r, "to")
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return from, to, nil
+}
+
+// handleVehicleTrips returns a vehicle's trips and stops with a per-day
+// summary, as JSON or CSV
+func (s *HTTPServer) handleVehicleTrips(w http.ResponseWriter, r *http.Request, vehicleCD int64) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	from, to, err := parseDayRange(r, false)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	kind := r.URL.Query().Get("kind")
+	if kind != "" && kind != storage.SegmentTrip && kind != storage.SegmentStop {
+		s.sendError(w, fmt.Sprintf("invalid kind: %q", kind), http.StatusBadRequest)
+		return
+	}
+
+	trips, err := s.storage.ListTrips(storage.TripFilter{VehicleCD: vehicleCD, Kind: kind, From: from, To: to})
+	if err != nil {
+		s.sendError(w, fmt.Sprintf("Failed to list trips: %v", err), http.StatusInternalServerError)
+		return
+	}
+	for i := range trips {
+		trips[i].StartTime = trips[i].StartTime.In(vehicle.Tokyo)
+		trips[i].EndTime = trips[i].EndTime.In(vehicle.Tokyo)
+	}
+
+	if r.URL.Query().Get("format") == "csv" {
+		s.sendCSV(w, fmt.Sprintf("trips_%d.csv", vehicleCD), tripRows(trips))
+		return
+	}
+
+	if trips == nil {
+		trips = []storage.Trip{}
+	}
+	s.sendJSON(w, map[string]interface{}{
+		"vehicle_cd": vehicleCD,
+		"trips":      trips,
+		"count":      len(trips),
+		"summary":    fleet.SummarizeTrips(trips),
+	}, http.StatusOK)
+}
+
+// Trip report endpoint - per-vehicle, per-day totals of trips and stops
+func (s *HTTPServer) handleTripReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	from, to, err := parseDayRange(r, true)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	trips, err := s.storage.ListTrips(storage.TripFilter{From: from, To: to})
+	if err != nil {
+		s.sendError(w, fmt.Sprintf("Failed to list trips: %v", err), http.StatusInternalServerError)
+		return
+	}
+	summaries := fleet.SummarizeTrips(trips)
+
+	if r.URL.Query().Get("format") == "csv" {
+		s.sendCSV(w, fmt.Sprintf("trip_report_%s.csv", from.In(vehicle.Tokyo).Format("20060102")), tripSummaryRows(summaries))
+		return
+	}
+
+	if summaries == nil {
+		summaries = []fleet.TripSummary{}
+	}
+	s.sendJSON(w, map[string]interface{}{
+		"from":     from.In(vehicle.Tokyo),
+		"to":       to.In(vehicle.Tokyo),
+		"vehicles": summaries,
+		"count":    len(summaries),
+	}, http.StatusOK)
+}
+
+func tripRows(trips []storage.Trip) [][]string {
+	rows := [][]string{{
+		"vehicle_cd", "vehicle_name", "kind", "start_time", "end_time", "duration_seconds",
+		"distance_km", "distance_source", "idle_seconds", "max_speed", "driver_cd",
+		"start_latitude", "start_longitude", "end_latitude", "end_longitude", "closed",
+	}}
+	for _, t := range trips {
+		rows = append(rows, []string{
+			strconv.FormatInt(t.VehicleCD, 10),
+			t.VehicleName,
+			t.Kind,
+			t.StartTime.In(vehicle.Tokyo).Format(csvTimeLayout),
+			t.EndTime.In(vehicle.Tokyo).Format(csvTimeLayout),
+			strconv.FormatInt(int64(t.Duration().Seconds()), 10),
+			formatFloat(t.DistanceKm),
+			t.DistanceSource,
+			strconv.FormatInt(t.IdleSeconds, 10),
+			formatFloat(t.MaxSpeed),
+			strconv.Itoa(t.DriverCD),
+			formatOptionalFloat(t.StartLatitude),
+			formatOptionalFloat(t.StartLongitude),
+			formatOptionalFloat(t.EndLatitude),
+			formatOptionalFloat(t.EndLongitude),
+			strconv.FormatBool(t.Closed),
+		})
+	}
+	return rows
+}
+
+func tripSummaryRows(summaries []fleet.TripSummary) [][]string {
+	rows := [][]string{{
+		"date", "vehicle_cd", "vehicle_name", "trips", "stops", "distance_km",
+		"driving_seconds", "idle_seconds", "stop_seconds", "first_start", "last_end",
+	}}
+	for _, sum := range summaries {
+		rows = append(rows, []string{
+			sum.Date,
+			strconv.FormatInt(sum.VehicleCD, 10),
+			sum.VehicleName,
+			strconv.Itoa(sum.Trips),
+			strconv.Itoa(sum.Stops),
+			formatFloat(sum.DistanceKm),
+			strconv.FormatInt(sum.DrivingSeconds, 10),
+			strconv.FormatInt(sum.IdleSeconds, 10),
+			strconv.FormatInt(sum.StopSeconds, 10),
+			sum.FirstStart.In(vehicle.Tokyo).Format(csvTimeLayout),
+			sum.LastEnd.In(vehicle.Tokyo).Format(csvTimeLayout),
+		})
+	}
+	return rows
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func formatOptionalFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return formatFloat(*v)
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+func TestHTTPServer_Trips(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	day := time.Date(2025, 1, 2, 0, 0, 0, 0, vehicle.Tokyo)
+	for _, trip := range []*storage.Trip{
+		{VehicleCD: 1, VehicleName: "Truck", Kind: storage.SegmentTrip, StartTime: day.Add(8 * time.Hour), EndTime: day.Add(9 * time.Hour), DistanceKm: 42.5, Closed: true},
+		{VehicleCD: 1, VehicleName: "Truck", Kind: storage.SegmentStop, StartTime: day.Add(9 * time.Hour), EndTime: day.Add(10 * time.Hour)},
+		{VehicleCD: 1, VehicleName: "Truck", Kind: storage.SegmentTrip, StartTime: day.Add(33 * time.Hour), EndTime: day.Add(34 * time.Hour)},
+		{VehicleCD: 2, VehicleName: "Van", Kind: storage.SegmentTrip, StartTime: day.Add(10 * time.Hour), EndTime: day.Add(11 * time.Hour), DistanceKm: 12},
+	} {
+		if err := server.storage.SaveTrip(trip); err != nil {
+			t.Fatalf("Failed to save trip: %v", err)
+		}
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		path   string
+		status int
+		count  float64
+	}{
+		{"/v1/vehicles/1/trips", http.StatusOK, 3},
+		{"/v1/vehicles/1/trips?date=2025-01-02", http.StatusOK, 2},
+		{"/v1/vehicles/1/trips?date=2025/01/02&kind=trip", http.StatusOK, 1},
+		{"/v1/vehicles/1/trips?date=tomorrow", http.StatusBadRequest, 0},
+		{"/v1/vehicles/1/trips?kind=drive", http.StatusBadRequest, 0},
+		{"/v1/reports/trips?date=2025-01-02", http.StatusOK, 2},
+		{"/v1/reports/trips?from=2025-01-02T00:00:00%2B09:00&to=2025-01-04T00:00:00%2B09:00", http.StatusOK, 3},
+	}
+	for _, tt := range tests {
+		w := get(tt.path)
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.status, w.Code)
+			continue
+		}
+		var body map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&body)
+		if tt.status == http.StatusOK && body["count"] != tt.count {
+			t.Errorf("%s: expected count %v, got %v", tt.path, tt.count, body["count"])
+		}
+	}
+
+	w := get("/v1/reports/trips?date=2025-01-02&format=csv")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("Expected a CSV response, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	body := w.Body.String()
+	if !strings.HasPrefix(body, "\ufeff") {
+		t.Error("Expected a UTF-8 BOM")
+	}
+	rows, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(body, "\ufeff"))).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+	if len(rows) != 3 || rows[0][0] != "date" {
+		t.Fatalf("Expected a header and 2 rows, got %v", rows)
+	}
+	if rows[1][1] != "1" || rows[1][2] != "Truck" || rows[1][5] != "42.5" {
+		t.Errorf("Unexpected report row: %v", rows[1])
+	}
+
+	w = get("/v1/vehicles/1/trips?date=2025-01-02&format=csv")
+	rows, err = csv.NewReader(strings.NewReader(strings.TrimPrefix(w.Body.String(), "\ufeff"))).ReadAll()
+	if err != nil || len(rows) != 3 || rows[1][2] != "trip" || rows[1][3] != "2025/01/02 08:00:00" {
+		t.Errorf("Unexpected trips CSV: %v (%v)", rows, err)
+	}
+}
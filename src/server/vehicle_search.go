@@ -0,0 +1,251 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/browser"
+	"github.com/yhonda-ohishi/browser_render_go/src/fleet"
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+// Temporary struct definitions until protoc generates them
+type SearchVehiclesRequest struct {
+	BranchCds  []int32
+	States     []string
+	DriverName string
+	Name       string
+	Bbox       *BoundingBox
+	Sort       string
+	MaxAge     int64 // seconds, 0 for no limit
+	PageSize   int32
+	PageToken  string
+	Fields     []string
+}
+
+type BoundingBox struct {
+	MinLatitude  float64
+	MinLongitude float64
+	MaxLatitude  float64
+	MaxLongitude float64
+}
+
+type SearchVehiclesResponse struct {
+	Vehicles      []*VehicleData
+	TotalCount    int32
+	NextPageToken string
+}
+
+// latestVehicles returns the latest stored state of every vehicle seen within
+// maxAge (0 for no limit).
+func latestVehicles(store *storage.Storage, maxAge time.Duration) ([]browser.VehicleData, error) {
+	now := time.Now()
+	var since time.Time
+	if maxAge > 0 {
+		since = now.Add(-maxAge)
+	}
+	records, err := store.ListFleetAt(now, since, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	vehicles := make([]browser.VehicleData, len(records))
+	for i, r := range records {
+		vehicles[i] = fleet.StoredVehicle(r)
+	}
+	return vehicles, nil
+}
+
+// searchPage returns one page of the matched vehicles. The page token is the
+// offset of the next page.
+func searchPage(matched []browser.VehicleData, q historyQuery) ([]browser.VehicleData, string) {
+	offset := min(int(max(q.pageToken, 0)), len(matched))
+	end := min(offset+q.pageSize, len(matched))
+	next := ""
+	if end < len(matched) {
+		next = strconv.Itoa(end)
+	}
+	return matched[offset:end], next
+}
+
+// projectVehicle returns the vehicle as a JSON object with times in
+// Asia/Tokyo. When fields is set, only those keys are kept; keys that are not
+// VehicleData fields are looked up in the raw portal record.
+func projectVehicle(vd browser.VehicleData, fields []string) map[string]interface{} {
+	for _, t := range []**time.Time{&vd.DataTime, &vd.ComuTime, &vd.StartWorkTime} {
+		if *t != nil {
+			local := (*t).In(vehicle.Tokyo)
+			*t = &local
+		}
+	}
+
+	full := make(map[string]interface{})
+	data, _ := json.Marshal(vd)
+	json.Unmarshal(data, &full)
+	if len(fields) == 0 {
+		return full
+	}
+
+	projected := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		if v, ok := full[field]; ok {
+			projected[field] = v
+		} else if v, ok := vd.Raw[field]; ok {
+			projected[field] = v
+		}
+	}
+	return projected
+}
+
+// parseBBox parses "min_lat,min_lon,max_lat,max_lon".
+func parseBBox(s string) (*fleet.BBox, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("invalid bbox: %q", s)
+	}
+	var v [4]float64
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bbox: %q", s)
+		}
+		v[i] = f
+	}
+	b := &fleet.BBox{MinLatitude: v[0], MinLongitude: v[1], MaxLatitude: v[2], MaxLongitude: v[3]}
+	if b.MinLatitude > b.MaxLatitude || b.MinLongitude > b.MaxLongitude {
+		return nil, fmt.Errorf("invalid bbox: %q", s)
+	}
+	return b, nil
+}
+
+// Vehicle search endpoint - filters, sorts and pages the latest stored state
+// of every vehicle
+func (s *HTTPServer) handleVehicleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	search := fleet.VehicleSearch{
+		States:     parseFields(query.Get("state")),
+		DriverName: query.Get("driver"),
+		Name:       query.Get("name"),
+		Sort:       query.Get("sort"),
+	}
+	for _, v := range parseFields(query.Get("branch_cd")) {
+		cd, err := strconv.Atoi(v)
+		if err != nil {
+			s.sendError(w, fmt.Sprintf("invalid branch_cd: %q", v), http.StatusBadRequest)
+			return
+		}
+		search.BranchCDs = append(search.BranchCDs, cd)
+	}
+	var err error
+	if v := query.Get("bbox"); v != "" {
+		if search.BBox, err = parseBBox(v); err != nil {
+			s.sendError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	var maxAge time.Duration
+	if v := query.Get("max_age"); v != "" {
+		if maxAge, err = time.ParseDuration(v); err != nil || maxAge < 0 {
+			s.sendError(w, fmt.Sprintf("invalid max_age: %q", v), http.StatusBadRequest)
+			return
+		}
+	}
+	limit, err := parseLimitParam(r)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q, err := newHistoryQuery(limit, query.Get("page_token"), parseFields(query.Get("fields")))
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	vehicles, err := latestVehicles(s.storage, maxAge)
+	if err != nil {
+		s.sendError(w, fmt.Sprintf("Failed to load vehicles: %v", err), http.StatusInternalServerError)
+		return
+	}
+	matched, err := fleet.SearchVehicles(vehicles, search)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, next := searchPage(matched, q)
+
+	projected := make([]map[string]interface{}, len(page))
+	for i, vd := range page {
+		projected[i] = projectVehicle(vd, q.fields)
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"vehicles":        projected,
+		"count":           len(projected),
+		"total":           len(matched),
+		"next_page_token": next,
+	}, http.StatusOK)
+}
+
+// SearchVehicles filters, sorts and pages the latest stored state of every
+// vehicle
+func (s *GRPCServer) SearchVehicles(ctx context.Context, req *SearchVehiclesRequest) (*SearchVehiclesResponse, error) {
+	log.Printf("SearchVehicles called with name=%q, driver=%q", req.Name, req.DriverName)
+
+	search := fleet.VehicleSearch{
+		States:     req.States,
+		DriverName: req.DriverName,
+		Name:       req.Name,
+		Sort:       req.Sort,
+	}
+	for _, cd := range req.BranchCds {
+		search.BranchCDs = append(search.BranchCDs, int(cd))
+	}
+	if b := req.Bbox; b != nil {
+		search.BBox = &fleet.BBox{MinLatitude: b.MinLatitude, MinLongitude: b.MinLongitude, MaxLatitude: b.MaxLatitude, MaxLongitude: b.MaxLongitude}
+	}
+	q, err := newHistoryQuery(int(req.PageSize), req.PageToken, req.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	vehicles, err := latestVehicles(s.storage, time.Duration(req.MaxAge)*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load vehicles: %w", err)
+	}
+	matched, err := fleet.SearchVehicles(vehicles, search)
+	if err != nil {
+		return nil, err
+	}
+	page, next := searchPage(matched, q)
+
+	pb := make([]*VehicleData, len(page))
+	for i, vd := range page {
+		if len(q.fields) > 0 {
+			raw := make(map[string]interface{}, len(q.fields))
+			for _, field := range q.fields {
+				if v, ok := vd.Raw[field]; ok {
+					raw[field] = v
+				}
+			}
+			vd.Raw = raw
+		}
+		pb[i] = toPbVehicleData(vd)
+	}
+
+	return &SearchVehiclesResponse{
+		Vehicles:      pb,
+		TotalCount:    int32(len(matched)),
+		NextPageToken: next,
+	}, nil
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+)
+
+func saveSearchTelemetry(t *testing.T, store *storage.Storage) {
+	t.Helper()
+	now := time.Now().Truncate(time.Second)
+	point := func(cd int64, name string, branchCD int, lat float64, at time.Time, record string) storage.TelemetryRecord {
+		lon := 139.0
+		return storage.TelemetryRecord{
+			VehicleCD: cd, DataTime: at, VehicleName: name, BranchCD: branchCD,
+			Latitude: &lat, Longitude: &lon, GPSValid: true, Record: record,
+		}
+	}
+	if err := store.SaveTelemetry([]storage.TelemetryRecord{
+		point(1, "ＡＢＣ１号車", 1, 35.1, now.Add(-time.Minute), `{"DriverName":"佐藤 太郎","AllState":"運行中","Speed":40,"GPSDirection":90}`),
+		point(2, "abc2号車", 2, 35.5, now.Add(-2*time.Minute), `{"DriverName":"鈴木 花子","AllState":"休憩中","Speed":0}`),
+		point(3, "ｶﾞｿﾘﾝ車", 1, 36.0, now.Add(-48*time.Hour), `{"DriverName":"佐藤 次郎","AllState":"運行中","Speed":60}`),
+	}); err != nil {
+		t.Fatalf("Failed to save telemetry: %v", err)
+	}
+}
+
+func TestHTTPServer_VehicleSearch(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	saveSearchTelemetry(t, server.storage)
+
+	get := func(query string) (int, map[string]interface{}) {
+		req := httptest.NewRequest("GET", "/v1/vehicles"+query, nil)
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		var body map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&body)
+		return w.Code, body
+	}
+	codes := func(body map[string]interface{}) []string {
+		var cds []string
+		for _, v := range body["vehicles"].([]interface{}) {
+			cds = append(cds, v.(map[string]interface{})["VehicleCD"].(string))
+		}
+		return cds
+	}
+
+	tests := []struct {
+		query  string
+		status int
+		want   []string
+	}{
+		{"", http.StatusOK, []string{"1", "2", "3"}},
+		{"?branch_cd=1", http.StatusOK, []string{"1", "3"}},
+		{"?state=運行中&driver=佐藤", http.StatusOK, []string{"1", "3"}},
+		{"?name=abc", http.StatusOK, []string{"1", "2"}},
+		{"?name=ガソリン", http.StatusOK, []string{"3"}},
+		{"?bbox=35,138,35.6,140", http.StatusOK, []string{"1", "2"}},
+		{"?max_age=1h", http.StatusOK, []string{"1", "2"}},
+		{"?sort=-vehicle_name", http.StatusOK, []string{"3", "2", "1"}},
+		{"?sort=color", http.StatusBadRequest, nil},
+		{"?bbox=35,138", http.StatusBadRequest, nil},
+		{"?branch_cd=x", http.StatusBadRequest, nil},
+		{"?page_token=x", http.StatusBadRequest, nil},
+		{"?limit=ten", http.StatusBadRequest, nil},
+		{"?limit=0", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		code, body := get(tt.query)
+		if code != tt.status {
+			t.Errorf("Query %q: expected status %d, got %d", tt.query, tt.status, code)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		got := codes(body)
+		if len(got) != len(tt.want) {
+			t.Errorf("Query %q: expected vehicles %v, got %v", tt.query, tt.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Query %q: expected vehicles %v, got %v", tt.query, tt.want, got)
+				break
+			}
+		}
+	}
+
+	// Pagination
+	_, first := get("?limit=2")
+	if first["count"] != float64(2) || first["total"] != float64(3) || first["next_page_token"] != "2" {
+		t.Errorf("Unexpected first page: count=%v total=%v next=%v", first["count"], first["total"], first["next_page_token"])
+	}
+	_, second := get("?limit=2&page_token=2")
+	if got := codes(second); len(got) != 1 || got[0] != "3" || second["next_page_token"] != "" {
+		t.Errorf("Unexpected second page: %v, next=%v", got, second["next_page_token"])
+	}
+
+	// Projection, with portal fields looked up in the raw record
+	_, projected := get("?fields=VehicleName,GPSDirection&limit=1")
+	v := projected["vehicles"].([]interface{})[0].(map[string]interface{})
+	if len(v) != 2 || v["VehicleName"] != "ＡＢＣ１号車" || v["GPSDirection"] != float64(90) {
+		t.Errorf("Unexpected projection: %v", v)
+	}
+}
+
+func TestGRPCServer_SearchVehicles(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	saveSearchTelemetry(t, server.storage)
+
+	grpcServer := NewGRPCServer(server.config, server.storage, nil)
+	resp, err := grpcServer.SearchVehicles(context.Background(), &SearchVehiclesRequest{
+		BranchCds: []int32{1},
+		Sort:      "-speed",
+		PageSize:  1,
+		Fields:    []string{"Speed"},
+	})
+	if err != nil {
+		t.Fatalf("SearchVehicles failed: %v", err)
+	}
+	if resp.TotalCount != 2 || len(resp.Vehicles) != 1 || resp.Vehicles[0].VehicleCd != "3" || resp.NextPageToken != "1" {
+		t.Fatalf("Unexpected response: %+v", resp)
+	}
+	if raw := resp.Vehicles[0].Raw.AsMap(); len(raw) != 1 || raw["Speed"] != float64(60) {
+		t.Errorf("Expected projected raw record, got %v", raw)
+	}
+
+	bbox := &BoundingBox{MinLatitude: 35.4, MinLongitude: 138, MaxLatitude: 35.6, MaxLongitude: 140}
+	resp, err = grpcServer.SearchVehicles(context.Background(), &SearchVehiclesRequest{Bbox: bbox})
+	if err != nil || resp.TotalCount != 1 || resp.Vehicles[0].VehicleCd != "2" {
+		t.Errorf("Expected only vehicle 2 inside the box, got %+v (%v)", resp, err)
+	}
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+	"github.com/yhonda-ohishi/browser_render_go/src/vehicle"
+)
+
+type mergeVehicleRequest struct {
+	MergeID int64 `json:"merge_id"`
+}
+
+// Vehicle registry endpoint - lists registry entries
+func (s *HTTPServer) handleRegistryVehicles(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	includeMerged, _ := strconv.ParseBool(query.Get("include_merged"))
+	vehicles, err := s.storage.ListVehicles(includeMerged)
+	if err != nil {
+		s.sendError(w, fmt.Sprintf("Failed to list vehicles: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	vehicles = filterByPlate(vehicles, query.Get("plate"), vehicle.Plate{
+		Region: query.Get("region"),
+		Class:  query.Get("class"),
+		Kana:   query.Get("kana"),
+		Serial: query.Get("serial"),
+	})
+
+	s.sendJSON(w, map[string]interface{}{
+		"vehicles": vehicles,
+		"count":    len(vehicles),
+	}, http.StatusOK)
+}
+
+// filterByPlate keeps the vehicles whose plate contains text (compared in
+// normalized form without spaces) and matches every set part of q.
+func filterByPlate(vehicles []storage.RegisteredVehicle, text string, q vehicle.Plate) []storage.RegisteredVehicle {
+	text = strings.ReplaceAll(vehicle.NormalizePlateText(text), " ", "")
+	if text == "" && q == (vehicle.Plate{}) {
+		return vehicles
+	}
+
+	filtered := make([]storage.RegisteredVehicle, 0, len(vehicles))
+	for _, v := range vehicles {
+		plate, ok := vehicle.ParsePlate(v.Plate)
+		if !ok {
+			continue
+		}
+		if text != "" && !strings.Contains(plate.Key(), text) && !strings.Contains(strings.ReplaceAll(plate.String(), " ", ""), text) {
+			continue
+		}
+		if !plate.Matches(q) {
+			continue
+		}
+		filtered = append(filtered, v)
+	}
+	return filtered
+}
+
+// Vehicle registry entry endpoint - gets an entry, lists collisions or merges
+// another entry into this one
+func (s *HTTPServer) handleRegistryVehicle(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path[len("/v1/admin/vehicles/"):]
+	if path == "collisions" {
+		s.handleVehicleCollisions(w, r)
+		return
+	}
+
+	idStr, action, _ := strings.Cut(path, "/")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || id <= 0 {
+		s.sendError(w, "Invalid vehicle ID", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		vehicle, err := s.storage.GetVehicle(id)
+		if err != nil {
+			s.sendError(w, fmt.Sprintf("Failed to get vehicle: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if vehicle == nil {
+			s.sendError(w, "Vehicle not found", http.StatusNotFound)
+			return
+		}
+		s.sendJSON(w, vehicle, http.StatusOK)
+
+	case action == "merge" && r.Method == http.MethodPost:
+		var req mergeVehicleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.sendError(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		if req.MergeID <= 0 {
+			s.sendError(w, "merge_id is required", http.StatusBadRequest)
+			return
+		}
+
+		for _, checkID := range []int64{id, req.MergeID} {
+			vehicle, err := s.storage.GetVehicle(checkID)
+			if err != nil {
+				s.sendError(w, fmt.Sprintf("Failed to get vehicle: %v", err), http.StatusInternalServerError)
+				return
+			}
+			if vehicle == nil {
+				s.sendError(w, fmt.Sprintf("Vehicle %d not found", checkID), http.StatusNotFound)
+				return
+			}
+		}
+
+		if err := s.storage.MergeVehicles(id, req.MergeID); err != nil {
+			s.sendError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		vehicle, err := s.storage.GetVehicle(id)
+		if err != nil {
+			s.sendError(w, fmt.Sprintf("Failed to get vehicle: %v", err), http.StatusInternalServerError)
+			return
+		}
+		s.sendJSON(w, vehicle, http.StatusOK)
+
+	case action == "" || action == "merge":
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+
+	default:
+		s.notFound(w, r)
+	}
+}
+
+// Vehicle collisions endpoint - lists names and plates shared by different
+// portal vehicle codes
+func (s *HTTPServer) handleVehicleCollisions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	includeResolved, _ := strconv.ParseBool(r.URL.Query().Get("include_resolved"))
+	collisions, err := s.storage.ListVehicleCollisions(includeResolved)
+	if err != nil {
+		s.sendError(w, fmt.Sprintf("Failed to list collisions: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"collisions": collisions,
+		"count":      len(collisions),
+	}, http.StatusOK)
+}
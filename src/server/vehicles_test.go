@@ -0,0 +1,129 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/yhonda-ohishi/browser_render_go/src/storage"
+)
+
+func TestHTTPServer_VehicleRegistry(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	ids, err := server.storage.RegisterVehicles([]storage.VehicleObservation{
+		{VehicleCD: 1, VehicleName: "Truck"},
+		{VehicleCD: 2, VehicleName: "Truck"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to register vehicles: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/v1/admin/vehicles/collisions", nil)
+	w := httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+	var body map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&body)
+	if w.Code != http.StatusOK || body["count"] != float64(1) {
+		t.Fatalf("Expected 1 collision, got %d: %v", w.Code, body)
+	}
+
+	mergeBody := fmt.Sprintf(`{"merge_id":%d}`, ids[1])
+	req = httptest.NewRequest("POST", fmt.Sprintf("/v1/admin/vehicles/%d/merge", ids[0]), bytes.NewBufferString(mergeBody))
+	w = httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/v1/admin/vehicles", nil)
+	w = httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+	json.NewDecoder(w.Body).Decode(&body)
+	if body["count"] != float64(1) {
+		t.Errorf("Expected 1 active vehicle after merge, got %v", body["count"])
+	}
+
+	req = httptest.NewRequest("GET", "/v1/admin/vehicles?include_merged=true", nil)
+	w = httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+	json.NewDecoder(w.Body).Decode(&body)
+	if body["count"] != float64(2) {
+		t.Errorf("Expected 2 vehicles including merged, got %v", body["count"])
+	}
+}
+
+func TestHTTPServer_VehicleRegistryErrors(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	ids, _ := server.storage.RegisterVehicles([]storage.VehicleObservation{{VehicleCD: 1, VehicleName: "Truck"}})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"invalid id", "GET", "/v1/admin/vehicles/abc", "", http.StatusBadRequest},
+		{"unknown vehicle", "GET", "/v1/admin/vehicles/999", "", http.StatusNotFound},
+		{"wrong method", "DELETE", fmt.Sprintf("/v1/admin/vehicles/%d", ids[0]), "", http.StatusMethodNotAllowed},
+		{"missing merge id", "POST", fmt.Sprintf("/v1/admin/vehicles/%d/merge", ids[0]), `{}`, http.StatusBadRequest},
+		{"merge unknown", "POST", fmt.Sprintf("/v1/admin/vehicles/%d/merge", ids[0]), `{"merge_id":999}`, http.StatusNotFound},
+		{"merge into itself", "POST", fmt.Sprintf("/v1/admin/vehicles/%d/merge", ids[0]), fmt.Sprintf(`{"merge_id":%d}`, ids[0]), http.StatusBadRequest},
+		{"unknown action", "GET", fmt.Sprintf("/v1/admin/vehicles/%d/other", ids[0]), "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			server.mux.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHTTPServer_VehicleRegistryPlateSearch(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	if _, err := server.storage.RegisterVehicles([]storage.VehicleObservation{
+		{VehicleCD: 1, VehicleName: "品川 300 あ 12-34", Plate: "品川300あ1234"},
+		{VehicleCD: 2, VehicleName: "横浜 500 さ ・・・1", Plate: "横浜500さ1"},
+		{VehicleCD: 3, VehicleName: "No plate"},
+	}); err != nil {
+		t.Fatalf("Failed to register vehicles: %v", err)
+	}
+
+	tests := []struct {
+		query string
+		count float64
+	}{
+		{"", 3},
+		{"?region=%E5%93%81%E5%B7%9D", 1}, // region=品川
+		{"?serial=%EF%BC%91%EF%BC%92-%EF%BC%93%EF%BC%94", 1}, // serial=１２-３４ (full width)
+		{"?plate=300%E3%81%82", 1},                           // plate=300あ
+		{"?class=500&serial=1", 1},
+		{"?class=999", 0},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/v1/admin/vehicles"+tt.query, nil)
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+
+		var body map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&body)
+		if body["count"] != tt.count {
+			t.Errorf("Query %q: expected %v vehicles, got %v", tt.query, tt.count, body["count"])
+		}
+	}
+}
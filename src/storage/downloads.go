@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Download is a report file downloaded from the portal.
+type Download struct {
+	ID          string    `json:"id"`
+	JobID       string    `json:"job_id,omitempty"`
+	ReportType  string    `json:"report_type"`
+	DateFrom    string    `json:"date_from,omitempty"`
+	DateTo      string    `json:"date_to,omitempty"`
+	Account     string    `json:"account,omitempty"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int       `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+	Data        []byte    `json:"-"`
+}
+
+func (s *Storage) SaveDownload(download *Download) error {
+	query := `
+		INSERT INTO downloads (id, job_id, report_type, date_from, date_to, account,
+			filename, content_type, size, data, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := s.db.Exec(query,
+		download.ID,
+		download.JobID,
+		download.ReportType,
+		download.DateFrom,
+		download.DateTo,
+		download.Account,
+		download.Filename,
+		download.ContentType,
+		len(download.Data),
+		download.Data,
+		download.CreatedAt,
+	)
+	return err
+}
+
+// GetDownload returns the download including its data, or nil if it does not exist.
+func (s *Storage) GetDownload(id string) (*Download, error) {
+	query := `
+		SELECT id, job_id, report_type, date_from, date_to, account,
+			filename, content_type, size, data, created_at
+		FROM downloads
+		WHERE id = ?
+	`
+	var download Download
+	err := s.db.QueryRow(query, id).Scan(
+		&download.ID,
+		&download.JobID,
+		&download.ReportType,
+		&download.DateFrom,
+		&download.DateTo,
+		&download.Account,
+		&download.Filename,
+		&download.ContentType,
+		&download.Size,
+		&download.Data,
+		&download.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &download, nil
+}
+
+// ListDownloads returns download metadata, newest first, without the data.
+// An empty reportType lists every report type.
+func (s *Storage) ListDownloads(reportType string, limit int) ([]Download, error) {
+	query := `
+		SELECT id, job_id, report_type, date_from, date_to, account,
+			filename, content_type, size, created_at
+		FROM downloads
+		WHERE (? = '' OR report_type = ?)
+		ORDER BY created_at DESC
+		LIMIT ?
+	`
+	rows, err := s.db.Query(query, reportType, reportType, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var downloads []Download
+	for rows.Next() {
+		var download Download
+		if err := rows.Scan(
+			&download.ID,
+			&download.JobID,
+			&download.ReportType,
+			&download.DateFrom,
+			&download.DateTo,
+			&download.Account,
+			&download.Filename,
+			&download.ContentType,
+			&download.Size,
+			&download.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		downloads = append(downloads, download)
+	}
+
+	return downloads, rows.Err()
+}
+
+func (s *Storage) DeleteDownload(id string) error {
+	_, err := s.db.Exec("DELETE FROM downloads WHERE id = ?", id)
+	return err
+}
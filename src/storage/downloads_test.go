@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStorage_Downloads(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	downloads := []*Download{
+		{
+			ID:          "dl-1",
+			JobID:       "job-1",
+			ReportType:  "daily",
+			DateFrom:    "2025/09/01",
+			DateTo:      "2025/09/30",
+			Account:     "12345/user",
+			Filename:    "daily.csv",
+			ContentType: "text/csv",
+			CreatedAt:   time.Now().Add(-time.Minute),
+			Data:        []byte("a,b\n1,2\n"),
+		},
+		{
+			ID:          "dl-2",
+			ReportType:  "monthly",
+			Filename:    "monthly.xlsx",
+			ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+			CreatedAt:   time.Now(),
+			Data:        []byte("xlsx"),
+		},
+	}
+	for _, d := range downloads {
+		if err := store.SaveDownload(d); err != nil {
+			t.Fatalf("Failed to save download: %v", err)
+		}
+	}
+
+	retrieved, err := store.GetDownload("dl-1")
+	if err != nil {
+		t.Fatalf("Failed to get download: %v", err)
+	}
+	if retrieved == nil {
+		t.Fatal("Expected download, got nil")
+	}
+	if retrieved.Account != "12345/user" || retrieved.DateTo != "2025/09/30" {
+		t.Errorf("Metadata not preserved: %+v", retrieved)
+	}
+	if string(retrieved.Data) != "a,b\n1,2\n" {
+		t.Errorf("Unexpected data %q", retrieved.Data)
+	}
+
+	all, err := store.ListDownloads("", 10)
+	if err != nil {
+		t.Fatalf("Failed to list downloads: %v", err)
+	}
+	if len(all) != 2 || all[0].ID != "dl-2" {
+		t.Errorf("Expected newest first, got %+v", all)
+	}
+
+	daily, err := store.ListDownloads("daily", 10)
+	if err != nil {
+		t.Fatalf("Failed to list downloads: %v", err)
+	}
+	if len(daily) != 1 || daily[0].ID != "dl-1" {
+		t.Errorf("Expected only daily report, got %+v", daily)
+	}
+
+	if err := store.DeleteDownload("dl-1"); err != nil {
+		t.Fatalf("Failed to delete download: %v", err)
+	}
+	if d, _ := store.GetDownload("dl-1"); d != nil {
+		t.Error("Expected nil after delete")
+	}
+}
@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Event is a typed change detected between two states of a vehicle.
+type Event struct {
+	ID          int64                  `json:"id"`
+	Type        string                 `json:"type"`
+	VehicleCD   int64                  `json:"vehicle_cd"`
+	VehicleID   int64                  `json:"vehicle_id,omitempty"`
+	VehicleName string                 `json:"vehicle_name"`
+	BranchCD    int                    `json:"branch_cd"`
+	Time        time.Time              `json:"time"`
+	From        string                 `json:"from,omitempty"`
+	To          string                 `json:"to,omitempty"`
+	Latitude    *float64               `json:"latitude,omitempty"`
+	Longitude   *float64               `json:"longitude,omitempty"`
+	Detail      map[string]interface{} `json:"detail,omitempty"`
+	CreatedAt   time.Time              `json:"created_at"`
+}
+
+// EventFilter selects events. Zero values leave a condition out.
+type EventFilter struct {
+	VehicleCD int64
+	Types     []string
+	From      time.Time // inclusive
+	To        time.Time // exclusive
+	AfterID   int64     // only events with a larger ID, for tailing
+	Limit     int
+}
+
+// SaveEvents stores the events and sets their IDs. An event with the same
+// type, vehicle, time and new value as a stored one is skipped, so the same
+// change is never recorded twice.
+func (s *Storage) SaveEvents(events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT OR IGNORE INTO vehicle_events (
+			type, vehicle_cd, vehicle_id, vehicle_name, branch_cd, time,
+			from_value, to_value, latitude, longitude, detail
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for i := range events {
+		e := &events[i]
+		var detail sql.NullString
+		if len(e.Detail) > 0 {
+			data, err := json.Marshal(e.Detail)
+			if err != nil {
+				return err
+			}
+			detail = sql.NullString{String: string(data), Valid: true}
+		}
+
+		result, err := stmt.Exec(
+			e.Type, e.VehicleCD, e.VehicleID, e.VehicleName, e.BranchCD, e.Time.Unix(),
+			e.From, e.To, e.Latitude, e.Longitude, detail,
+		)
+		if err != nil {
+			return err
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			e.ID, _ = result.LastInsertId()
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListEvents returns the matching events in the order they were recorded.
+func (s *Storage) ListEvents(f EventFilter) ([]Event, error) {
+	query := `
+		SELECT id, type, vehicle_cd, vehicle_id, vehicle_name, branch_cd, time,
+			from_value, to_value, latitude, longitude, detail, created_at
+		FROM vehicle_events
+		WHERE id > ?`
+	args := []interface{}{f.AfterID}
+	if f.VehicleCD != 0 {
+		query += ` AND vehicle_cd = ?`
+		args = append(args, f.VehicleCD)
+	}
+	if len(f.Types) > 0 {
+		query += ` AND type IN (?` + strings.Repeat(", ?", len(f.Types)-1) + `)`
+		for _, t := range f.Types {
+			args = append(args, t)
+		}
+	}
+	if !f.From.IsZero() {
+		query += ` AND time >= ?`
+		args = append(args, f.From.Unix())
+	}
+	if !f.To.IsZero() {
+		query += ` AND time < ?`
+		args = append(args, f.To.Unix())
+	}
+	query += ` ORDER BY id`
+	if f.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, f.Limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var e Event
+		var eventTime int64
+		var lat, lon sql.NullFloat64
+		var detail sql.NullString
+		if err := rows.Scan(
+			&e.ID, &e.Type, &e.VehicleCD, &e.VehicleID, &e.VehicleName, &e.BranchCD, &eventTime,
+			&e.From, &e.To, &lat, &lon, &detail, &e.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		e.Time = time.Unix(eventTime, 0)
+		if lat.Valid && lon.Valid {
+			e.Latitude = &lat.Float64
+			e.Longitude = &lon.Float64
+		}
+		if detail.Valid {
+			json.Unmarshal([]byte(detail.String), &e.Detail)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
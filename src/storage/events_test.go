@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStorage_Events(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	base := time.Date(2025, 1, 2, 3, 0, 0, 0, time.UTC)
+	events := []Event{
+		{Type: "moved", VehicleCD: 1, Time: base, From: "35,139", To: "35.01,139", Detail: map[string]interface{}{"distance_m": float64(1112)}},
+		{Type: "driver_changed", VehicleCD: 1, Time: base.Add(time.Minute), From: "10", To: "11"},
+		{Type: "moved", VehicleCD: 2, Time: base.Add(2 * time.Minute)},
+	}
+	if err := store.SaveEvents(events); err != nil {
+		t.Fatalf("Failed to save events: %v", err)
+	}
+	if events[0].ID == 0 || events[2].ID <= events[0].ID {
+		t.Errorf("Expected increasing IDs, got %d, %d", events[0].ID, events[2].ID)
+	}
+
+	// The same change is not recorded twice
+	if err := store.SaveEvents(events[:1]); err != nil {
+		t.Fatalf("Failed to save events again: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter EventFilter
+		count  int
+	}{
+		{"all", EventFilter{}, 3},
+		{"vehicle", EventFilter{VehicleCD: 1}, 2},
+		{"types", EventFilter{Types: []string{"moved"}}, 2},
+		{"time range", EventFilter{From: base.Add(time.Minute), To: base.Add(2 * time.Minute)}, 1},
+		{"after id", EventFilter{AfterID: events[0].ID}, 2},
+		{"limit", EventFilter{Limit: 1}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.ListEvents(tt.filter)
+			if err != nil {
+				t.Fatalf("Failed to list events: %v", err)
+			}
+			if len(got) != tt.count {
+				t.Errorf("Expected %d events, got %d", tt.count, len(got))
+			}
+		})
+	}
+
+	got, _ := store.ListEvents(EventFilter{Limit: 1})
+	if got[0].Detail["distance_m"] != float64(1112) || got[0].To != "35.01,139" {
+		t.Errorf("Expected detail to round-trip, got %+v", got[0])
+	}
+}
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// Geofence shapes.
+const (
+	ShapeCircle  = "circle"
+	ShapePolygon = "polygon"
+)
+
+// Geofence is a named area. A circle uses Latitude, Longitude and Radius;
+// a polygon uses Polygon, a list of [latitude, longitude] vertices. All
+// coordinates are WGS84.
+type Geofence struct {
+	ID           int64        `json:"id"`
+	Name         string       `json:"name"`
+	Category     string       `json:"category"` // e.g. depot, customer, restricted
+	Shape        string       `json:"shape"`
+	Latitude     float64      `json:"latitude,omitempty"`
+	Longitude    float64      `json:"longitude,omitempty"`
+	Radius       float64      `json:"radius,omitempty"` // meters
+	Polygon      [][2]float64 `json:"polygon,omitempty"`
+	DwellSeconds int64        `json:"dwell_seconds,omitempty"` // 0 uses the default
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
+
+// CreateGeofence stores a new geofence and sets its ID.
+func (s *Storage) CreateGeofence(g *Geofence) error {
+	polygon, err := marshalPolygon(g.Polygon)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.Exec(`
+		INSERT INTO geofences (name, category, shape, latitude, longitude, radius, polygon, dwell_seconds)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, g.Name, g.Category, g.Shape, g.Latitude, g.Longitude, g.Radius, polygon, g.DwellSeconds)
+	if err != nil {
+		return err
+	}
+	g.ID, err = result.LastInsertId()
+	return err
+}
+
+// UpdateGeofence replaces a geofence. It returns false if the ID does not
+// exist.
+func (s *Storage) UpdateGeofence(g *Geofence) (bool, error) {
+	polygon, err := marshalPolygon(g.Polygon)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := s.db.Exec(`
+		UPDATE geofences
+		SET name = ?, category = ?, shape = ?, latitude = ?, longitude = ?, radius = ?,
+			polygon = ?, dwell_seconds = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, g.Name, g.Category, g.Shape, g.Latitude, g.Longitude, g.Radius, polygon, g.DwellSeconds, g.ID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// GetGeofence returns a geofence, or nil if it does not exist.
+func (s *Storage) GetGeofence(id int64) (*Geofence, error) {
+	rows, err := s.db.Query(geofenceSelect+` WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fences, err := scanGeofences(rows)
+	if err != nil || len(fences) == 0 {
+		return nil, err
+	}
+	return &fences[0], nil
+}
+
+// ListGeofences returns every geofence ordered by ID.
+func (s *Storage) ListGeofences() ([]Geofence, error) {
+	rows, err := s.db.Query(geofenceSelect + ` ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanGeofences(rows)
+}
+
+// DeleteGeofence removes a geofence. It returns false if the ID does not
+// exist.
+func (s *Storage) DeleteGeofence(id int64) (bool, error) {
+	result, err := s.db.Exec("DELETE FROM geofences WHERE id = ?", id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+const geofenceSelect = `
+	SELECT id, name, category, shape, latitude, longitude, radius, polygon, dwell_seconds,
+		created_at, updated_at
+	FROM geofences`
+
+func scanGeofences(rows *sql.Rows) ([]Geofence, error) {
+	var fences []Geofence
+	for rows.Next() {
+		var g Geofence
+		var polygon sql.NullString
+		if err := rows.Scan(
+			&g.ID, &g.Name, &g.Category, &g.Shape, &g.Latitude, &g.Longitude, &g.Radius, &polygon,
+			&g.DwellSeconds, &g.CreatedAt, &g.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if polygon.Valid {
+			if err := json.Unmarshal([]byte(polygon.String), &g.Polygon); err != nil {
+				return nil, err
+			}
+		}
+		fences = append(fences, g)
+	}
+	return fences, rows.Err()
+}
+
+func marshalPolygon(polygon [][2]float64) (sql.NullString, error) {
+	if len(polygon) == 0 {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(polygon)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
@@ -0,0 +1,40 @@
+package storage
+
+import "testing"
+
+func TestStorage_Geofences(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	g := &Geofence{Name: "Site", Category: "customer", Shape: ShapePolygon, Polygon: [][2]float64{{35, 139}, {35, 139.1}, {35.1, 139.1}}, DwellSeconds: 300}
+	if err := store.CreateGeofence(g); err != nil {
+		t.Fatalf("Failed to create geofence: %v", err)
+	}
+
+	got, err := store.GetGeofence(g.ID)
+	if err != nil || got == nil {
+		t.Fatalf("Failed to get geofence: %v", err)
+	}
+	if len(got.Polygon) != 3 || got.Polygon[2] != [2]float64{35.1, 139.1} || got.DwellSeconds != 300 {
+		t.Errorf("Expected polygon to round-trip, got %+v", got)
+	}
+
+	got.Shape, got.Polygon, got.Latitude, got.Longitude, got.Radius = ShapeCircle, nil, 35, 139, 100
+	if found, err := store.UpdateGeofence(got); err != nil || !found {
+		t.Fatalf("Failed to update geofence: %v", err)
+	}
+	got, _ = store.GetGeofence(g.ID)
+	if got.Shape != ShapeCircle || got.Polygon != nil || got.Radius != 100 {
+		t.Errorf("Expected circle after update, got %+v", got)
+	}
+
+	if found, _ := store.UpdateGeofence(&Geofence{ID: 999, Name: "X", Shape: ShapeCircle}); found {
+		t.Error("Expected update of unknown geofence to report not found")
+	}
+	if found, err := store.DeleteGeofence(g.ID); err != nil || !found {
+		t.Fatalf("Failed to delete geofence: %v", err)
+	}
+	if got, _ := store.GetGeofence(g.ID); got != nil {
+		t.Errorf("Expected geofence to be deleted, got %+v", got)
+	}
+}
@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// VehicleHealth is the data-quality status of a vehicle's telematics device
+// as of its last check. Since is when the vehicle entered Status.
+type VehicleHealth struct {
+	VehicleCD   int64      `json:"vehicle_cd"`
+	VehicleID   int64      `json:"vehicle_id,omitempty"`
+	VehicleName string     `json:"vehicle_name"`
+	BranchCD    int        `json:"branch_cd"`
+	Status      string     `json:"status"`
+	Since       time.Time  `json:"since"`
+	LastContact *time.Time `json:"last_contact,omitempty"` // latest of DataDateTime and ComuDateTime
+	GPSValid    bool       `json:"gps_valid"`
+	CheckedAt   time.Time  `json:"checked_at"`
+}
+
+// HealthFilter selects vehicle health rows. Zero values leave a condition
+// out.
+type HealthFilter struct {
+	Status   string
+	BranchCD int
+}
+
+// SaveHealth upserts the vehicles' health in one transaction.
+func (s *Storage) SaveHealth(health []VehicleHealth) error {
+	if len(health) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO vehicle_health (
+			vehicle_cd, vehicle_id, vehicle_name, branch_cd, status, since,
+			last_contact, gps_valid, checked_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(vehicle_cd) DO UPDATE SET
+			vehicle_id = excluded.vehicle_id,
+			vehicle_name = excluded.vehicle_name,
+			branch_cd = excluded.branch_cd,
+			status = excluded.status,
+			since = excluded.since,
+			last_contact = excluded.last_contact,
+			gps_valid = excluded.gps_valid,
+			checked_at = excluded.checked_at
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, h := range health {
+		var lastContact interface{}
+		if h.LastContact != nil {
+			lastContact = h.LastContact.Unix()
+		}
+		if _, err := stmt.Exec(
+			h.VehicleCD, h.VehicleID, h.VehicleName, h.BranchCD, h.Status, h.Since.Unix(),
+			lastContact, h.GPSValid, h.CheckedAt.Unix(),
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListHealth returns the matching vehicles ordered by VehicleCD.
+func (s *Storage) ListHealth(f HealthFilter) ([]VehicleHealth, error) {
+	query := `
+		SELECT vehicle_cd, vehicle_id, vehicle_name, branch_cd, status, since,
+			last_contact, gps_valid, checked_at
+		FROM vehicle_health
+		WHERE 1 = 1`
+	var args []interface{}
+	if f.Status != "" {
+		query += ` AND status = ?`
+		args = append(args, f.Status)
+	}
+	if f.BranchCD != 0 {
+		query += ` AND branch_cd = ?`
+		args = append(args, f.BranchCD)
+	}
+	query += ` ORDER BY vehicle_cd`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var health []VehicleHealth
+	for rows.Next() {
+		var h VehicleHealth
+		var since, checkedAt int64
+		var lastContact sql.NullInt64
+		if err := rows.Scan(
+			&h.VehicleCD, &h.VehicleID, &h.VehicleName, &h.BranchCD, &h.Status, &since,
+			&lastContact, &h.GPSValid, &checkedAt,
+		); err != nil {
+			return nil, err
+		}
+		h.Since = time.Unix(since, 0)
+		h.CheckedAt = time.Unix(checkedAt, 0)
+		if lastContact.Valid {
+			t := time.Unix(lastContact.Int64, 0)
+			h.LastContact = &t
+		}
+		health = append(health, h)
+	}
+	return health, rows.Err()
+}
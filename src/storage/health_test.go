@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStorage_Health(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	base := time.Date(2025, 1, 2, 3, 0, 0, 0, time.UTC)
+	contact := base.Add(-time.Hour)
+	health := []VehicleHealth{
+		{VehicleCD: 2, VehicleName: "Van", BranchCD: 1, Status: "stale", Since: base, LastContact: &contact, CheckedAt: base},
+		{VehicleCD: 1, VehicleName: "Truck", BranchCD: 2, Status: "fresh", Since: base, LastContact: &base, GPSValid: true, CheckedAt: base},
+		{VehicleCD: 3, BranchCD: 1, Status: "offline", Since: base, CheckedAt: base},
+	}
+	if err := store.SaveHealth(health); err != nil {
+		t.Fatalf("Failed to save health: %v", err)
+	}
+
+	// Saving a vehicle again replaces its row
+	health[0].Status = "fresh"
+	if err := store.SaveHealth(health[:1]); err != nil {
+		t.Fatalf("Failed to save health again: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter HealthFilter
+		count  int
+	}{
+		{"all", HealthFilter{}, 3},
+		{"status", HealthFilter{Status: "fresh"}, 2},
+		{"branch", HealthFilter{BranchCD: 1}, 2},
+		{"status and branch", HealthFilter{Status: "offline", BranchCD: 1}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.ListHealth(tt.filter)
+			if err != nil {
+				t.Fatalf("Failed to list health: %v", err)
+			}
+			if len(got) != tt.count {
+				t.Errorf("Expected %d rows, got %d", tt.count, len(got))
+			}
+		})
+	}
+
+	got, err := store.ListHealth(HealthFilter{})
+	if err != nil {
+		t.Fatalf("Failed to list health: %v", err)
+	}
+	if got[0].VehicleCD != 1 || !got[0].GPSValid || got[0].LastContact == nil || !got[0].LastContact.Equal(base) {
+		t.Errorf("Unexpected first row: %+v", got[0])
+	}
+	if got[2].LastContact != nil {
+		t.Errorf("Expected no last contact, got %v", got[2].LastContact)
+	}
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Recipe is a stored extraction recipe. Definition holds the recipe as JSON.
+type Recipe struct {
+	Name       string
+	Definition string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// SaveRecipe creates the recipe or replaces the definition of an existing one.
+func (s *Storage) SaveRecipe(name, definition string) error {
+	query := `
+		INSERT INTO recipes (name, definition, created_at, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			definition = excluded.definition,
+			updated_at = excluded.updated_at
+	`
+	now := time.Now()
+	_, err := s.db.Exec(query, name, definition, now, now)
+	return err
+}
+
+// GetRecipe returns the recipe, or nil if it does not exist.
+func (s *Storage) GetRecipe(name string) (*Recipe, error) {
+	query := `
+		SELECT name, definition, created_at, updated_at
+		FROM recipes
+		WHERE name = ?
+	`
+	var recipe Recipe
+	err := s.db.QueryRow(query, name).Scan(
+		&recipe.Name,
+		&recipe.Definition,
+		&recipe.CreatedAt,
+		&recipe.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &recipe, nil
+}
+
+func (s *Storage) ListRecipes() ([]Recipe, error) {
+	query := `
+		SELECT name, definition, created_at, updated_at
+		FROM recipes
+		ORDER BY name
+	`
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recipes []Recipe
+	for rows.Next() {
+		var recipe Recipe
+		if err := rows.Scan(&recipe.Name, &recipe.Definition, &recipe.CreatedAt, &recipe.UpdatedAt); err != nil {
+			return nil, err
+		}
+		recipes = append(recipes, recipe)
+	}
+
+	return recipes, rows.Err()
+}
+
+func (s *Storage) DeleteRecipe(name string) error {
+	_, err := s.db.Exec("DELETE FROM recipes WHERE name = ?", name)
+	return err
+}
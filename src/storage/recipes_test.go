@@ -0,0 +1,43 @@
+package storage
+
+import "testing"
+
+func TestStorage_Recipes(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	if err := store.SaveRecipe("b_recipe", `{"name":"b_recipe"}`); err != nil {
+		t.Fatalf("Failed to save recipe: %v", err)
+	}
+	if err := store.SaveRecipe("a_recipe", `{"name":"a_recipe"}`); err != nil {
+		t.Fatalf("Failed to save recipe: %v", err)
+	}
+
+	// Saving again replaces the definition
+	if err := store.SaveRecipe("a_recipe", `{"name":"a_recipe","description":"v2"}`); err != nil {
+		t.Fatalf("Failed to update recipe: %v", err)
+	}
+
+	recipe, err := store.GetRecipe("a_recipe")
+	if err != nil {
+		t.Fatalf("Failed to get recipe: %v", err)
+	}
+	if recipe == nil || recipe.Definition != `{"name":"a_recipe","description":"v2"}` {
+		t.Errorf("Expected updated definition, got %+v", recipe)
+	}
+
+	recipes, err := store.ListRecipes()
+	if err != nil {
+		t.Fatalf("Failed to list recipes: %v", err)
+	}
+	if len(recipes) != 2 || recipes[0].Name != "a_recipe" {
+		t.Errorf("Expected recipes ordered by name, got %+v", recipes)
+	}
+
+	if err := store.DeleteRecipe("a_recipe"); err != nil {
+		t.Fatalf("Failed to delete recipe: %v", err)
+	}
+	if r, _ := store.GetRecipe("a_recipe"); r != nil {
+		t.Error("Expected nil after delete")
+	}
+}
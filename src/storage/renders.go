@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Render is a stored screenshot or PDF.
+type Render struct {
+	ID          string    `json:"id"`
+	Kind        string    `json:"kind"` // "screenshot" or "pdf"
+	URL         string    `json:"url"`
+	ContentType string    `json:"content_type"`
+	Size        int       `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+	Data        []byte    `json:"-"`
+}
+
+func (s *Storage) SaveRender(render *Render) error {
+	query := `
+		INSERT INTO renders (id, kind, url, content_type, size, data, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := s.db.Exec(query,
+		render.ID,
+		render.Kind,
+		render.URL,
+		render.ContentType,
+		len(render.Data),
+		render.Data,
+		render.CreatedAt,
+	)
+	return err
+}
+
+// GetRender returns the render including its data, or nil if it does not exist.
+func (s *Storage) GetRender(id string) (*Render, error) {
+	query := `
+		SELECT id, kind, url, content_type, size, data, created_at
+		FROM renders
+		WHERE id = ?
+	`
+	var render Render
+	err := s.db.QueryRow(query, id).Scan(
+		&render.ID,
+		&render.Kind,
+		&render.URL,
+		&render.ContentType,
+		&render.Size,
+		&render.Data,
+		&render.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &render, nil
+}
+
+// ListRenders returns render metadata, newest first, without the data.
+func (s *Storage) ListRenders(limit int) ([]Render, error) {
+	query := `
+		SELECT id, kind, url, content_type, size, created_at
+		FROM renders
+		ORDER BY created_at DESC
+		LIMIT ?
+	`
+	rows, err := s.db.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var renders []Render
+	for rows.Next() {
+		var render Render
+		if err := rows.Scan(
+			&render.ID,
+			&render.Kind,
+			&render.URL,
+			&render.ContentType,
+			&render.Size,
+			&render.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		renders = append(renders, render)
+	}
+
+	return renders, rows.Err()
+}
+
+func (s *Storage) DeleteRender(id string) error {
+	_, err := s.db.Exec("DELETE FROM renders WHERE id = ?", id)
+	return err
+}
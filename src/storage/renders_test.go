@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestStorage_Renders(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	render := &Render{
+		ID:          "render-1",
+		Kind:        "pdf",
+		URL:         "https://example.com",
+		ContentType: "application/pdf",
+		CreatedAt:   time.Now(),
+		Data:        []byte("%PDF-1.4 test"),
+	}
+	if err := store.SaveRender(render); err != nil {
+		t.Fatalf("Failed to save render: %v", err)
+	}
+
+	retrieved, err := store.GetRender("render-1")
+	if err != nil {
+		t.Fatalf("Failed to get render: %v", err)
+	}
+	if retrieved == nil {
+		t.Fatal("Expected render, got nil")
+	}
+	if !bytes.Equal(retrieved.Data, render.Data) {
+		t.Errorf("Expected data %q, got %q", render.Data, retrieved.Data)
+	}
+	if retrieved.Size != len(render.Data) {
+		t.Errorf("Expected size %d, got %d", len(render.Data), retrieved.Size)
+	}
+
+	renders, err := store.ListRenders(10)
+	if err != nil {
+		t.Fatalf("Failed to list renders: %v", err)
+	}
+	if len(renders) != 1 || renders[0].Data != nil {
+		t.Errorf("Expected one render without data, got %+v", renders)
+	}
+
+	if err := store.DeleteRender("render-1"); err != nil {
+		t.Fatalf("Failed to delete render: %v", err)
+	}
+	retrieved, err = store.GetRender("render-1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if retrieved != nil {
+		t.Error("Expected nil after delete")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	_ "modernc.org/sqlite"
@@ -11,6 +12,8 @@ import (
 
 type Storage struct {
 	db *sql.DB
+
+	registryMu sync.Mutex // serializes vehicle registry updates
 }
 
 type Session struct {
@@ -39,6 +42,21 @@ type VehicleCache struct {
 	ExpiresAt time.Time
 }
 
+// Branch is a portal branch as used by VehicleStateTableForBranchEx.
+// BranchCD and BranchName follow the dtakologs schema; BranchID is the
+// zero-padded form the bridge service expects as its branchID argument.
+type Branch struct {
+	BranchID   string `json:"branch_id"`
+	BranchCD   int    `json:"branch_cd"`
+	BranchName string `json:"branch_name"`
+}
+
+// Filter is a vehicle list filter accepted as the filterID argument.
+type Filter struct {
+	FilterID   string `json:"filter_id"`
+	FilterName string `json:"filter_name"`
+}
+
 func NewStorage(dbPath string) (*Storage, error) {
 	db, err := sql.Open("sqlite", dbPath)
 	if err != nil {
@@ -91,6 +109,185 @@ func (s *Storage) initialize() error {
 			cached_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			expires_at TIMESTAMP
 		)`,
+		`CREATE TABLE IF NOT EXISTS branch_cache (
+			branch_id TEXT PRIMARY KEY,
+			branch_cd INTEGER NOT NULL,
+			branch_name TEXT NOT NULL,
+			cached_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS filter_cache (
+			filter_id TEXT PRIMARY KEY,
+			filter_name TEXT NOT NULL,
+			cached_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS renders (
+			id TEXT PRIMARY KEY,
+			kind TEXT NOT NULL,
+			url TEXT NOT NULL,
+			content_type TEXT NOT NULL,
+			size INTEGER NOT NULL,
+			data BLOB NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS downloads (
+			id TEXT PRIMARY KEY,
+			job_id TEXT,
+			report_type TEXT NOT NULL,
+			date_from TEXT,
+			date_to TEXT,
+			account TEXT,
+			filename TEXT NOT NULL,
+			content_type TEXT NOT NULL,
+			size INTEGER NOT NULL,
+			data BLOB NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_downloads_report_type ON downloads (report_type, created_at)`,
+		`CREATE TABLE IF NOT EXISTS recipes (
+			name TEXT PRIMARY KEY,
+			definition TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS vehicle_registry (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			vehicle_cd INTEGER NOT NULL DEFAULT 0,
+			vehicle_name TEXT NOT NULL,
+			plate TEXT NOT NULL DEFAULT '',
+			branch_cd INTEGER NOT NULL DEFAULT 0,
+			merged_into INTEGER,
+			first_seen TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_seen TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (merged_into) REFERENCES vehicle_registry(id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_vehicle_registry_cd ON vehicle_registry (vehicle_cd)`,
+		`CREATE INDEX IF NOT EXISTS idx_vehicle_registry_name ON vehicle_registry (vehicle_name)`,
+		`CREATE INDEX IF NOT EXISTS idx_vehicle_registry_plate ON vehicle_registry (plate)`,
+		`CREATE TABLE IF NOT EXISTS vehicle_aliases (
+			vehicle_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			first_seen TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (vehicle_id, name),
+			FOREIGN KEY (vehicle_id) REFERENCES vehicle_registry(id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_vehicle_aliases_name ON vehicle_aliases (name)`,
+		`CREATE TABLE IF NOT EXISTS vehicle_collisions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			vehicle_id INTEGER NOT NULL,
+			other_vehicle_id INTEGER NOT NULL,
+			field TEXT NOT NULL,
+			value TEXT NOT NULL,
+			detected_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			resolved_at TIMESTAMP,
+			UNIQUE (vehicle_id, other_vehicle_id, field)
+		)`,
+		// Keyed by the parsed Unix second, not the DataDateTime string
+		`CREATE TABLE IF NOT EXISTS telemetry (
+			vehicle_cd INTEGER NOT NULL,
+			data_time INTEGER NOT NULL,
+			data_date_time TEXT NOT NULL,
+			vehicle_id INTEGER NOT NULL DEFAULT 0,
+			vehicle_name TEXT NOT NULL DEFAULT '',
+			branch_cd INTEGER NOT NULL DEFAULT 0,
+			driver_cd INTEGER NOT NULL DEFAULT 0,
+			speed REAL NOT NULL DEFAULT 0,
+			latitude REAL,
+			longitude REAL,
+			gps_valid BOOLEAN NOT NULL DEFAULT 0,
+			record TEXT NOT NULL,
+			fetched_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (vehicle_cd, data_time)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_telemetry_data_time ON telemetry (data_time)`,
+		`CREATE INDEX IF NOT EXISTS idx_telemetry_vehicle_id ON telemetry (vehicle_id, data_time)`,
+		`CREATE INDEX IF NOT EXISTS idx_telemetry_branch ON telemetry (branch_cd, data_time)`,
+		`CREATE TABLE IF NOT EXISTS vehicle_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			type TEXT NOT NULL,
+			vehicle_cd INTEGER NOT NULL,
+			vehicle_id INTEGER NOT NULL DEFAULT 0,
+			vehicle_name TEXT NOT NULL DEFAULT '',
+			branch_cd INTEGER NOT NULL DEFAULT 0,
+			time INTEGER NOT NULL,
+			from_value TEXT NOT NULL DEFAULT '',
+			to_value TEXT NOT NULL DEFAULT '',
+			latitude REAL,
+			longitude REAL,
+			detail TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (type, vehicle_cd, time, to_value)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_vehicle_events_vehicle ON vehicle_events (vehicle_cd, time)`,
+		`CREATE INDEX IF NOT EXISTS idx_vehicle_events_time ON vehicle_events (time)`,
+		`CREATE TABLE IF NOT EXISTS temperature_excursions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			vehicle_cd INTEGER NOT NULL,
+			vehicle_id INTEGER NOT NULL DEFAULT 0,
+			vehicle_name TEXT NOT NULL DEFAULT '',
+			compartment INTEGER NOT NULL,
+			set_point REAL NOT NULL,
+			tolerance REAL NOT NULL,
+			started_at INTEGER NOT NULL,
+			ended_at INTEGER,
+			min_temp REAL NOT NULL,
+			max_temp REAL NOT NULL,
+			last_temp REAL NOT NULL,
+			max_deviation REAL NOT NULL,
+			samples INTEGER NOT NULL DEFAULT 0,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_temperature_excursions_vehicle ON temperature_excursions (vehicle_cd, started_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_temperature_excursions_open ON temperature_excursions (ended_at)`,
+		`CREATE TABLE IF NOT EXISTS trips (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			vehicle_cd INTEGER NOT NULL,
+			vehicle_id INTEGER NOT NULL DEFAULT 0,
+			vehicle_name TEXT NOT NULL DEFAULT '',
+			kind TEXT NOT NULL,
+			start_time INTEGER NOT NULL,
+			end_time INTEGER NOT NULL,
+			closed BOOLEAN NOT NULL DEFAULT 0,
+			start_latitude REAL,
+			start_longitude REAL,
+			end_latitude REAL,
+			end_longitude REAL,
+			start_odometer REAL,
+			end_odometer REAL,
+			distance_km REAL NOT NULL DEFAULT 0,
+			distance_source TEXT NOT NULL DEFAULT '',
+			idle_seconds INTEGER NOT NULL DEFAULT 0,
+			max_speed REAL NOT NULL DEFAULT 0,
+			driver_cd INTEGER NOT NULL DEFAULT 0,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_trips_vehicle ON trips (vehicle_cd, start_time)`,
+		`CREATE INDEX IF NOT EXISTS idx_trips_start_time ON trips (start_time)`,
+		`CREATE TABLE IF NOT EXISTS geofences (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			category TEXT NOT NULL DEFAULT '',
+			shape TEXT NOT NULL,
+			latitude REAL NOT NULL DEFAULT 0,
+			longitude REAL NOT NULL DEFAULT 0,
+			radius REAL NOT NULL DEFAULT 0,
+			polygon TEXT,
+			dwell_seconds INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS vehicle_health (
+			vehicle_cd INTEGER PRIMARY KEY,
+			vehicle_id INTEGER NOT NULL DEFAULT 0,
+			vehicle_name TEXT NOT NULL DEFAULT '',
+			branch_cd INTEGER NOT NULL DEFAULT 0,
+			status TEXT NOT NULL,
+			since INTEGER NOT NULL,
+			last_contact INTEGER,
+			gps_valid BOOLEAN NOT NULL DEFAULT 0,
+			checked_at INTEGER NOT NULL
+		)`,
 		`CREATE TABLE IF NOT EXISTS kv_store (
 			key TEXT PRIMARY KEY,
 			value TEXT NOT NULL,
@@ -311,11 +508,127 @@ func (s *Storage) GetCachedVehicleData(vehicleCD string) (string, error) {
 	return data, err
 }
 
+// Branch and filter cache methods
+
+// CacheBranches replaces the cached branch list.
+func (s *Storage) CacheBranches(branches []Branch, ttl time.Duration) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM branch_cache"); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO branch_cache (branch_id, branch_cd, branch_name, cached_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, branch := range branches {
+		if _, err := stmt.Exec(branch.BranchID, branch.BranchCD, branch.BranchName, now, now.Add(ttl)); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetCachedBranches returns the cached branch list, or nil if it has expired.
+func (s *Storage) GetCachedBranches() ([]Branch, error) {
+	query := `
+		SELECT branch_id, branch_cd, branch_name FROM branch_cache
+		WHERE expires_at > ?
+		ORDER BY branch_cd
+	`
+	rows, err := s.db.Query(query, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var branches []Branch
+	for rows.Next() {
+		var branch Branch
+		if err := rows.Scan(&branch.BranchID, &branch.BranchCD, &branch.BranchName); err != nil {
+			return nil, err
+		}
+		branches = append(branches, branch)
+	}
+
+	return branches, rows.Err()
+}
+
+// CacheFilters replaces the cached filter list.
+func (s *Storage) CacheFilters(filters []Filter, ttl time.Duration) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM filter_cache"); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO filter_cache (filter_id, filter_name, cached_at, expires_at)
+		VALUES (?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, filter := range filters {
+		if _, err := stmt.Exec(filter.FilterID, filter.FilterName, now, now.Add(ttl)); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetCachedFilters returns the cached filter list, or nil if it has expired.
+func (s *Storage) GetCachedFilters() ([]Filter, error) {
+	query := `
+		SELECT filter_id, filter_name FROM filter_cache
+		WHERE expires_at > ?
+		ORDER BY filter_id
+	`
+	rows, err := s.db.Query(query, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var filters []Filter
+	for rows.Next() {
+		var filter Filter
+		if err := rows.Scan(&filter.FilterID, &filter.FilterName); err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+
+	return filters, rows.Err()
+}
+
 // Cleanup expired data
 func (s *Storage) CleanupExpired() error {
 	queries := []string{
 		"DELETE FROM sessions WHERE expires_at < ?",
 		"DELETE FROM vehicle_cache WHERE expires_at < ?",
+		"DELETE FROM branch_cache WHERE expires_at < ?",
+		"DELETE FROM filter_cache WHERE expires_at < ?",
 		"DELETE FROM cookies WHERE expires_at < ?",
 	}
 
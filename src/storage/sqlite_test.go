@@ -358,4 +358,57 @@ func TestStorage_Close(t *testing.T) {
 	if err == nil {
 		t.Error("Expected error when using closed database")
 	}
-}
\ No newline at end of file
+}
+func TestStorage_BranchFilterCache(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	branches := []Branch{
+		{BranchID: "00000002", BranchCD: 2, BranchName: "Branch B"},
+		{BranchID: "00000001", BranchCD: 1, BranchName: "Branch A"},
+	}
+	if err := store.CacheBranches(branches, 10*time.Minute); err != nil {
+		t.Fatalf("Failed to cache branches: %v", err)
+	}
+
+	cached, err := store.GetCachedBranches()
+	if err != nil {
+		t.Fatalf("Failed to get cached branches: %v", err)
+	}
+	if len(cached) != 2 {
+		t.Fatalf("Expected 2 branches, got %d", len(cached))
+	}
+	if cached[0].BranchCD != 1 || cached[0].BranchName != "Branch A" {
+		t.Errorf("Expected branches ordered by BranchCD, got %+v", cached[0])
+	}
+
+	// Replacing the cache with an expired list hides it
+	if err := store.CacheBranches(branches[:1], -1*time.Minute); err != nil {
+		t.Fatalf("Failed to cache branches: %v", err)
+	}
+	cached, err = store.GetCachedBranches()
+	if err != nil {
+		t.Fatalf("Failed to get cached branches: %v", err)
+	}
+	if len(cached) != 0 {
+		t.Errorf("Expected expired branches to be hidden, got %d", len(cached))
+	}
+
+	filters := []Filter{
+		{FilterID: "0", FilterName: "Active"},
+		{FilterID: "", FilterName: "All"},
+	}
+	if err := store.CacheFilters(filters, 10*time.Minute); err != nil {
+		t.Fatalf("Failed to cache filters: %v", err)
+	}
+	cachedFilters, err := store.GetCachedFilters()
+	if err != nil {
+		t.Fatalf("Failed to get cached filters: %v", err)
+	}
+	if len(cachedFilters) != 2 {
+		t.Fatalf("Expected 2 filters, got %d", len(cachedFilters))
+	}
+	if cachedFilters[0].FilterID != "" {
+		t.Errorf("Expected filters ordered by FilterID, got %+v", cachedFilters[0])
+	}
+}
@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// TelemetryRecord is one stored vehicle state, keyed by (VehicleCD,
+// DataTime): DataDateTime parsed as Asia/Tokyo to the second. Unlike
+// dtakologs, two portal strings for the same second (e.g. "25/01/02 9:05"
+// and "2025/01/02 09:05:00") are the same row. Record holds the raw bridge
+// record as JSON.
+type TelemetryRecord struct {
+	VehicleCD    int64     `json:"VehicleCD"`
+	DataTime     time.Time `json:"DataTime"`
+	DataDateTime string    `json:"DataDateTime"` // as sent by the portal
+	VehicleID    int64     `json:"VehicleID,omitempty"`
+	VehicleName  string    `json:"VehicleName"`
+	BranchCD     int       `json:"BranchCD"`
+	DriverCD     int       `json:"DriverCD"`
+	Speed        float64   `json:"Speed"`
+	Latitude     *float64  `json:"latitude,omitempty"`
+	Longitude    *float64  `json:"longitude,omitempty"`
+	GPSValid     bool      `json:"gps_valid"`
+	Record       string    `json:"-"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// SaveTelemetry upserts the records in one transaction. Saving the same
+// (VehicleCD, DataTime) again replaces the stored row, so repeated scrapes
+// are idempotent.
+func (s *Storage) SaveTelemetry(records []TelemetryRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO telemetry (
+			vehicle_cd, data_time, data_date_time, vehicle_id, vehicle_name, branch_cd,
+			driver_cd, speed, latitude, longitude, gps_valid, record, fetched_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(vehicle_cd, data_time) DO UPDATE SET
+			data_date_time = excluded.data_date_time,
+			vehicle_id = excluded.vehicle_id,
+			vehicle_name = excluded.vehicle_name,
+			branch_cd = excluded.branch_cd,
+			driver_cd = excluded.driver_cd,
+			speed = excluded.speed,
+			latitude = excluded.latitude,
+			longitude = excluded.longitude,
+			gps_valid = excluded.gps_valid,
+			record = excluded.record,
+			fetched_at = excluded.fetched_at
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range records {
+		if _, err := stmt.Exec(
+			r.VehicleCD, r.DataTime.Unix(), r.DataDateTime, r.VehicleID, r.VehicleName, r.BranchCD,
+			r.DriverCD, r.Speed, r.Latitude, r.Longitude, r.GPSValid, r.Record, r.FetchedAt,
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListTelemetry returns a vehicle's records with from <= DataTime < to,
+// oldest first. A zero from or to leaves that end open; limit <= 0 means no
+// limit.
+func (s *Storage) ListTelemetry(vehicleCD int64, from, to time.Time, limit int) ([]TelemetryRecord, error) {
+	query := telemetrySelect + ` WHERE vehicle_cd = ?`
+	args := []interface{}{vehicleCD}
+	if !from.IsZero() {
+		query += ` AND data_time >= ?`
+		args = append(args, from.Unix())
+	}
+	if !to.IsZero() {
+		query += ` AND data_time < ?`
+		args = append(args, to.Unix())
+	}
+	query += ` ORDER BY data_time`
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTelemetry(rows)
+}
+
+// ListTelemetryBetween returns every vehicle's records with from <= DataTime <
+// to, ordered by time. A non-zero driverCD keeps only that driver's records.
+func (s *Storage) ListTelemetryBetween(from, to time.Time, driverCD int) ([]TelemetryRecord, error) {
+	query := telemetrySelect + ` WHERE data_time >= ? AND data_time < ?`
+	args := []interface{}{from.Unix(), to.Unix()}
+	if driverCD != 0 {
+		query += ` AND driver_cd = ?`
+		args = append(args, driverCD)
+	}
+	query += ` ORDER BY data_time, vehicle_cd`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTelemetry(rows)
+}
+
+// ListFleetAt returns the latest record of every vehicle with DataTime <= at,
+// ordered by VehicleCD. Vehicles last seen before since (when set) are left
+// out. Only vehicles with VehicleCD > afterCD are returned, so a caller can
+// page by passing the last VehicleCD it received; limit <= 0 means no limit.
+func (s *Storage) ListFleetAt(at, since time.Time, afterCD int64, limit int) ([]TelemetryRecord, error) {
+	query := telemetrySelect + ` t
+		WHERE t.data_time = (
+			SELECT MAX(data_time) FROM telemetry
+			WHERE vehicle_cd = t.vehicle_cd AND data_time <= ?
+		) AND t.vehicle_cd > ?`
+	args := []interface{}{at.Unix(), afterCD}
+	if !since.IsZero() {
+		query += ` AND t.data_time >= ?`
+		args = append(args, since.Unix())
+	}
+	query += ` ORDER BY t.vehicle_cd`
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTelemetry(rows)
+}
+
+// DeleteTelemetryBefore removes records older than t and returns how many
+// were deleted.
+func (s *Storage) DeleteTelemetryBefore(t time.Time) (int64, error) {
+	result, err := s.db.Exec("DELETE FROM telemetry WHERE data_time < ?", t.Unix())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const telemetrySelect = `
+	SELECT vehicle_cd, data_time, data_date_time, vehicle_id, vehicle_name, branch_cd,
+		driver_cd, speed, latitude, longitude, gps_valid, record, fetched_at
+	FROM telemetry`
+
+func scanTelemetry(rows *sql.Rows) ([]TelemetryRecord, error) {
+	var records []TelemetryRecord
+	for rows.Next() {
+		var r TelemetryRecord
+		var dataTime int64
+		var lat, lon sql.NullFloat64
+		if err := rows.Scan(
+			&r.VehicleCD, &dataTime, &r.DataDateTime, &r.VehicleID, &r.VehicleName, &r.BranchCD,
+			&r.DriverCD, &r.Speed, &lat, &lon, &r.GPSValid, &r.Record, &r.FetchedAt,
+		); err != nil {
+			return nil, err
+		}
+		r.DataTime = time.Unix(dataTime, 0)
+		if lat.Valid && lon.Valid {
+			r.Latitude = &lat.Float64
+			r.Longitude = &lon.Float64
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStorage_SaveTelemetry(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	base := time.Date(2025, 1, 2, 3, 0, 0, 0, time.UTC)
+	lat, lon := 35.681236, 139.767125
+	records := []TelemetryRecord{
+		{VehicleCD: 1, DataTime: base, DataDateTime: "2025/01/02 12:00:00", VehicleName: "Truck", Speed: 10, Latitude: &lat, Longitude: &lon, GPSValid: true, Record: `{}`, FetchedAt: base},
+		{VehicleCD: 1, DataTime: base.Add(time.Minute), DataDateTime: "2025/01/02 12:01:00", VehicleName: "Truck", Speed: 20, Record: `{}`, FetchedAt: base},
+		{VehicleCD: 2, DataTime: base, DataDateTime: "2025/01/02 12:00:00", VehicleName: "Van", Record: `{}`, FetchedAt: base},
+	}
+	if err := store.SaveTelemetry(records); err != nil {
+		t.Fatalf("Failed to save telemetry: %v", err)
+	}
+
+	// Saving the same key again replaces the row instead of duplicating it
+	records[1].Speed = 25
+	if err := store.SaveTelemetry(records[1:2]); err != nil {
+		t.Fatalf("Failed to save telemetry again: %v", err)
+	}
+
+	got, err := store.ListTelemetry(1, time.Time{}, time.Time{}, 0)
+	if err != nil {
+		t.Fatalf("Failed to list telemetry: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(got))
+	}
+	if !got[0].DataTime.Equal(base) || got[1].Speed != 25 {
+		t.Errorf("Unexpected records: %+v", got)
+	}
+	if got[0].Latitude == nil || *got[0].Latitude != lat || !got[0].GPSValid {
+		t.Errorf("Expected position to round-trip, got %+v", got[0])
+	}
+	if got[1].Latitude != nil {
+		t.Errorf("Expected no position, got %v", *got[1].Latitude)
+	}
+}
+
+func TestStorage_ListTelemetryRange(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	base := time.Date(2025, 1, 2, 3, 0, 0, 0, time.UTC)
+	var records []TelemetryRecord
+	for i := 0; i < 5; i++ {
+		records = append(records, TelemetryRecord{VehicleCD: 1, DataTime: base.Add(time.Duration(i) * time.Minute), FetchedAt: base})
+	}
+	if err := store.SaveTelemetry(records); err != nil {
+		t.Fatalf("Failed to save telemetry: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		limit    int
+		count    int
+	}{
+		{"open range", time.Time{}, time.Time{}, 0, 5},
+		{"from", base.Add(2 * time.Minute), time.Time{}, 0, 3},
+		{"to is exclusive", time.Time{}, base.Add(2 * time.Minute), 0, 2},
+		{"from and to", base.Add(time.Minute), base.Add(3 * time.Minute), 0, 2},
+		{"limit", time.Time{}, time.Time{}, 3, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.ListTelemetry(1, tt.from, tt.to, tt.limit)
+			if err != nil {
+				t.Fatalf("Failed to list telemetry: %v", err)
+			}
+			if len(got) != tt.count {
+				t.Errorf("Expected %d records, got %d", tt.count, len(got))
+			}
+		})
+	}
+
+	deleted, err := store.DeleteTelemetryBefore(base.Add(2 * time.Minute))
+	if err != nil || deleted != 2 {
+		t.Errorf("Expected 2 deleted records, got %d (%v)", deleted, err)
+	}
+}
+
+func TestStorage_ListTelemetryBetween(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	base := time.Date(2025, 1, 2, 3, 0, 0, 0, time.UTC)
+	records := []TelemetryRecord{
+		{VehicleCD: 2, DataTime: base, DriverCD: 10, FetchedAt: base},
+		{VehicleCD: 1, DataTime: base, DriverCD: 11, FetchedAt: base},
+		{VehicleCD: 1, DataTime: base.Add(time.Minute), DriverCD: 11, FetchedAt: base},
+		{VehicleCD: 2, DataTime: base.Add(time.Hour), DriverCD: 10, FetchedAt: base},
+	}
+	if err := store.SaveTelemetry(records); err != nil {
+		t.Fatalf("Failed to save telemetry: %v", err)
+	}
+
+	got, err := store.ListTelemetryBetween(base, base.Add(time.Hour), 0)
+	if err != nil {
+		t.Fatalf("Failed to list telemetry: %v", err)
+	}
+	if len(got) != 3 || got[0].VehicleCD != 1 || got[1].VehicleCD != 2 || !got[2].DataTime.Equal(base.Add(time.Minute)) {
+		t.Errorf("Expected 3 records ordered by time and vehicle, got %+v", got)
+	}
+
+	got, err = store.ListTelemetryBetween(base, base.Add(2*time.Hour), 10)
+	if err != nil || len(got) != 2 {
+		t.Errorf("Expected 2 records of driver 10, got %d (%v)", len(got), err)
+	}
+}
+
+func TestStorage_ListFleetAt(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	base := time.Date(2025, 1, 2, 3, 0, 0, 0, time.UTC)
+	records := []TelemetryRecord{
+		{VehicleCD: 1, DataTime: base, Speed: 10},
+		{VehicleCD: 1, DataTime: base.Add(10 * time.Minute), Speed: 20},
+		{VehicleCD: 1, DataTime: base.Add(20 * time.Minute), Speed: 30},
+		{VehicleCD: 2, DataTime: base.Add(5 * time.Minute), Speed: 40},
+		{VehicleCD: 3, DataTime: base.Add(30 * time.Minute), Speed: 50},
+	}
+	if err := store.SaveTelemetry(records); err != nil {
+		t.Fatalf("Failed to save telemetry: %v", err)
+	}
+
+	got, err := store.ListFleetAt(base.Add(15*time.Minute), time.Time{}, 0, 0)
+	if err != nil {
+		t.Fatalf("Failed to list fleet: %v", err)
+	}
+	if len(got) != 2 || got[0].VehicleCD != 1 || got[0].Speed != 20 || got[1].VehicleCD != 2 {
+		t.Errorf("Unexpected fleet state: %+v", got)
+	}
+
+	// Vehicles last seen before since are dropped
+	got, _ = store.ListFleetAt(base.Add(15*time.Minute), base.Add(8*time.Minute), 0, 0)
+	if len(got) != 1 || got[0].VehicleCD != 1 {
+		t.Errorf("Expected only vehicle 1, got %+v", got)
+	}
+
+	// Paging by VehicleCD
+	got, _ = store.ListFleetAt(base.Add(time.Hour), time.Time{}, 1, 1)
+	if len(got) != 1 || got[0].VehicleCD != 2 {
+		t.Errorf("Expected vehicle 2 on the second page, got %+v", got)
+	}
+}
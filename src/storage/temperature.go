@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// TemperatureExcursion is a period in which a compartment stayed outside its
+// set point plus or minus the tolerance. EndedAt is nil while it is open.
+type TemperatureExcursion struct {
+	ID           int64      `json:"id"`
+	VehicleCD    int64      `json:"vehicle_cd"`
+	VehicleID    int64      `json:"vehicle_id,omitempty"`
+	VehicleName  string     `json:"vehicle_name"`
+	Compartment  int        `json:"compartment"` // 1-4, matching Temp1-Temp4
+	SetPoint     float64    `json:"set_point"`
+	Tolerance    float64    `json:"tolerance"`
+	StartedAt    time.Time  `json:"started_at"`
+	EndedAt      *time.Time `json:"ended_at,omitempty"`
+	MinTemp      float64    `json:"min_temp"`
+	MaxTemp      float64    `json:"max_temp"`
+	LastTemp     float64    `json:"last_temp"`
+	MaxDeviation float64    `json:"max_deviation"` // largest |temp - set point|
+	Samples      int        `json:"samples"`
+}
+
+// ExcursionFilter selects excursions. Zero values leave a condition out.
+type ExcursionFilter struct {
+	VehicleCD int64
+	OpenOnly  bool
+	From      time.Time // excursions still open at or ending after From
+	To        time.Time // excursions starting before To
+	Limit     int
+}
+
+// SaveExcursion inserts the excursion when its ID is 0 and updates it
+// otherwise.
+func (s *Storage) SaveExcursion(e *TemperatureExcursion) error {
+	var endedAt interface{}
+	if e.EndedAt != nil {
+		endedAt = e.EndedAt.Unix()
+	}
+
+	if e.ID == 0 {
+		result, err := s.db.Exec(`
+			INSERT INTO temperature_excursions (
+				vehicle_cd, vehicle_id, vehicle_name, compartment, set_point, tolerance,
+				started_at, ended_at, min_temp, max_temp, last_temp, max_deviation, samples
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, e.VehicleCD, e.VehicleID, e.VehicleName, e.Compartment, e.SetPoint, e.Tolerance,
+			e.StartedAt.Unix(), endedAt, e.MinTemp, e.MaxTemp, e.LastTemp, e.MaxDeviation, e.Samples)
+		if err != nil {
+			return err
+		}
+		e.ID, err = result.LastInsertId()
+		return err
+	}
+
+	_, err := s.db.Exec(`
+		UPDATE temperature_excursions
+		SET vehicle_name = ?, ended_at = ?, min_temp = ?, max_temp = ?, last_temp = ?,
+			max_deviation = ?, samples = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, e.VehicleName, endedAt, e.MinTemp, e.MaxTemp, e.LastTemp, e.MaxDeviation, e.Samples, e.ID)
+	return err
+}
+
+// ListExcursions returns the matching excursions, newest first.
+func (s *Storage) ListExcursions(f ExcursionFilter) ([]TemperatureExcursion, error) {
+	query := `
+		SELECT id, vehicle_cd, vehicle_id, vehicle_name, compartment, set_point, tolerance,
+			started_at, ended_at, min_temp, max_temp, last_temp, max_deviation, samples
+		FROM temperature_excursions
+		WHERE 1 = 1`
+	var args []interface{}
+	if f.VehicleCD != 0 {
+		query += ` AND vehicle_cd = ?`
+		args = append(args, f.VehicleCD)
+	}
+	if f.OpenOnly {
+		query += ` AND ended_at IS NULL`
+	}
+	if !f.From.IsZero() {
+		query += ` AND (ended_at IS NULL OR ended_at >= ?)`
+		args = append(args, f.From.Unix())
+	}
+	if !f.To.IsZero() {
+		query += ` AND started_at < ?`
+		args = append(args, f.To.Unix())
+	}
+	query += ` ORDER BY started_at DESC, id DESC`
+	if f.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, f.Limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var excursions []TemperatureExcursion
+	for rows.Next() {
+		var e TemperatureExcursion
+		var startedAt int64
+		var endedAt sql.NullInt64
+		if err := rows.Scan(
+			&e.ID, &e.VehicleCD, &e.VehicleID, &e.VehicleName, &e.Compartment, &e.SetPoint, &e.Tolerance,
+			&startedAt, &endedAt, &e.MinTemp, &e.MaxTemp, &e.LastTemp, &e.MaxDeviation, &e.Samples,
+		); err != nil {
+			return nil, err
+		}
+		e.StartedAt = time.Unix(startedAt, 0)
+		if endedAt.Valid {
+			t := time.Unix(endedAt.Int64, 0)
+			e.EndedAt = &t
+		}
+		excursions = append(excursions, e)
+	}
+	return excursions, rows.Err()
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStorage_Excursions(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	base := time.Date(2025, 1, 2, 3, 0, 0, 0, time.UTC)
+	closed := &TemperatureExcursion{VehicleCD: 1, Compartment: 1, SetPoint: -20, Tolerance: 3, StartedAt: base, MinTemp: -15, MaxTemp: -12, LastTemp: -12, MaxDeviation: 8, Samples: 2}
+	open := &TemperatureExcursion{VehicleCD: 2, Compartment: 2, SetPoint: 5, Tolerance: 3, StartedAt: base.Add(2 * time.Hour), MinTemp: 9, MaxTemp: 9, LastTemp: 9, MaxDeviation: 4, Samples: 1}
+	for _, e := range []*TemperatureExcursion{closed, open} {
+		if err := store.SaveExcursion(e); err != nil {
+			t.Fatalf("Failed to save excursion: %v", err)
+		}
+	}
+
+	ended := base.Add(time.Hour)
+	closed.EndedAt = &ended
+	closed.Samples = 3
+	if err := store.SaveExcursion(closed); err != nil {
+		t.Fatalf("Failed to update excursion: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter ExcursionFilter
+		count  int
+	}{
+		{"all", ExcursionFilter{}, 2},
+		{"open only", ExcursionFilter{OpenOnly: true}, 1},
+		{"vehicle", ExcursionFilter{VehicleCD: 1}, 1},
+		{"overlapping from", ExcursionFilter{From: base.Add(90 * time.Minute)}, 1},
+		{"overlapping to", ExcursionFilter{To: base.Add(90 * time.Minute)}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.ListExcursions(tt.filter)
+			if err != nil {
+				t.Fatalf("Failed to list excursions: %v", err)
+			}
+			if len(got) != tt.count {
+				t.Errorf("Expected %d excursions, got %d", tt.count, len(got))
+			}
+		})
+	}
+
+	got, _ := store.ListExcursions(ExcursionFilter{VehicleCD: 1})
+	if got[0].EndedAt == nil || !got[0].EndedAt.Equal(ended) || got[0].Samples != 3 {
+		t.Errorf("Expected the update to be stored, got %+v", got[0])
+	}
+}
@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Trip segment kinds.
+const (
+	SegmentTrip = "trip"
+	SegmentStop = "stop"
+)
+
+// Trip is one segment of a vehicle's timeline: a trip while it moves or a
+// stop while it stands still. Open segments (Closed false) are extended by
+// every new sample; EndTime is then the latest sample's time.
+type Trip struct {
+	ID             int64     `json:"id"`
+	VehicleCD      int64     `json:"vehicle_cd"`
+	VehicleID      int64     `json:"vehicle_id,omitempty"`
+	VehicleName    string    `json:"vehicle_name"`
+	Kind           string    `json:"kind"`
+	StartTime      time.Time `json:"start_time"`
+	EndTime        time.Time `json:"end_time"`
+	Closed         bool      `json:"closed"`
+	StartLatitude  *float64  `json:"start_latitude,omitempty"`
+	StartLongitude *float64  `json:"start_longitude,omitempty"`
+	EndLatitude    *float64  `json:"end_latitude,omitempty"`
+	EndLongitude   *float64  `json:"end_longitude,omitempty"`
+	StartOdometer  *float64  `json:"start_odometer,omitempty"`
+	EndOdometer    *float64  `json:"end_odometer,omitempty"`
+	DistanceKm     float64   `json:"distance_km"`
+	DistanceSource string    `json:"distance_source,omitempty"` // "odometer" or "gps"
+	IdleSeconds    int64     `json:"idle_seconds"`
+	MaxSpeed       float64   `json:"max_speed"`
+	DriverCD       int       `json:"driver_cd"`
+}
+
+// Duration is the time the segment covers so far.
+func (t *Trip) Duration() time.Duration {
+	return t.EndTime.Sub(t.StartTime)
+}
+
+// TripFilter selects trip segments. Zero values leave a condition out.
+type TripFilter struct {
+	VehicleCD int64
+	Kind      string
+	From      time.Time // segments starting at or after From
+	To        time.Time // segments starting before To
+	OpenOnly  bool
+}
+
+// SaveTrip inserts the segment when its ID is 0 and updates it otherwise.
+func (s *Storage) SaveTrip(t *Trip) error {
+	if t.ID == 0 {
+		result, err := s.db.Exec(`
+			INSERT INTO trips (
+				vehicle_cd, vehicle_id, vehicle_name, kind, start_time, end_time, closed,
+				start_latitude, start_longitude, end_latitude, end_longitude,
+				start_odometer, end_odometer, distance_km, distance_source,
+				idle_seconds, max_speed, driver_cd
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, t.VehicleCD, t.VehicleID, t.VehicleName, t.Kind, t.StartTime.Unix(), t.EndTime.Unix(), t.Closed,
+			t.StartLatitude, t.StartLongitude, t.EndLatitude, t.EndLongitude,
+			t.StartOdometer, t.EndOdometer, t.DistanceKm, t.DistanceSource,
+			t.IdleSeconds, t.MaxSpeed, t.DriverCD)
+		if err != nil {
+			return err
+		}
+		t.ID, err = result.LastInsertId()
+		return err
+	}
+
+	_, err := s.db.Exec(`
+		UPDATE trips
+		SET vehicle_name = ?, end_time = ?, closed = ?, end_latitude = ?, end_longitude = ?,
+			end_odometer = ?, distance_km = ?, distance_source = ?, idle_seconds = ?,
+			max_speed = ?, driver_cd = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, t.VehicleName, t.EndTime.Unix(), t.Closed, t.EndLatitude, t.EndLongitude,
+		t.EndOdometer, t.DistanceKm, t.DistanceSource, t.IdleSeconds,
+		t.MaxSpeed, t.DriverCD, t.ID)
+	return err
+}
+
+// ListTrips returns the matching segments ordered by vehicle and start time.
+func (s *Storage) ListTrips(f TripFilter) ([]Trip, error) {
+	query := `
+		SELECT id, vehicle_cd, vehicle_id, vehicle_name, kind, start_time, end_time, closed,
+			start_latitude, start_longitude, end_latitude, end_longitude,
+			start_odometer, end_odometer, distance_km, distance_source,
+			idle_seconds, max_speed, driver_cd
+		FROM trips
+		WHERE 1 = 1`
+	var args []interface{}
+	if f.VehicleCD != 0 {
+		query += ` AND vehicle_cd = ?`
+		args = append(args, f.VehicleCD)
+	}
+	if f.Kind != "" {
+		query += ` AND kind = ?`
+		args = append(args, f.Kind)
+	}
+	if !f.From.IsZero() {
+		query += ` AND start_time >= ?`
+		args = append(args, f.From.Unix())
+	}
+	if !f.To.IsZero() {
+		query += ` AND start_time < ?`
+		args = append(args, f.To.Unix())
+	}
+	if f.OpenOnly {
+		query += ` AND closed = 0`
+	}
+	query += ` ORDER BY vehicle_cd, start_time, id`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var trips []Trip
+	for rows.Next() {
+		var t Trip
+		var start, end int64
+		var startLat, startLon, endLat, endLon, startOdo, endOdo sql.NullFloat64
+		if err := rows.Scan(
+			&t.ID, &t.VehicleCD, &t.VehicleID, &t.VehicleName, &t.Kind, &start, &end, &t.Closed,
+			&startLat, &startLon, &endLat, &endLon,
+			&startOdo, &endOdo, &t.DistanceKm, &t.DistanceSource,
+			&t.IdleSeconds, &t.MaxSpeed, &t.DriverCD,
+		); err != nil {
+			return nil, err
+		}
+		t.StartTime = time.Unix(start, 0)
+		t.EndTime = time.Unix(end, 0)
+		t.StartLatitude, t.StartLongitude = nullFloat(startLat), nullFloat(startLon)
+		t.EndLatitude, t.EndLongitude = nullFloat(endLat), nullFloat(endLon)
+		t.StartOdometer, t.EndOdometer = nullFloat(startOdo), nullFloat(endOdo)
+		trips = append(trips, t)
+	}
+	return trips, rows.Err()
+}
+
+func nullFloat(v sql.NullFloat64) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Float64
+}
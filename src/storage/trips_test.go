@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStorage_Trips(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	base := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	lat, lon := 35.0, 139.0
+	trip := &Trip{VehicleCD: 1, VehicleName: "Truck", Kind: SegmentTrip, StartTime: base, EndTime: base, StartLatitude: &lat, StartLongitude: &lon}
+	if err := store.SaveTrip(trip); err != nil {
+		t.Fatalf("Failed to save trip: %v", err)
+	}
+	if trip.ID == 0 {
+		t.Fatal("Expected an ID after insert")
+	}
+
+	// Extend and close it
+	endLat := 35.1
+	trip.EndTime = base.Add(30 * time.Minute)
+	trip.EndLatitude, trip.EndLongitude = &endLat, &lon
+	trip.DistanceKm, trip.DistanceSource, trip.IdleSeconds, trip.Closed = 11.1, "gps", 120, true
+	if err := store.SaveTrip(trip); err != nil {
+		t.Fatalf("Failed to update trip: %v", err)
+	}
+
+	others := []*Trip{
+		{VehicleCD: 1, Kind: SegmentStop, StartTime: base.Add(30 * time.Minute), EndTime: base.Add(time.Hour)},
+		{VehicleCD: 2, Kind: SegmentTrip, StartTime: base.Add(25 * time.Hour), EndTime: base.Add(26 * time.Hour)},
+	}
+	for _, o := range others {
+		if err := store.SaveTrip(o); err != nil {
+			t.Fatalf("Failed to save trip: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter TripFilter
+		count  int
+	}{
+		{"all", TripFilter{}, 3},
+		{"vehicle", TripFilter{VehicleCD: 1}, 2},
+		{"kind", TripFilter{Kind: SegmentTrip}, 2},
+		{"day", TripFilter{From: base, To: base.Add(24 * time.Hour)}, 2},
+		{"open", TripFilter{OpenOnly: true}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trips, err := store.ListTrips(tt.filter)
+			if err != nil {
+				t.Fatalf("Failed to list trips: %v", err)
+			}
+			if len(trips) != tt.count {
+				t.Errorf("Expected %d trips, got %d", tt.count, len(trips))
+			}
+		})
+	}
+
+	trips, err := store.ListTrips(TripFilter{VehicleCD: 1, Kind: SegmentTrip})
+	if err != nil || len(trips) != 1 {
+		t.Fatalf("Expected 1 trip, got %d (%v)", len(trips), err)
+	}
+	got := trips[0]
+	if !got.Closed || got.DistanceKm != 11.1 || got.IdleSeconds != 120 || got.Duration() != 30*time.Minute {
+		t.Errorf("Unexpected trip: %+v", got)
+	}
+	if got.EndLatitude == nil || *got.EndLatitude != 35.1 || got.StartOdometer != nil {
+		t.Errorf("Unexpected trip positions: %+v", got)
+	}
+}
@@ -0,0 +1,413 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// RegisteredVehicle is an entry in the vehicle registry. ID is the stable
+// internal identifier; it survives renames and is kept when entries are
+// merged. MergedInto is set on entries that were merged into another one.
+type RegisteredVehicle struct {
+	ID          int64     `json:"id"`
+	VehicleCD   int64     `json:"VehicleCD"`
+	VehicleName string    `json:"VehicleName"`
+	Plate       string    `json:"plate,omitempty"`
+	BranchCD    int       `json:"BranchCD"`
+	Aliases     []string  `json:"aliases,omitempty"` // previous names
+	MergedInto  *int64    `json:"merged_into,omitempty"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+// VehicleObservation is what the portal reported for one vehicle.
+// VehicleCD is 0 when the portal did not send a code.
+type VehicleObservation struct {
+	VehicleCD   int64
+	VehicleName string
+	Plate       string
+	BranchCD    int
+}
+
+// VehicleCollision records two registry entries that share a name or plate
+// but have different portal codes.
+type VehicleCollision struct {
+	ID             int64      `json:"id"`
+	VehicleID      int64      `json:"vehicle_id"`
+	OtherVehicleID int64      `json:"other_vehicle_id"`
+	Field          string     `json:"field"` // "name" or "plate"
+	Value          string     `json:"value"`
+	DetectedAt     time.Time  `json:"detected_at"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+}
+
+// RegisterVehicles resolves each observation to a stable registry ID,
+// creating entries for new vehicles. Vehicles are matched by the portal's
+// VehicleCD first and by current or previous name when there is no code, so
+// renaming a vehicle keeps its ID. A name or plate shared by vehicles with
+// different codes is recorded as a collision instead of being merged.
+func (s *Storage) RegisterVehicles(observations []VehicleObservation) ([]int64, error) {
+	s.registryMu.Lock()
+	defer s.registryMu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	ids := make([]int64, len(observations))
+	for i, o := range observations {
+		id, err := registerVehicle(tx, o, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to register vehicle %d (%s): %w", o.VehicleCD, o.VehicleName, err)
+		}
+		ids[i] = id
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func registerVehicle(tx *sql.Tx, o VehicleObservation, now time.Time) (int64, error) {
+	if o.VehicleCD != 0 {
+		id, err := findVehicle(tx, "SELECT id FROM vehicle_registry WHERE vehicle_cd = ? ORDER BY merged_into IS NOT NULL, id LIMIT 1", o.VehicleCD)
+		if err != nil {
+			return 0, err
+		}
+		if id != 0 {
+			return id, touchVehicle(tx, id, o, now)
+		}
+	}
+
+	if o.VehicleName != "" {
+		id, err := findVehicle(tx, `
+			SELECT id FROM vehicle_registry WHERE vehicle_name = ?
+			UNION ALL
+			SELECT vehicle_id FROM vehicle_aliases WHERE name = ?
+			LIMIT 1
+		`, o.VehicleName, o.VehicleName)
+		if err != nil {
+			return 0, err
+		}
+		if id != 0 {
+			var existingCD int64
+			if err := tx.QueryRow("SELECT vehicle_cd FROM vehicle_registry WHERE id = ?", id).Scan(&existingCD); err != nil {
+				return 0, err
+			}
+			if o.VehicleCD == 0 || existingCD == 0 || existingCD == o.VehicleCD {
+				return id, touchVehicle(tx, id, o, now)
+			}
+
+			// Different portal codes share the name
+			newID, err := insertVehicle(tx, o, now)
+			if err != nil {
+				return 0, err
+			}
+			return newID, recordCollision(tx, newID, id, "name", o.VehicleName, now)
+		}
+	}
+
+	id, err := insertVehicle(tx, o, now)
+	if err != nil {
+		return 0, err
+	}
+	return id, checkPlate(tx, id, o.Plate, now)
+}
+
+// findVehicle runs a single-id query and follows merges to the surviving
+// entry. It returns 0 when nothing matches.
+func findVehicle(tx *sql.Tx, query string, args ...interface{}) (int64, error) {
+	var id int64
+	err := tx.QueryRow(query, args...).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	for i := 0; i < 16; i++ {
+		var mergedInto sql.NullInt64
+		if err := tx.QueryRow("SELECT merged_into FROM vehicle_registry WHERE id = ?", id).Scan(&mergedInto); err != nil {
+			return 0, err
+		}
+		if !mergedInto.Valid {
+			return id, nil
+		}
+		id = mergedInto.Int64
+	}
+	return 0, fmt.Errorf("merge chain too long at vehicle %d", id)
+}
+
+func insertVehicle(tx *sql.Tx, o VehicleObservation, now time.Time) (int64, error) {
+	result, err := tx.Exec(`
+		INSERT INTO vehicle_registry (vehicle_cd, vehicle_name, plate, branch_cd, first_seen, last_seen)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, o.VehicleCD, o.VehicleName, o.Plate, o.BranchCD, now, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// touchVehicle updates an entry with the latest observation. A new name is
+// stored and the old one kept as an alias.
+func touchVehicle(tx *sql.Tx, id int64, o VehicleObservation, now time.Time) error {
+	var name, plate string
+	var cd int64
+	if err := tx.QueryRow("SELECT vehicle_cd, vehicle_name, plate FROM vehicle_registry WHERE id = ?", id).Scan(&cd, &name, &plate); err != nil {
+		return err
+	}
+
+	if o.VehicleName != "" && o.VehicleName != name {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO vehicle_aliases (vehicle_id, name, first_seen) VALUES (?, ?, ?)", id, name, now); err != nil {
+			return err
+		}
+		name = o.VehicleName
+	}
+	if cd == 0 {
+		cd = o.VehicleCD
+	}
+	plateChanged := o.Plate != "" && o.Plate != plate
+	if plateChanged {
+		plate = o.Plate
+	}
+
+	_, err := tx.Exec(`
+		UPDATE vehicle_registry
+		SET vehicle_cd = ?, vehicle_name = ?, plate = ?, branch_cd = ?, last_seen = ?
+		WHERE id = ?
+	`, cd, name, plate, o.BranchCD, now, id)
+	if err != nil {
+		return err
+	}
+
+	if plateChanged {
+		return checkPlate(tx, id, plate, now)
+	}
+	return nil
+}
+
+// checkPlate records a collision with every other active entry that has
+// the same plate.
+func checkPlate(tx *sql.Tx, id int64, plate string, now time.Time) error {
+	if plate == "" {
+		return nil
+	}
+
+	rows, err := tx.Query("SELECT id FROM vehicle_registry WHERE plate = ? AND id != ? AND merged_into IS NULL", plate, id)
+	if err != nil {
+		return err
+	}
+	var others []int64
+	for rows.Next() {
+		var other int64
+		if err := rows.Scan(&other); err != nil {
+			rows.Close()
+			return err
+		}
+		others = append(others, other)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, other := range others {
+		if err := recordCollision(tx, id, other, "plate", plate, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func recordCollision(tx *sql.Tx, id, other int64, field, value string, now time.Time) error {
+	_, err := tx.Exec(`
+		INSERT OR IGNORE INTO vehicle_collisions (vehicle_id, other_vehicle_id, field, value, detected_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, id, other, field, value, now)
+	return err
+}
+
+// MergeVehicles merges mergeID into keepID. Later observations of the merged
+// entry resolve to keepID, its names become aliases of keepID and
+// collisions between the two are marked resolved.
+func (s *Storage) MergeVehicles(keepID, mergeID int64) error {
+	if keepID == mergeID {
+		return fmt.Errorf("cannot merge vehicle %d into itself", keepID)
+	}
+
+	s.registryMu.Lock()
+	defer s.registryMu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var keepCD, mergeCD int64
+	var mergeName string
+	var keepMerged, mergeMerged sql.NullInt64
+	if err := tx.QueryRow("SELECT vehicle_cd, merged_into FROM vehicle_registry WHERE id = ?", keepID).Scan(&keepCD, &keepMerged); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("vehicle %d not found", keepID)
+		}
+		return err
+	}
+	if err := tx.QueryRow("SELECT vehicle_cd, vehicle_name, merged_into FROM vehicle_registry WHERE id = ?", mergeID).Scan(&mergeCD, &mergeName, &mergeMerged); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("vehicle %d not found", mergeID)
+		}
+		return err
+	}
+	if keepMerged.Valid || mergeMerged.Valid {
+		return fmt.Errorf("vehicle %d or %d was already merged", keepID, mergeID)
+	}
+
+	now := time.Now()
+	queries := []struct {
+		query string
+		args  []interface{}
+	}{
+		{"UPDATE vehicle_registry SET merged_into = ? WHERE id = ? OR merged_into = ?", []interface{}{keepID, mergeID, mergeID}},
+		{"UPDATE OR IGNORE vehicle_aliases SET vehicle_id = ? WHERE vehicle_id = ?", []interface{}{keepID, mergeID}},
+		{"DELETE FROM vehicle_aliases WHERE vehicle_id = ?", []interface{}{mergeID}},
+		{"INSERT OR IGNORE INTO vehicle_aliases (vehicle_id, name, first_seen) VALUES (?, ?, ?)", []interface{}{keepID, mergeName, now}},
+		{`UPDATE vehicle_registry
+			SET first_seen = MIN(first_seen, (SELECT first_seen FROM vehicle_registry WHERE id = ?))
+			WHERE id = ?`, []interface{}{mergeID, keepID}},
+		{`UPDATE vehicle_collisions SET resolved_at = ?
+			WHERE resolved_at IS NULL
+			AND ((vehicle_id = ? AND other_vehicle_id = ?) OR (vehicle_id = ? AND other_vehicle_id = ?))`,
+			[]interface{}{now, keepID, mergeID, mergeID, keepID}},
+	}
+	if keepCD == 0 && mergeCD != 0 {
+		queries = append(queries, struct {
+			query string
+			args  []interface{}
+		}{"UPDATE vehicle_registry SET vehicle_cd = ? WHERE id = ?", []interface{}{mergeCD, keepID}})
+	}
+
+	for _, q := range queries {
+		if _, err := tx.Exec(q.query, q.args...); err != nil {
+			return fmt.Errorf("failed to merge vehicles: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetVehicle returns the registry entry, or nil if it does not exist.
+func (s *Storage) GetVehicle(id int64) (*RegisteredVehicle, error) {
+	vehicles, err := s.queryVehicles("WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(vehicles) == 0 {
+		return nil, nil
+	}
+	return &vehicles[0], nil
+}
+
+// ListVehicles returns the registry ordered by ID. Merged entries are only
+// included when includeMerged is set.
+func (s *Storage) ListVehicles(includeMerged bool) ([]RegisteredVehicle, error) {
+	if includeMerged {
+		return s.queryVehicles("")
+	}
+	return s.queryVehicles("WHERE merged_into IS NULL")
+}
+
+func (s *Storage) queryVehicles(where string, args ...interface{}) ([]RegisteredVehicle, error) {
+	query := `
+		SELECT id, vehicle_cd, vehicle_name, plate, branch_cd, merged_into, first_seen, last_seen
+		FROM vehicle_registry
+		` + where + `
+		ORDER BY id
+	`
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var vehicles []RegisteredVehicle
+	index := make(map[int64]int)
+	for rows.Next() {
+		var v RegisteredVehicle
+		var mergedInto sql.NullInt64
+		if err := rows.Scan(&v.ID, &v.VehicleCD, &v.VehicleName, &v.Plate, &v.BranchCD, &mergedInto, &v.FirstSeen, &v.LastSeen); err != nil {
+			return nil, err
+		}
+		if mergedInto.Valid {
+			v.MergedInto = &mergedInto.Int64
+		}
+		index[v.ID] = len(vehicles)
+		vehicles = append(vehicles, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(vehicles) == 0 {
+		return vehicles, nil
+	}
+
+	aliasRows, err := s.db.Query("SELECT vehicle_id, name FROM vehicle_aliases ORDER BY first_seen, name")
+	if err != nil {
+		return nil, err
+	}
+	defer aliasRows.Close()
+
+	for aliasRows.Next() {
+		var id int64
+		var name string
+		if err := aliasRows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		if i, ok := index[id]; ok {
+			vehicles[i].Aliases = append(vehicles[i].Aliases, name)
+		}
+	}
+
+	return vehicles, aliasRows.Err()
+}
+
+// ListVehicleCollisions returns detected collisions, newest first. Resolved
+// collisions are only included when includeResolved is set.
+func (s *Storage) ListVehicleCollisions(includeResolved bool) ([]VehicleCollision, error) {
+	query := `
+		SELECT id, vehicle_id, other_vehicle_id, field, value, detected_at, resolved_at
+		FROM vehicle_collisions
+	`
+	if !includeResolved {
+		query += " WHERE resolved_at IS NULL"
+	}
+	query += " ORDER BY detected_at DESC, id DESC"
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var collisions []VehicleCollision
+	for rows.Next() {
+		var c VehicleCollision
+		var resolvedAt sql.NullTime
+		if err := rows.Scan(&c.ID, &c.VehicleID, &c.OtherVehicleID, &c.Field, &c.Value, &c.DetectedAt, &resolvedAt); err != nil {
+			return nil, err
+		}
+		if resolvedAt.Valid {
+			c.ResolvedAt = &resolvedAt.Time
+		}
+		collisions = append(collisions, c)
+	}
+
+	return collisions, rows.Err()
+}
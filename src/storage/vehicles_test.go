@@ -0,0 +1,150 @@
+package storage
+
+import "testing"
+
+func registerOne(t *testing.T, store *Storage, o VehicleObservation) int64 {
+	t.Helper()
+	ids, err := store.RegisterVehicles([]VehicleObservation{o})
+	if err != nil {
+		t.Fatalf("Failed to register vehicle: %v", err)
+	}
+	return ids[0]
+}
+
+func TestStorage_RegisterVehicles(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	ids, err := store.RegisterVehicles([]VehicleObservation{
+		{VehicleCD: 101, VehicleName: "品川 100 あ 12-34", BranchCD: 1},
+		{VehicleCD: 102, VehicleName: "品川 100 い 56-78", BranchCD: 1},
+	})
+	if err != nil {
+		t.Fatalf("Failed to register vehicles: %v", err)
+	}
+	if ids[0] == 0 || ids[0] == ids[1] {
+		t.Fatalf("Expected distinct IDs, got %v", ids)
+	}
+
+	// Same code again resolves to the same ID
+	if id := registerOne(t, store, VehicleObservation{VehicleCD: 101, VehicleName: "品川 100 あ 12-34", BranchCD: 1}); id != ids[0] {
+		t.Errorf("Expected ID %d, got %d", ids[0], id)
+	}
+
+	// A rename keeps the ID and records the old name
+	if id := registerOne(t, store, VehicleObservation{VehicleCD: 101, VehicleName: "Renamed truck", BranchCD: 2}); id != ids[0] {
+		t.Errorf("Expected renamed vehicle to keep ID %d, got %d", ids[0], id)
+	}
+	v, err := store.GetVehicle(ids[0])
+	if err != nil || v == nil {
+		t.Fatalf("Failed to get vehicle: %v", err)
+	}
+	if v.VehicleName != "Renamed truck" || v.BranchCD != 2 {
+		t.Errorf("Expected updated name and branch, got %+v", v)
+	}
+	if len(v.Aliases) != 1 || v.Aliases[0] != "品川 100 あ 12-34" {
+		t.Errorf("Expected old name as alias, got %v", v.Aliases)
+	}
+
+	// Without a code, the old name still finds the vehicle
+	if id := registerOne(t, store, VehicleObservation{VehicleName: "品川 100 あ 12-34"}); id != ids[0] {
+		t.Errorf("Expected alias lookup to return %d, got %d", ids[0], id)
+	}
+
+	if missing, _ := store.GetVehicle(9999); missing != nil {
+		t.Errorf("Expected nil for unknown vehicle, got %+v", missing)
+	}
+}
+
+func TestStorage_VehicleCollisions(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	first := registerOne(t, store, VehicleObservation{VehicleCD: 1, VehicleName: "Truck", Plate: "品川100あ1234"})
+	// A different code with the same name is a new vehicle plus a collision
+	second := registerOne(t, store, VehicleObservation{VehicleCD: 2, VehicleName: "Truck"})
+	if first == second {
+		t.Fatal("Expected vehicles with different codes to get different IDs")
+	}
+	// A different code with the same plate is also a collision
+	third := registerOne(t, store, VehicleObservation{VehicleCD: 3, VehicleName: "Other", Plate: "品川100あ1234"})
+
+	collisions, err := store.ListVehicleCollisions(false)
+	if err != nil {
+		t.Fatalf("Failed to list collisions: %v", err)
+	}
+	if len(collisions) != 2 {
+		t.Fatalf("Expected 2 collisions, got %+v", collisions)
+	}
+	fields := map[string]bool{}
+	for _, c := range collisions {
+		fields[c.Field] = true
+	}
+	if !fields["name"] || !fields["plate"] {
+		t.Errorf("Expected name and plate collisions, got %+v", collisions)
+	}
+
+	// Registering again does not duplicate collisions
+	registerOne(t, store, VehicleObservation{VehicleCD: 3, VehicleName: "Other", Plate: "品川100あ1234"})
+	if again, _ := store.ListVehicleCollisions(false); len(again) != 2 {
+		t.Errorf("Expected collisions to be recorded once, got %d", len(again))
+	}
+
+	// Merging resolves the collision between the two entries
+	if err := store.MergeVehicles(first, third); err != nil {
+		t.Fatalf("Failed to merge: %v", err)
+	}
+	open, _ := store.ListVehicleCollisions(false)
+	if len(open) != 1 || open[0].Field != "name" {
+		t.Errorf("Expected only the name collision to remain, got %+v", open)
+	}
+	all, _ := store.ListVehicleCollisions(true)
+	if len(all) != 2 {
+		t.Errorf("Expected resolved collisions when requested, got %d", len(all))
+	}
+}
+
+func TestStorage_MergeVehicles(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	keep := registerOne(t, store, VehicleObservation{VehicleName: "Old entry"})
+	merge := registerOne(t, store, VehicleObservation{VehicleCD: 55, VehicleName: "New entry"})
+
+	if err := store.MergeVehicles(keep, keep); err == nil {
+		t.Error("Expected error merging a vehicle into itself")
+	}
+	if err := store.MergeVehicles(keep, 9999); err == nil {
+		t.Error("Expected error merging an unknown vehicle")
+	}
+
+	if err := store.MergeVehicles(keep, merge); err != nil {
+		t.Fatalf("Failed to merge: %v", err)
+	}
+	if err := store.MergeVehicles(keep, merge); err == nil {
+		t.Error("Expected error merging an already merged vehicle")
+	}
+
+	// The merged code now resolves to the kept ID
+	if id := registerOne(t, store, VehicleObservation{VehicleCD: 55, VehicleName: "New entry"}); id != keep {
+		t.Errorf("Expected merged code to resolve to %d, got %d", keep, id)
+	}
+
+	v, _ := store.GetVehicle(keep)
+	if v.VehicleCD != 55 {
+		t.Errorf("Expected kept entry to adopt code 55, got %d", v.VehicleCD)
+	}
+
+	active, err := store.ListVehicles(false)
+	if err != nil {
+		t.Fatalf("Failed to list vehicles: %v", err)
+	}
+	if len(active) != 1 || active[0].ID != keep {
+		t.Errorf("Expected only the kept vehicle, got %+v", active)
+	}
+
+	all, _ := store.ListVehicles(true)
+	if len(all) != 2 || all[1].MergedInto == nil || *all[1].MergedInto != keep {
+		t.Errorf("Expected merged entry to point at %d, got %+v", keep, all)
+	}
+}
@@ -0,0 +1,112 @@
+package vehicle
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Plate is a Japanese license plate, e.g. "品川 300 あ 12-34".
+type Plate struct {
+	Region string `json:"region"` // 地名, e.g. "品川"
+	Class  string `json:"class"`  // 分類番号, e.g. "300" or "30A"
+	Kana   string `json:"kana"`   // ひらがな, e.g. "あ"
+	Serial string `json:"serial"` // 一連指定番号 without separators, e.g. "1234" or "1"
+}
+
+// platePattern matches a plate after normalization. Parts may be separated
+// by a space. Serial numbers are written "12-34" (or "1234") for four digits
+// and padded with middle dots for shorter ones ("・・・1", "・123").
+var platePattern = regexp.MustCompile(
+	`([\p{Han}\p{Hiragana}\p{Katakana}]{1,6}) ?` + // region
+		`([0-9][0-9A-Z]{0,2}) ?` + // classification number
+		`(\p{Hiragana}) ?` + // kana
+		`([0-9]{2}[-ー]?[0-9]{2}|[・.]{1,3}[0-9]{1,3}|[0-9]{1,4})`) // serial
+
+// hyphenReplacer maps dash variants that NFKC keeps to "-".
+var hyphenReplacer = strings.NewReplacer("‐", "-", "‑", "-", "–", "-", "—", "-", "−", "-")
+
+// NormalizePlateText applies NFKC (full-width digits, letters and spaces
+// become ASCII, half-width katakana become full-width), unifies dashes and
+// collapses whitespace to single spaces.
+func NormalizePlateText(s string) string {
+	s = norm.NFKC.String(s)
+	s = hyphenReplacer.Replace(s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// ParsePlate finds a license plate in s, which may contain other text such
+// as "1号車 品川３００あ１２－３４". Text before the plate must be separated
+// by a space or end in a non-kanji character. It returns false if there is
+// no plate.
+func ParsePlate(s string) (*Plate, bool) {
+	m := platePattern.FindStringSubmatch(NormalizePlateText(s))
+	if m == nil {
+		return nil, false
+	}
+
+	serial := serialDigits(m[4])
+	if serial == "" {
+		return nil, false
+	}
+
+	return &Plate{
+		Region: m[1],
+		Class:  m[2],
+		Kana:   m[3],
+		Serial: serial,
+	}, true
+}
+
+// String formats the plate as it is printed, e.g. "品川 300 あ 12-34" or
+// "品川 300 あ ・・・1".
+func (p Plate) String() string {
+	return p.Region + " " + p.Class + " " + p.Kana + " " + p.formattedSerial()
+}
+
+// Key is the compact form used for storage and matching, e.g. "品川300あ1234".
+func (p Plate) Key() string {
+	return p.Region + p.Class + p.Kana + p.Serial
+}
+
+func (p Plate) formattedSerial() string {
+	if len(p.Serial) == 4 {
+		return p.Serial[:2] + "-" + p.Serial[2:]
+	}
+	if len(p.Serial) < 4 {
+		return strings.Repeat("・", 4-len(p.Serial)) + p.Serial
+	}
+	return p.Serial
+}
+
+// Matches reports whether the plate has every non-empty field of q. Query
+// values are normalized like plate text; Serial may include separators.
+func (p Plate) Matches(q Plate) bool {
+	if q.Region != "" && NormalizePlateText(q.Region) != p.Region {
+		return false
+	}
+	if q.Class != "" && strings.ToUpper(NormalizePlateText(q.Class)) != p.Class {
+		return false
+	}
+	if q.Kana != "" && NormalizePlateText(q.Kana) != p.Kana {
+		return false
+	}
+	if q.Serial != "" {
+		if serialDigits(NormalizePlateText(q.Serial)) != p.Serial {
+			return false
+		}
+	}
+	return true
+}
+
+// serialDigits strips separators, dots and leading zeros from a serial number.
+func serialDigits(s string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+	return strings.TrimLeft(digits, "0")
+}
@@ -0,0 +1,71 @@
+package vehicle
+
+import "testing"
+
+func TestParsePlate(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Plate
+		str   string
+	}{
+		{"half-width with spaces", "品川 300 あ 12-34", Plate{"品川", "300", "あ", "1234"}, "品川 300 あ 12-34"},
+		{"full-width digits", "品川３００あ１２－３４", Plate{"品川", "300", "あ", "1234"}, "品川 300 あ 12-34"},
+		{"full-width spaces", "横浜　５００　さ　　５６-７８", Plate{"横浜", "500", "さ", "5678"}, "横浜 500 さ 56-78"},
+		{"no hyphen", "足立100え1234", Plate{"足立", "100", "え", "1234"}, "足立 100 え 12-34"},
+		{"long vowel mark as hyphen", "練馬 400 か 12ー34", Plate{"練馬", "400", "か", "1234"}, "練馬 400 か 12-34"},
+		{"dotted short serial", "つくば 300 た ・・・1", Plate{"つくば", "300", "た", "1"}, "つくば 300 た ・・・1"},
+		{"half-width dots", "尾張小牧 100 せ ･･12", Plate{"尾張小牧", "100", "せ", "12"}, "尾張小牧 100 せ ・・12"},
+		{"letter in class", "品川 30A ひ 12-34", Plate{"品川", "30A", "ひ", "1234"}, "品川 30A ひ 12-34"},
+		{"full-width letter in class", "品川 ３０Ａ ひ 12-34", Plate{"品川", "30A", "ひ", "1234"}, "品川 30A ひ 12-34"},
+		{"prefix text", "1号車 品川 100 あ 12-34 冷凍", Plate{"品川", "100", "あ", "1234"}, "品川 100 あ 12-34"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParsePlate(tt.input)
+			if !ok {
+				t.Fatalf("ParsePlate(%q) found no plate", tt.input)
+			}
+			if *got != tt.want {
+				t.Errorf("ParsePlate(%q) = %+v, want %+v", tt.input, *got, tt.want)
+			}
+			if got.String() != tt.str {
+				t.Errorf("String() = %q, want %q", got.String(), tt.str)
+			}
+		})
+	}
+}
+
+func TestParsePlate_NoPlate(t *testing.T) {
+	for _, s := range []string{"", "Truck 42", "品川", "1号車", "品川 300 12-34"} {
+		if p, ok := ParsePlate(s); ok {
+			t.Errorf("ParsePlate(%q) = %+v, want no plate", s, p)
+		}
+	}
+}
+
+func TestPlate_KeyAndMatches(t *testing.T) {
+	p, _ := ParsePlate("品川 300 あ 12-34")
+	if p.Key() != "品川300あ1234" {
+		t.Errorf("Unexpected key %q", p.Key())
+	}
+
+	tests := []struct {
+		query Plate
+		want  bool
+	}{
+		{Plate{}, true},
+		{Plate{Region: "品川"}, true},
+		{Plate{Region: "横浜"}, false},
+		{Plate{Class: "３００"}, true},
+		{Plate{Kana: "あ", Serial: "12-34"}, true},
+		{Plate{Serial: "１２３４"}, true},
+		{Plate{Serial: "1235"}, false},
+	}
+	for _, tt := range tests {
+		if got := p.Matches(tt.query); got != tt.want {
+			t.Errorf("Matches(%+v) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
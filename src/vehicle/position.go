@@ -0,0 +1,201 @@
+package vehicle
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Datum is the geodetic datum the portal reports coordinates in.
+type Datum string
+
+const (
+	DatumTokyo Datum = "tokyo" // Tokyo datum (旧日本測地系), used by older on-board units
+	DatumWGS84 Datum = "wgs84"
+)
+
+// Fix quality of a position.
+const (
+	QualityInvalid = "invalid" // GPS disabled or no usable coordinates
+	QualityLow     = "low"     // fewer than 4 satellites, 2D fix at best
+	QualityGood    = "good"
+)
+
+// minGoodSatellites is the satellite count needed for a 3D fix.
+const minGoodSatellites = 4
+
+// Position is a vehicle position in WGS84 decimal degrees.
+type Position struct {
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+	Direction  int     `json:"direction"` // GPSDirection as reported
+	Satellites int     `json:"satellites"`
+	Valid      bool    `json:"valid"`
+	Quality    string  `json:"quality"`
+}
+
+// millisecondsPerDegree converts GPSLatitude/GPSLongitude, which are in
+// milliseconds of arc, to degrees.
+const millisecondsPerDegree = 3600 * 1000
+
+// Position decodes the record's coordinates into WGS84. GPSLatitude and
+// GPSLongitude are used when set, otherwise GPSLatiAndLong is parsed. datum
+// is the datum the portal reports in. It returns nil when the record has no
+// coordinates at all.
+func (t *Telemetry) Position(datum Datum) *Position {
+	lat, lon, ok := t.rawCoordinates()
+	if !ok {
+		return nil
+	}
+
+	if datum != DatumWGS84 {
+		lat, lon = TokyoToWGS84(lat, lon)
+	}
+
+	p := &Position{
+		Latitude:   round(lat, 7),
+		Longitude:  round(lon, 7),
+		Direction:  t.GPSDirection,
+		Satellites: t.GPSSatelliteNum,
+	}
+
+	switch {
+	case t.GPSEnable == 0 || !inRange(lat, lon):
+		p.Quality = QualityInvalid
+	case t.GPSSatelliteNum < minGoodSatellites:
+		p.Quality = QualityLow
+	default:
+		p.Quality = QualityGood
+	}
+	p.Valid = p.Quality != QualityInvalid
+	return p
+}
+
+func (t *Telemetry) rawCoordinates() (float64, float64, bool) {
+	if t.GPSLatitude != 0 || t.GPSLongitude != 0 {
+		return float64(t.GPSLatitude) / millisecondsPerDegree, float64(t.GPSLongitude) / millisecondsPerDegree, true
+	}
+	return ParseLatLong(t.GPSLatiAndLong)
+}
+
+var coordSeparator = regexp.MustCompile(`[\s,/]+`)
+
+// ParseLatLong parses a "latitude,longitude" string. Each value may be
+// decimal degrees ("35.681236") or degrees, minutes and seconds separated by
+// dots, colons or symbols ("35.40.52.45", "35°40'52.45\""), optionally with
+// an N/S/E/W prefix or suffix.
+func ParseLatLong(s string) (float64, float64, bool) {
+	parts := coordSeparator.Split(strings.TrimSpace(s), -1)
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+
+	lat, ok := parseCoordinate(parts[0])
+	if !ok {
+		return 0, 0, false
+	}
+	lon, ok := parseCoordinate(parts[1])
+	if !ok {
+		return 0, 0, false
+	}
+	if lat == 0 && lon == 0 {
+		return 0, 0, false
+	}
+	return lat, lon, true
+}
+
+var dmsSeparator = regexp.MustCompile(`[°'"′″:.]+`)
+
+func parseCoordinate(s string) (float64, bool) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	sign := 1.0
+	if s == "" {
+		return 0, false
+	}
+	if c := s[0]; c == 'N' || c == 'S' || c == 'E' || c == 'W' {
+		if c == 'S' || c == 'W' {
+			sign = -1
+		}
+		s = s[1:]
+	} else if c := s[len(s)-1]; c == 'N' || c == 'S' || c == 'E' || c == 'W' {
+		if c == 'S' || c == 'W' {
+			sign = -1
+		}
+		s = s[:len(s)-1]
+	}
+
+	// Plain decimal degrees
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return sign * v, true
+	}
+
+	// Degrees, minutes, seconds and optional fraction of a second
+	fields := strings.FieldsFunc(dmsSeparator.ReplaceAllString(s, " "), func(r rune) bool { return r == ' ' })
+	if len(fields) < 3 || len(fields) > 4 {
+		return 0, false
+	}
+	var nums [3]float64
+	for i := 0; i < 3; i++ {
+		v, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return 0, false
+		}
+		nums[i] = v
+	}
+	if len(fields) == 4 {
+		frac, err := strconv.ParseFloat("0."+fields[3], 64)
+		if err != nil {
+			return 0, false
+		}
+		nums[2] += frac
+	}
+	if nums[1] >= 60 || nums[2] >= 60 {
+		return 0, false
+	}
+	return sign * (nums[0] + nums[1]/60 + nums[2]/3600), true
+}
+
+// TokyoToWGS84 converts Tokyo datum coordinates to WGS84 with the
+// approximation published for Japan (error within a few meters).
+func TokyoToWGS84(lat, lon float64) (float64, float64) {
+	wLat := lat - 0.00010695*lat + 0.000017464*lon + 0.0046017
+	wLon := lon - 0.000046038*lat - 0.000083043*lon + 0.010040
+	return wLat, wLon
+}
+
+// Odometer parses ODOMeter as kilometers. Empty, zero and unparsable values
+// are reported as missing.
+func (t *Telemetry) Odometer() (float64, bool) {
+	s := strings.TrimSuffix(strings.TrimSpace(norm.NFKC.String(t.ODOMeter)), "km")
+	v, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(s), ",", ""), 64)
+	if err != nil || v <= 0 {
+		return 0, false
+	}
+	return v, true
+}
+
+// earthRadius is the mean Earth radius in meters.
+const earthRadius = 6371008.8
+
+// Distance returns the great-circle distance in meters between two WGS84
+// points using the haversine formula.
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := func(d float64) float64 { return d * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+func inRange(lat, lon float64) bool {
+	return lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180 && !(lat == 0 && lon == 0)
+}
+
+func round(v float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(v*scale) / scale
+}
@@ -0,0 +1,141 @@
+package vehicle
+
+import (
+	"math"
+	"testing"
+)
+
+func near(a, b, tolerance float64) bool {
+	return math.Abs(a-b) <= tolerance
+}
+
+func TestTelemetry_Position(t *testing.T) {
+	// Tokyo Station in Tokyo datum milliseconds of arc
+	tm := &Telemetry{
+		GPSLatitude:     128440800,
+		GPSLongitude:    503174880,
+		GPSEnable:       1,
+		GPSSatelliteNum: 7,
+		GPSDirection:    90,
+	}
+
+	p := tm.Position(DatumTokyo)
+	if p == nil {
+		t.Fatal("Expected a position")
+	}
+	// WGS84 Tokyo Station is 35.681236, 139.767125
+	if !near(p.Latitude, 35.681236, 0.001) || !near(p.Longitude, 139.767125, 0.001) {
+		t.Errorf("Unexpected WGS84 position %v, %v", p.Latitude, p.Longitude)
+	}
+	if !p.Valid || p.Quality != QualityGood || p.Direction != 90 || p.Satellites != 7 {
+		t.Errorf("Unexpected position flags: %+v", p)
+	}
+
+	wgs := tm.Position(DatumWGS84)
+	if !near(wgs.Latitude, 35.678, 1e-6) || !near(wgs.Longitude, 139.7708, 1e-6) {
+		t.Errorf("Expected WGS84 datum to be passed through, got %v, %v", wgs.Latitude, wgs.Longitude)
+	}
+}
+
+func TestTelemetry_PositionQuality(t *testing.T) {
+	tests := []struct {
+		name       string
+		enable     int
+		satellites int
+		lat, lon   int64
+		quality    string
+	}{
+		{"good fix", 1, 4, 128440800, 503174880, QualityGood},
+		{"few satellites", 1, 3, 128440800, 503174880, QualityLow},
+		{"no satellites", 1, 0, 128440800, 503174880, QualityLow},
+		{"gps disabled", 0, 8, 128440800, 503174880, QualityInvalid},
+		{"out of range", 1, 8, 900000000, 503174880, QualityInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm := &Telemetry{GPSEnable: tt.enable, GPSSatelliteNum: tt.satellites, GPSLatitude: tt.lat, GPSLongitude: tt.lon}
+			p := tm.Position(DatumTokyo)
+			if p.Quality != tt.quality {
+				t.Errorf("Expected quality %s, got %s", tt.quality, p.Quality)
+			}
+			if p.Valid != (tt.quality != QualityInvalid) {
+				t.Errorf("Valid flag %v does not match quality %s", p.Valid, p.Quality)
+			}
+		})
+	}
+
+	if p := (&Telemetry{GPSEnable: 1}).Position(DatumTokyo); p != nil {
+		t.Errorf("Expected nil position without coordinates, got %+v", p)
+	}
+}
+
+func TestParseLatLong(t *testing.T) {
+	tests := []struct {
+		input    string
+		lat, lon float64
+		ok       bool
+	}{
+		{"35.681236,139.767125", 35.681236, 139.767125, true},
+		{"35.681236 / 139.767125", 35.681236, 139.767125, true},
+		{"N35.40.52.45 E139.46.15.00", 35 + 40.0/60 + 52.45/3600, 139 + 46.0/60 + 15.0/3600, true},
+		{"35°40'52.45\" 139°46'15\"", 35 + 40.0/60 + 52.45/3600, 139 + 46.0/60 + 15.0/3600, true},
+		{"33.86S,151.21E", -33.86, 151.21, true},
+		{"", 0, 0, false},
+		{"0,0", 0, 0, false},
+		{"35.681236", 0, 0, false},
+		{"abc,def", 0, 0, false},
+		{"35.70.00,139.00.00", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		lat, lon, ok := ParseLatLong(tt.input)
+		if ok != tt.ok {
+			t.Errorf("ParseLatLong(%q) ok = %v, want %v", tt.input, ok, tt.ok)
+			continue
+		}
+		if ok && (!near(lat, tt.lat, 1e-9) || !near(lon, tt.lon, 1e-9)) {
+			t.Errorf("ParseLatLong(%q) = %v, %v, want %v, %v", tt.input, lat, lon, tt.lat, tt.lon)
+		}
+	}
+}
+
+func TestTelemetry_PositionFromLatLongString(t *testing.T) {
+	tm := &Telemetry{GPSLatiAndLong: "35.678,139.7708", GPSEnable: 1, GPSSatelliteNum: 5}
+	p := tm.Position(DatumTokyo)
+	if p == nil || !near(p.Latitude, 35.681236, 0.001) {
+		t.Errorf("Expected position from GPSLatiAndLong, got %+v", p)
+	}
+}
+
+func TestDistance(t *testing.T) {
+	// Tokyo Station to Shin-Osaka Station is about 403 km
+	d := Distance(35.681236, 139.767125, 34.733165, 135.500255)
+	if !near(d, 403000, 2000) {
+		t.Errorf("Expected about 403 km, got %v m", d)
+	}
+	if d := Distance(35.0, 139.0, 35.0, 139.0); d != 0 {
+		t.Errorf("Expected 0 for the same point, got %v", d)
+	}
+}
+
+func TestTelemetry_Odometer(t *testing.T) {
+	tests := []struct {
+		input string
+		want  float64
+		ok    bool
+	}{
+		{"123456.7", 123456.7, true},
+		{"１２３,４５６ km", 123456, true},
+		{"", 0, false},
+		{"0", 0, false},
+		{"---", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := (&Telemetry{ODOMeter: tt.input}).Odometer()
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("Odometer(%q) = %v, %v, want %v, %v", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}
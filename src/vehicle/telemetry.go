@@ -0,0 +1,275 @@
+package vehicle
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Telemetry is one vehicle state record from VehicleStateTableForBranchEx.
+// The fields and JSON names follow the dtakologs table
+// (0003_chubby_annihilus.sql) so records can be passed through unchanged.
+type Telemetry struct {
+	Type string `json:"__type"`
+
+	// Vehicle and branch
+	VehicleCD        int64  `json:"VehicleCD"`
+	VehicleName      string `json:"VehicleName"`
+	BranchCD         int    `json:"BranchCD"`
+	BranchName       string `json:"BranchName"`
+	DataFilterType   int    `json:"DataFilterType"`
+	DispFlag         int    `json:"DispFlag"`
+	VehicleIconColor string `json:"VehicleIconColor"`
+
+	VehicleIconLabelForDatetime string `json:"VehicleIconLabelForDatetime"`
+	VehicleIconLabelForDriver   string `json:"VehicleIconLabelForDriver"`
+	VehicleIconLabelForVehicle  string `json:"VehicleIconLabelForVehicle"`
+
+	// Timestamps as sent by the portal
+	DataDateTime      string `json:"DataDateTime"`
+	ComuDateTime      string `json:"ComuDateTime"`
+	StartWorkDateTime string `json:"StartWorkDateTime"`
+
+	// Driver
+	DriverCD    int    `json:"DriverCD"`
+	DriverName  string `json:"DriverName"`
+	SubDriverCD int    `json:"SubDriverCD"`
+
+	// Work state
+	CurrentWorkCD          int    `json:"CurrentWorkCD"`
+	CurrentWorkName        string `json:"CurrentWorkName"`
+	OperationState         int    `json:"OperationState"`
+	AllState               string `json:"AllState"`
+	AllStateEx             string `json:"AllStateEx"`
+	AllStateFontColor      string `json:"AllStateFontColor"`
+	AllStateFontColorIndex int    `json:"AllStateFontColorIndex"`
+	AllStateRyoutColor     string `json:"AllStateRyoutColor"`
+	State                  string `json:"State"`
+	State1                 string `json:"State1"`
+	State2                 string `json:"State2"`
+	State3                 string `json:"State3"`
+	StateFlag              string `json:"StateFlag"`
+	EventVal               string `json:"EventVal"`
+
+	// Communication
+	ReciveEventType     int    `json:"ReciveEventType"`
+	RecivePacketType    int    `json:"RecivePacketType"`
+	ReciveTypeColorName string `json:"ReciveTypeColorName"`
+	ReciveTypeName      string `json:"ReciveTypeName"`
+	ReciveWorkCD        int    `json:"ReciveWorkCD"`
+
+	// Position and movement
+	GPSLatitude     int64   `json:"GPSLatitude"`
+	GPSLongitude    int64   `json:"GPSLongitude"`
+	GPSLatiAndLong  string  `json:"GPSLatiAndLong"`
+	GPSDirection    int     `json:"GPSDirection"`
+	GPSEnable       int     `json:"GPSEnable"`
+	GPSSatelliteNum int     `json:"GPSSatelliteNum"`
+	AddressDispC    string  `json:"AddressDispC"`
+	AddressDispP    string  `json:"AddressDispP"`
+	Speed           float64 `json:"Speed"`
+	Revo            int     `json:"Revo"`
+	ODOMeter        string  `json:"ODOMeter"`
+
+	// Temperatures
+	Temp1        string `json:"Temp1"`
+	Temp2        string `json:"Temp2"`
+	Temp3        string `json:"Temp3"`
+	Temp4        string `json:"Temp4"`
+	SettingTemp  string `json:"SettingTemp"`
+	SettingTemp1 string `json:"SettingTemp1"`
+	SettingTemp3 string `json:"SettingTemp3"`
+	SettingTemp4 string `json:"SettingTemp4"`
+	TempState    int    `json:"TempState"`
+
+	// Extra holds fields the schema does not know, with their original values.
+	Extra map[string]interface{} `json:"Extra,omitempty"`
+}
+
+// Issue describes a field the decoder could not map cleanly.
+type Issue struct {
+	Field  string
+	Kind   string // "unknown" or "invalid"
+	Detail string
+}
+
+func (i Issue) String() string {
+	if i.Detail == "" {
+		return fmt.Sprintf("%s field %s", i.Kind, i.Field)
+	}
+	return fmt.Sprintf("%s field %s: %s", i.Kind, i.Field, i.Detail)
+}
+
+// telemetryFields maps each JSON name to its struct field index.
+var telemetryFields = func() map[string]int {
+	fields := make(map[string]int)
+	t := reflect.TypeOf(Telemetry{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "Extra" {
+			continue
+		}
+		fields[name] = i
+	}
+	return fields
+}()
+
+// Decode converts a raw bridge record to Telemetry. Numbers sent as strings
+// and strings sent as numbers are converted; null leaves the zero value.
+// Fields outside the schema are kept in Extra and reported as "unknown", and
+// values that cannot be converted are reported as "invalid".
+func Decode(record map[string]interface{}) (*Telemetry, []Issue) {
+	t := &Telemetry{}
+	v := reflect.ValueOf(t).Elem()
+	var issues []Issue
+
+	keys := make([]string, 0, len(record))
+	for k := range record {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		raw := record[key]
+		index, ok := telemetryFields[key]
+		if !ok {
+			if t.Extra == nil {
+				t.Extra = make(map[string]interface{})
+			}
+			t.Extra[key] = raw
+			issues = append(issues, Issue{Field: key, Kind: "unknown"})
+			continue
+		}
+		if raw == nil {
+			continue
+		}
+		if err := setField(v.Field(index), raw); err != nil {
+			issues = append(issues, Issue{Field: key, Kind: "invalid", Detail: err.Error()})
+		}
+	}
+
+	return t, issues
+}
+
+// DecodeAll decodes every record in a bridge result, skipping entries that are
+// not objects. The issues of all records are merged by field.
+func DecodeAll(records []interface{}) ([]*Telemetry, []Issue) {
+	out := make([]*Telemetry, 0, len(records))
+	seen := make(map[string]bool)
+	var issues []Issue
+
+	for i, rawItem := range records {
+		item, ok := rawItem.(map[string]interface{})
+		if !ok {
+			issues = append(issues, Issue{Field: fmt.Sprintf("[%d]", i), Kind: "invalid", Detail: fmt.Sprintf("record is %T, not an object", rawItem)})
+			continue
+		}
+		t, recordIssues := Decode(item)
+		out = append(out, t)
+		for _, issue := range recordIssues {
+			key := issue.Kind + ":" + issue.Field
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			issues = append(issues, issue)
+		}
+	}
+
+	return out, issues
+}
+
+func setField(field reflect.Value, raw interface{}) error {
+	switch field.Kind() {
+	case reflect.String:
+		s, err := toString(raw)
+		if err != nil {
+			return err
+		}
+		field.SetString(s)
+
+	case reflect.Int, reflect.Int64:
+		n, err := toInt(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+
+	case reflect.Float64:
+		f, err := toFloat(raw)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+
+	default:
+		return fmt.Errorf("unsupported field kind %s", field.Kind())
+	}
+	return nil
+}
+
+func toString(raw interface{}) (string, error) {
+	switch v := raw.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case json.Number:
+		return v.String(), nil
+	}
+	return "", fmt.Errorf("expected string, got %T", raw)
+}
+
+func toInt(raw interface{}) (int64, error) {
+	switch v := raw.(type) {
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("expected integer, got %v", v)
+		}
+		return int64(v), nil
+	case string:
+		s := strings.TrimSpace(v)
+		if s == "" {
+			return 0, nil
+		}
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("expected integer, got %q", v)
+		}
+		return n, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case json.Number:
+		return v.Int64()
+	}
+	return 0, fmt.Errorf("expected integer, got %T", raw)
+}
+
+func toFloat(raw interface{}) (float64, error) {
+	switch v := raw.(type) {
+	case float64:
+		return v, nil
+	case string:
+		s := strings.TrimSpace(v)
+		if s == "" {
+			return 0, nil
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("expected number, got %q", v)
+		}
+		return f, nil
+	case json.Number:
+		return v.Float64()
+	}
+	return 0, fmt.Errorf("expected number, got %T", raw)
+}
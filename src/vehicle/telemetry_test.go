@@ -0,0 +1,129 @@
+package vehicle
+
+import (
+	"os"
+	"regexp"
+	"testing"
+)
+
+// TestTelemetry_MatchesSchema checks that Telemetry has exactly the columns
+// of the dtakologs table.
+func TestTelemetry_MatchesSchema(t *testing.T) {
+	schema, err := os.ReadFile("../../0003_chubby_annihilus.sql")
+	if err != nil {
+		t.Fatalf("Failed to read schema: %v", err)
+	}
+
+	columns := make(map[string]bool)
+	for _, m := range regexp.MustCompile("(?m)^\\s*`([^`]+)`").FindAllStringSubmatch(string(schema), -1) {
+		columns[m[1]] = true
+	}
+	if len(columns) == 0 {
+		t.Fatal("No columns found in schema")
+	}
+
+	for column := range columns {
+		if _, ok := telemetryFields[column]; !ok {
+			t.Errorf("Column %s is missing from Telemetry", column)
+		}
+	}
+	for field := range telemetryFields {
+		if !columns[field] {
+			t.Errorf("Telemetry field %s is not in the schema", field)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	record := map[string]interface{}{
+		"__type":          "VehicleStateInfo:#Venus",
+		"VehicleCD":       float64(1234),
+		"VehicleName":     "品川 100 あ 12-34",
+		"BranchCD":        "2",
+		"DriverCD":        float64(15),
+		"DriverName":      nil,
+		"GPSLatitude":     float64(128565432),
+		"GPSLongitude":    float64(503221234),
+		"GPSEnable":       float64(1),
+		"GPSSatelliteNum": float64(8),
+		"Speed":           "42.5",
+		"Temp1":           float64(-18.5),
+		"ODOMeter":        "123456",
+		"DataDateTime":    "25/01/15 10:30",
+		"NewPortalField":  map[string]interface{}{"nested": true},
+	}
+
+	tm, issues := Decode(record)
+
+	if tm.VehicleCD != 1234 {
+		t.Errorf("Expected VehicleCD 1234, got %d", tm.VehicleCD)
+	}
+	if tm.BranchCD != 2 {
+		t.Errorf("Expected numeric string BranchCD to decode to 2, got %d", tm.BranchCD)
+	}
+	if tm.DriverName != "" {
+		t.Errorf("Expected null DriverName to be empty, got %q", tm.DriverName)
+	}
+	if tm.GPSLatitude != 128565432 || tm.GPSSatelliteNum != 8 {
+		t.Errorf("Unexpected GPS values: %+v", tm)
+	}
+	if tm.Speed != 42.5 {
+		t.Errorf("Expected Speed 42.5, got %v", tm.Speed)
+	}
+	if tm.Temp1 != "-18.5" {
+		t.Errorf("Expected Temp1 -18.5, got %q", tm.Temp1)
+	}
+	if tm.Type != "VehicleStateInfo:#Venus" {
+		t.Errorf("Expected __type to be decoded, got %q", tm.Type)
+	}
+
+	if len(issues) != 1 || issues[0].Field != "NewPortalField" || issues[0].Kind != "unknown" {
+		t.Fatalf("Expected one unknown field issue, got %v", issues)
+	}
+	if nested, ok := tm.Extra["NewPortalField"].(map[string]interface{}); !ok || nested["nested"] != true {
+		t.Errorf("Expected unknown field to be kept unchanged in Extra, got %#v", tm.Extra)
+	}
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		value interface{}
+	}{
+		{"fraction for integer", "DriverCD", float64(1.5)},
+		{"text for integer", "VehicleCD", "abc"},
+		{"text for number", "Speed", "fast"},
+		{"object for string", "Temp1", map[string]interface{}{}},
+		{"list for integer", "GPSEnable", []interface{}{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, issues := Decode(map[string]interface{}{tt.field: tt.value})
+			if len(issues) != 1 || issues[0].Kind != "invalid" || issues[0].Field != tt.field {
+				t.Errorf("Expected invalid issue for %s, got %v", tt.field, issues)
+			}
+		})
+	}
+}
+
+func TestDecodeAll(t *testing.T) {
+	records := []interface{}{
+		map[string]interface{}{"VehicleCD": float64(1), "Extra1": "a"},
+		map[string]interface{}{"VehicleCD": float64(2), "Extra1": "b"},
+		"not a record",
+	}
+
+	telemetry, issues := DecodeAll(records)
+	if len(telemetry) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(telemetry))
+	}
+	if telemetry[1].VehicleCD != 2 {
+		t.Errorf("Expected second VehicleCD 2, got %d", telemetry[1].VehicleCD)
+	}
+	// The unknown field is reported once, plus the non-object record
+	if len(issues) != 2 {
+		t.Errorf("Expected 2 issues, got %v", issues)
+	}
+}
@@ -0,0 +1,75 @@
+package vehicle
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Plausible range of a refrigeration sensor; values outside are treated as
+// "no sensor" markers rather than readings.
+const (
+	minTemperature = -60.0
+	maxTemperature = 80.0
+)
+
+// Compartment is one refrigerated compartment's reading.
+type Compartment struct {
+	Index    int     `json:"index"` // 1-4, matching Temp1-Temp4
+	Temp     float64 `json:"temp"`
+	SetPoint float64 `json:"set_point"`
+}
+
+// Compartments returns the compartments that have both a temperature and a
+// set point. Compartment N uses SettingTempN where the schema has it and
+// SettingTemp otherwise (there is no SettingTemp2 column).
+func (t *Telemetry) Compartments() []Compartment {
+	temps := [4]string{t.Temp1, t.Temp2, t.Temp3, t.Temp4}
+	settings := [4]string{t.SettingTemp1, t.SettingTemp, t.SettingTemp3, t.SettingTemp4}
+
+	var compartments []Compartment
+	for i := range temps {
+		temp, ok := ParseTemperature(temps[i])
+		if !ok {
+			continue
+		}
+		setting := settings[i]
+		if strings.TrimSpace(setting) == "" {
+			setting = t.SettingTemp
+		}
+		setPoint, ok := ParseTemperature(setting)
+		if !ok {
+			continue
+		}
+		compartments = append(compartments, Compartment{Index: i + 1, Temp: temp, SetPoint: setPoint})
+	}
+	return compartments
+}
+
+// ActiveCompartments returns Compartments unless TempState is one of
+// offStates, the values meaning the refrigeration unit is off or not in
+// service.
+func (t *Telemetry) ActiveCompartments(offStates []int) []Compartment {
+	for _, s := range offStates {
+		if t.TempState == s {
+			return nil
+		}
+	}
+	return t.Compartments()
+}
+
+// ParseTemperature parses a portal temperature such as "-18.5", "-18.5℃" or
+// "－５℃". Empty values, dashes and out-of-range sensor markers are not
+// readings.
+func ParseTemperature(s string) (float64, bool) {
+	s = strings.TrimSpace(hyphenReplacer.Replace(norm.NFKC.String(s)))
+	for _, suffix := range []string{"°C", "℃", "度", "C"} {
+		s = strings.TrimSuffix(s, suffix)
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || v < minTemperature || v > maxTemperature {
+		return 0, false
+	}
+	return v, true
+}
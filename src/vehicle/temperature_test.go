@@ -0,0 +1,66 @@
+package vehicle
+
+import "testing"
+
+func TestParseTemperature(t *testing.T) {
+	tests := []struct {
+		input string
+		want  float64
+		ok    bool
+	}{
+		{"-18.5", -18.5, true},
+		{"5", 5, true},
+		{"-18.5℃", -18.5, true},
+		{"－５℃", -5, true},
+		{" 3.0 °C ", 3, true},
+		{"", 0, false},
+		{"---", 0, false},
+		{"-999", 0, false},
+		{"<nil>", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseTemperature(tt.input)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseTemperature(%q) = %v, %v, want %v, %v", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestTelemetry_Compartments(t *testing.T) {
+	tm := &Telemetry{
+		Temp1: "-18.0", SettingTemp1: "-20",
+		Temp2: "4.5", SettingTemp: "5",
+		Temp3: "---", SettingTemp3: "0",
+		Temp4: "2.0",
+	}
+
+	got := tm.Compartments()
+	want := []Compartment{
+		{Index: 1, Temp: -18, SetPoint: -20},
+		{Index: 2, Temp: 4.5, SetPoint: 5},
+		{Index: 4, Temp: 2, SetPoint: 5}, // falls back to SettingTemp
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d compartments, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Compartment %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestTelemetry_ActiveCompartments(t *testing.T) {
+	tm := &Telemetry{Temp1: "-18.0", SettingTemp1: "-20", TempState: 1}
+	if got := tm.ActiveCompartments([]int{0}); len(got) != 1 {
+		t.Errorf("Expected the running unit's compartment, got %+v", got)
+	}
+	tm.TempState = 0
+	if got := tm.ActiveCompartments([]int{0}); got != nil {
+		t.Errorf("Expected no compartments while the unit is off, got %+v", got)
+	}
+	if got := tm.ActiveCompartments(nil); len(got) != 1 {
+		t.Errorf("Expected every compartment without off states, got %+v", got)
+	}
+}
@@ -0,0 +1,100 @@
+package vehicle
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tokyo is the portal's time zone. Japan has no daylight saving time, so a
+// fixed +09:00 zone is used when the tz database is unavailable.
+var Tokyo = func() *time.Location {
+	loc, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		return time.FixedZone("JST", 9*60*60)
+	}
+	return loc
+}()
+
+// portalLayouts are the local-time formats used by the portal. Two-digit
+// years ("25/01/15 10:30", the dtakologs default "20/1/1 00:00") are 20xx.
+var portalLayouts = []string{
+	"2006/1/2 15:04:05",
+	"2006/1/2 15:04",
+	"2006/1/2",
+	"06/1/2 15:04:05",
+	"06/1/2 15:04",
+	"06/1/2",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// wcfDatePattern matches the WCF JSON date form "/Date(1700000000000+0900)/".
+var wcfDatePattern = regexp.MustCompile(`^/Date\((-?\d+)([+-]\d{4})?\)/$`)
+
+// ParseTime parses a timestamp from the portal or an API client. It accepts
+// the portal's local formats (read as Asia/Tokyo), RFC3339, WCF "/Date(ms)/"
+// values and Unix epoch seconds. Empty values and "<nil>" return the zero
+// time without an error.
+func ParseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "<nil>" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+
+	if m := wcfDatePattern.FindStringSubmatch(s); m != nil {
+		ms, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp %q: %w", s, err)
+		}
+		return time.UnixMilli(ms).In(Tokyo), nil
+	}
+
+	if isDigits(s) {
+		sec, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp %q: %w", s, err)
+		}
+		return time.Unix(sec, 0).In(Tokyo), nil
+	}
+
+	for _, layout := range portalLayouts {
+		if t, err := time.ParseInLocation(layout, s, Tokyo); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", s)
+}
+
+// DataTime returns the parsed DataDateTime.
+func (t *Telemetry) DataTime() (time.Time, error) {
+	return ParseTime(t.DataDateTime)
+}
+
+// ComuTime returns the parsed ComuDateTime.
+func (t *Telemetry) ComuTime() (time.Time, error) {
+	return ParseTime(t.ComuDateTime)
+}
+
+// StartWorkTime returns the parsed StartWorkDateTime.
+func (t *Telemetry) StartWorkTime() (time.Time, error) {
+	return ParseTime(t.StartWorkDateTime)
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,74 @@
+package vehicle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  time.Time
+	}{
+		{"four-digit year", "2025/09/29 10:30", time.Date(2025, 9, 29, 10, 30, 0, 0, Tokyo)},
+		{"four-digit year with seconds", "2025/9/29 10:30:15", time.Date(2025, 9, 29, 10, 30, 15, 0, Tokyo)},
+		{"two-digit year", "25/09/29 10:30", time.Date(2025, 9, 29, 10, 30, 0, 0, Tokyo)},
+		{"dtakologs default", "20/1/1 00:00", time.Date(2020, 1, 1, 0, 0, 0, 0, Tokyo)},
+		{"ISO without zone", "2025-09-29 10:30:00", time.Date(2025, 9, 29, 10, 30, 0, 0, Tokyo)},
+		{"RFC3339", "2025-09-29T01:30:00Z", time.Date(2025, 9, 29, 10, 30, 0, 0, Tokyo)},
+		{"epoch seconds", "1759109400", time.Date(2025, 9, 29, 10, 30, 0, 0, Tokyo)},
+		{"WCF date", "/Date(1759109400000+0900)/", time.Date(2025, 9, 29, 10, 30, 0, 0, Tokyo)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTime(tt.input)
+			if err != nil {
+				t.Fatalf("ParseTime(%q) failed: %v", tt.input, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseTime(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTime_EmptyAndInvalid(t *testing.T) {
+	for _, s := range []string{"", "  ", "<nil>"} {
+		got, err := ParseTime(s)
+		if err != nil || !got.IsZero() {
+			t.Errorf("ParseTime(%q) = %v, %v; want zero time", s, got, err)
+		}
+	}
+
+	for _, s := range []string{"yesterday", "2025/13/01 10:00", "25/09/29 25:00"} {
+		if _, err := ParseTime(s); err == nil {
+			t.Errorf("Expected error for %q", s)
+		}
+	}
+}
+
+func TestParseTime_Ordering(t *testing.T) {
+	// Two-digit years must not sort before four-digit ones once parsed
+	a, _ := ParseTime("25/12/31 23:59")
+	b, _ := ParseTime("2026/01/01 00:00")
+	if !a.Before(b) {
+		t.Errorf("Expected %v before %v", a, b)
+	}
+}
+
+func TestTelemetry_Times(t *testing.T) {
+	tm := &Telemetry{DataDateTime: "25/09/29 10:30", StartWorkDateTime: "bad"}
+
+	dt, err := tm.DataTime()
+	if err != nil || dt.Hour() != 10 || dt.Location() != Tokyo {
+		t.Errorf("Unexpected DataTime: %v, %v", dt, err)
+	}
+	if ct, err := tm.ComuTime(); err != nil || !ct.IsZero() {
+		t.Errorf("Expected zero ComuTime, got %v, %v", ct, err)
+	}
+	if _, err := tm.StartWorkTime(); err == nil {
+		t.Error("Expected error for invalid StartWorkDateTime")
+	}
+}